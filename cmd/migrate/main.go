@@ -43,7 +43,7 @@ func main(){
     log.Fatalf("Unknown service: \"%s\"", svc)
   }
   if svc == "-auth" && database != "-pg" {
-    log.Fatal("Auth service uses has Postgres, not \"%s\"", svc)
+    log.Fatalf("Auth service uses has Postgres, not \"%s\"", svc)
   }
 
   switch database{
@@ -0,0 +1,7 @@
+-- 002_schema_compatibility.down.sql
+ALTER TABLE schemas
+  DROP CONSTRAINT IF EXISTS schemas_subject_version_key,
+  DROP COLUMN IF EXISTS version,
+  DROP COLUMN IF EXISTS subject_id;
+DROP TABLE IF EXISTS subjects;
+DROP TABLE IF EXISTS schema_settings;
@@ -0,0 +1,25 @@
+-- 002_schema_compatibility.up.sql
+
+CREATE TABLE schema_settings (
+  entity_id UUID PRIMARY KEY,                            -- References the Entity these settings belong to
+  compatibility VARCHAR(32) NOT NULL DEFAULT 'BACKWARD', -- Entity's default Subject compatibility mode
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,        -- Datetime - When settings were created
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,        -- Datetime - When settings were last updated.
+  FOREIGN KEY (entity_id) REFERENCES entities(id) ON DELETE CASCADE
+);
+
+CREATE TABLE subjects (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),  -- Subject Unique ID
+  entity_id UUID NOT NULL,                        -- Foreign key - Links to parent Entity's ID
+  name VARCHAR(256) NOT NULL,                     -- Subject's Name, unique per Entity
+  compatibility VARCHAR(32),                      -- Optional override of the Entity's compatibility mode
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Datetime - When Subject was created
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Datetime - When Subject was last updated.
+  UNIQUE (entity_id, name),
+  FOREIGN KEY (entity_id) REFERENCES entities(id) ON DELETE CASCADE
+);
+
+ALTER TABLE schemas
+  ADD COLUMN subject_id UUID REFERENCES subjects(id) ON DELETE CASCADE, -- Subject this Schema is a version of
+  ADD COLUMN version INTEGER,                                           -- Subject version, starting at 1
+  ADD CONSTRAINT schemas_subject_version_key UNIQUE (subject_id, version);
@@ -0,0 +1,5 @@
+-- 003_schema_versioning.down.sql
+ALTER TABLE schemas
+  DROP COLUMN IF EXISTS tag;
+ALTER TABLE schema_settings
+  DROP COLUMN IF EXISTS versioning;
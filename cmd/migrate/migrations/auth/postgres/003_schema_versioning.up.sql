@@ -0,0 +1,7 @@
+-- 003_schema_versioning.up.sql
+
+ALTER TABLE schema_settings
+  ADD COLUMN versioning VARCHAR(32) NOT NULL DEFAULT 'PACKAGE_SUFFIX'; -- Entity's package versioning scheme
+
+ALTER TABLE schemas
+  ADD COLUMN tag VARCHAR(128); -- Optional semver tag supplied with the upload
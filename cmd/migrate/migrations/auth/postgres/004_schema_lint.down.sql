@@ -0,0 +1,3 @@
+-- 004_schema_lint.down.sql
+ALTER TABLE schema_settings
+  DROP COLUMN IF EXISTS lint;
@@ -0,0 +1,4 @@
+-- 004_schema_lint.up.sql
+
+ALTER TABLE schema_settings
+  ADD COLUMN lint JSONB NOT NULL DEFAULT '{"rule_set": "DEFAULT"}'; -- Entity's lint rule set, excepted rules and ignored paths
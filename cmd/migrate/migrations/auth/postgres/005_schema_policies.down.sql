@@ -0,0 +1,2 @@
+-- 005_schema_policies.down.sql
+DROP TABLE IF EXISTS schema_policies;
@@ -0,0 +1,15 @@
+-- 005_schema_policies.up.sql
+
+CREATE TABLE schema_policies (
+  entity_id UUID NOT NULL,                        -- References the Entity this Policy belongs to
+  name VARCHAR(128) NOT NULL,                     -- Policy's Name, unique per Entity
+  description TEXT,                               -- Optional message reported with each violation
+  target VARCHAR(32) NOT NULL,                    -- Element the Policy is evaluated against, e.g. MESSAGE or METHOD
+  packages TEXT[] NOT NULL DEFAULT '{}',          -- Package patterns the Policy applies to, empty for every package
+  expression TEXT NOT NULL,                       -- CEL expression, true when an element complies
+  severity VARCHAR(16) NOT NULL DEFAULT 'error',  -- Severity violations are reported with
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Datetime - When Policy was created
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Datetime - When Policy was last updated.
+  PRIMARY KEY (entity_id, name),
+  FOREIGN KEY (entity_id) REFERENCES entities(id) ON DELETE CASCADE
+);
@@ -0,0 +1,7 @@
+-- 006_schema_version_catalog.down.sql
+DROP TRIGGER IF EXISTS schemas_immutable ON schemas;
+DROP FUNCTION IF EXISTS schemas_immutable();
+ALTER TABLE schemas
+  DROP COLUMN IF EXISTS deleted_at,
+  DROP COLUMN IF EXISTS author_id,
+  DROP COLUMN IF EXISTS manifest;
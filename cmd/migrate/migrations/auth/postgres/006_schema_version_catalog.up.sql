@@ -0,0 +1,27 @@
+-- 006_schema_version_catalog.up.sql
+
+ALTER TABLE schemas
+  ADD COLUMN manifest JSONB,                                            -- Path and digest of every file in the version
+  ADD COLUMN author_id UUID REFERENCES accounts(id) ON DELETE SET NULL, -- Account that published the version
+  ADD COLUMN deleted_at TIMESTAMP;                                      -- Set while the version is soft-deleted
+
+-- Published versions can only be soft-deleted and restored, never rewritten.
+CREATE FUNCTION schemas_immutable() RETURNS TRIGGER AS $$
+BEGIN
+  IF NEW.subject_id IS DISTINCT FROM OLD.subject_id
+  OR NEW.version    IS DISTINCT FROM OLD.version
+  OR NEW.tag        IS DISTINCT FROM OLD.tag
+  OR NEW.blob_url   IS DISTINCT FROM OLD.blob_url
+  OR NEW.manifest   IS DISTINCT FROM OLD.manifest
+  OR NEW.author_id  IS DISTINCT FROM OLD.author_id AND NEW.author_id IS NOT NULL THEN
+    RAISE EXCEPTION 'schema version % of subject % is immutable', OLD.version, OLD.subject_id;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER schemas_immutable
+  BEFORE UPDATE ON schemas
+  FOR EACH ROW
+  WHEN (OLD.version IS NOT NULL)
+  EXECUTE FUNCTION schemas_immutable();
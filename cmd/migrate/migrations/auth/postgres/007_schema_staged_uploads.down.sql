@@ -0,0 +1,2 @@
+-- 007_schema_staged_uploads.down.sql
+DROP TABLE IF EXISTS schema_staged_uploads;
@@ -0,0 +1,15 @@
+-- 007_schema_staged_uploads.up.sql
+
+CREATE TABLE schema_staged_uploads (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),                    -- Staged Upload Unique ID
+  entity_id UUID NOT NULL,                                          -- References the Entity uploading the files
+  account_id UUID REFERENCES accounts(id) ON DELETE SET NULL,       -- Account that started the upload
+  subject VARCHAR(256) NOT NULL,                                    -- Subject the files will be published under
+  tag VARCHAR(128),                                                 -- Optional semver tag for the published version
+  files TEXT[] NOT NULL,                                            -- Import path of every expected file
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,                   -- Datetime - When the upload was started
+  expires_at TIMESTAMP NOT NULL,                                    -- Datetime - When the upload can no longer be finalized
+  FOREIGN KEY (entity_id) REFERENCES entities(id) ON DELETE CASCADE
+);
+
+CREATE INDEX schema_staged_uploads_expires_at_idx ON schema_staged_uploads (expires_at);
@@ -0,0 +1,2 @@
+-- 008_schema_tag_unique.down.sql
+DROP INDEX IF EXISTS schemas_subject_tag_key;
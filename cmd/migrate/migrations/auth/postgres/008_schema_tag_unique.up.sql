@@ -0,0 +1,6 @@
+-- 008_schema_tag_unique.up.sql
+
+-- Tags are unique among a subject's versions that aren't soft-deleted.
+CREATE UNIQUE INDEX schemas_subject_tag_key
+  ON schemas (subject_id, tag)
+  WHERE tag IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,17 @@
+-- 009_schema_version_scheme.down.sql
+CREATE OR REPLACE FUNCTION schemas_immutable() RETURNS TRIGGER AS $$
+BEGIN
+  IF NEW.subject_id IS DISTINCT FROM OLD.subject_id
+  OR NEW.version    IS DISTINCT FROM OLD.version
+  OR NEW.tag        IS DISTINCT FROM OLD.tag
+  OR NEW.blob_url   IS DISTINCT FROM OLD.blob_url
+  OR NEW.manifest   IS DISTINCT FROM OLD.manifest
+  OR NEW.author_id  IS DISTINCT FROM OLD.author_id AND NEW.author_id IS NOT NULL THEN
+    RAISE EXCEPTION 'schema version % of subject % is immutable', OLD.version, OLD.subject_id;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE schemas
+  DROP COLUMN IF EXISTS versioning;
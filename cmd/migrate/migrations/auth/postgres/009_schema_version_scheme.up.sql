@@ -0,0 +1,31 @@
+-- 009_schema_version_scheme.up.sql
+
+-- Versions are recompiled under the scheme they were published with, so an
+-- Entity changing its scheme never changes how its past versions resolve.
+ALTER TABLE schemas
+  ADD COLUMN versioning VARCHAR(32); -- VersioningScheme the version was compiled under
+
+UPDATE schemas s
+SET versioning = COALESCE(
+  (SELECT versioning FROM schema_settings WHERE entity_id = s.entity_id),
+  'PACKAGE_SUFFIX'
+);
+
+ALTER TABLE schemas
+  ALTER COLUMN versioning SET NOT NULL,
+  ALTER COLUMN versioning SET DEFAULT 'PACKAGE_SUFFIX';
+
+CREATE OR REPLACE FUNCTION schemas_immutable() RETURNS TRIGGER AS $$
+BEGIN
+  IF NEW.subject_id IS DISTINCT FROM OLD.subject_id
+  OR NEW.version    IS DISTINCT FROM OLD.version
+  OR NEW.tag        IS DISTINCT FROM OLD.tag
+  OR NEW.blob_url   IS DISTINCT FROM OLD.blob_url
+  OR NEW.manifest   IS DISTINCT FROM OLD.manifest
+  OR NEW.versioning IS DISTINCT FROM OLD.versioning
+  OR NEW.author_id  IS DISTINCT FROM OLD.author_id AND NEW.author_id IS NOT NULL THEN
+    RAISE EXCEPTION 'schema version % of subject % is immutable', OLD.version, OLD.subject_id;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
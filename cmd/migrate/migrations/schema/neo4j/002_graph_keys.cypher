@@ -0,0 +1,8 @@
+// -> Graph nodes are MERGEd on their fully-qualified key.
+CREATE CONSTRAINT package_key IF NOT EXISTS FOR (n:Package) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT message_key IF NOT EXISTS FOR (n:Message) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT parameter_key IF NOT EXISTS FOR (n:Parameter) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT enum_key IF NOT EXISTS FOR (n:Enum) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT enum_value_key IF NOT EXISTS FOR (n:EnumValue) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT service_key IF NOT EXISTS FOR (n:Service) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT method_key IF NOT EXISTS FOR (n:Method) REQUIRE n.key IS UNIQUE;
@@ -0,0 +1,8 @@
+// -> OpenAPI Files
+CREATE CONSTRAINT api_key IF NOT EXISTS FOR (n:API) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT api_path_key IF NOT EXISTS FOR (n:APIPath) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT operation_key IF NOT EXISTS FOR (n:Operation) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT api_parameter_key IF NOT EXISTS FOR (n:APIParameter) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT request_body_key IF NOT EXISTS FOR (n:RequestBody) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT response_key IF NOT EXISTS FOR (n:Response) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT component_schema_key IF NOT EXISTS FOR (n:ComponentSchema) REQUIRE n.key IS UNIQUE;
@@ -0,0 +1,7 @@
+// -> GraphQL Files
+CREATE CONSTRAINT graphql_type_key IF NOT EXISTS FOR (n:GraphQLType) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT graphql_field_key IF NOT EXISTS FOR (n:GraphQLField) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT graphql_operation_key IF NOT EXISTS FOR (n:GraphQLOperation) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT graphql_argument_key IF NOT EXISTS FOR (n:GraphQLArgument) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT graphql_enum_value_key IF NOT EXISTS FOR (n:GraphQLEnumValue) REQUIRE n.key IS UNIQUE;
+CREATE CONSTRAINT graphql_directive_key IF NOT EXISTS FOR (n:GraphQLDirective) REQUIRE n.key IS UNIQUE;
@@ -0,0 +1,2 @@
+// -> Proto Oneof groups
+CREATE CONSTRAINT oneof_key IF NOT EXISTS FOR (n:Oneof) REQUIRE n.key IS UNIQUE;
@@ -0,0 +1,2 @@
+// -> proto2 and editions extensions
+CREATE CONSTRAINT extension_key IF NOT EXISTS FOR (n:Extension) REQUIRE n.key IS UNIQUE;
@@ -0,0 +1,2 @@
+// -> Latest version lookups by unversioned package name
+CREATE INDEX package_name IF NOT EXISTS FOR (n:Package) ON (n.name);
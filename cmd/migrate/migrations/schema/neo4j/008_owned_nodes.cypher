@@ -0,0 +1,5 @@
+// -> Stale nodes are matched by Owner whenever their Package is rewritten.
+CREATE INDEX owned_owner IF NOT EXISTS FOR (n:Owned) ON (n.owner);
+// -> Shared imports, stored under their plain key, are only ever merged.
+MATCH (n) WHERE n.owner IS NOT NULL AND NOT n.owner CONTAINS '/' REMOVE n.owner;
+MATCH (n) WHERE n.owner IS NOT NULL SET n:Owned;
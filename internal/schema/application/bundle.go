@@ -0,0 +1,105 @@
+package application
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+)
+
+const (
+  // ReservedPathPrefix -- The directory bundles write their own files under.
+  // Uploaded schema files may not use it, see CleanSchemaPath.
+  ReservedPathPrefix = ".fidicus/"
+  // BundleManifestFile -- The path a bundle's domain.BundleManifest is written to.
+  BundleManifestFile = ReservedPathPrefix + "manifest.json"
+)
+
+// WriteBundle - Writes every file of 'bundle' into a 'format' archive,
+// in path order, followed by its manifest under BundleManifestFile.
+//
+// Potential Errors:
+//   - ErrInvalidArchive :: 'format' isn't a supported ArchiveFormat.
+func WriteBundle(
+  w      io.Writer,
+  format ArchiveFormat,
+  bundle *domain.SchemaBundle,
+) error {
+  manifest, err := json.MarshalIndent(bundle.Manifest, "", "  ")
+  if err != nil {
+    return err
+  }
+
+  paths := make([]string, 0, len(bundle.Files))
+  for p := range bundle.Files {
+    paths = append(paths, p)
+  }
+  sort.Strings(paths)
+
+  entries := make([]bundleEntry, 0, len(paths)+1)
+  for _, p := range paths {
+    entries = append(entries, bundleEntry{ p, bundle.Files[p] })
+  }
+  entries = append(entries, bundleEntry{ BundleManifestFile, manifest })
+
+  switch format {
+  case ArchiveZip:
+    zw := zip.NewWriter(w)
+    for _, e := range entries {
+      f, err := zw.CreateHeader(&zip.FileHeader{
+        Name     : e.path,
+        Method   : zip.Deflate,
+        Modified : bundle.Manifest.CreatedAt,
+      })
+      if err != nil {
+        return err
+      }
+      if _, err := f.Write(e.data); err != nil {
+        return err
+      }
+    }
+    return zw.Close()
+  case ArchiveTarGz:
+    gz := gzip.NewWriter(w)
+    if err := writeTar(gz, entries, bundle); err != nil {
+      return err
+    }
+    return gz.Close()
+  case ArchiveTar:
+    return writeTar(w, entries, bundle)
+  }
+  return fmt.Errorf("%w: unsupported format %q", ErrInvalidArchive, format)
+}
+
+type bundleEntry struct {
+  path string
+  data []byte
+}
+
+func writeTar(
+  w       io.Writer,
+  entries []bundleEntry,
+  bundle  *domain.SchemaBundle,
+) error {
+  tw := tar.NewWriter(w)
+  for _, e := range entries {
+    if err := tw.WriteHeader(&tar.Header{
+      Name     : e.path,
+      Mode     : 0o644,
+      Size     : int64(len(e.data)),
+      ModTime  : bundle.Manifest.CreatedAt,
+      Typeflag : tar.TypeReg,
+    }); err != nil {
+      return err
+    }
+    if _, err := tw.Write(e.data); err != nil {
+      return err
+    }
+  }
+  return tw.Close()
+}
@@ -0,0 +1,88 @@
+package application
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+)
+
+func TestWriteBundle(t *testing.T) {
+  bundle := &domain.SchemaBundle{
+    Manifest : domain.BundleManifest{
+      Subject   : "orders",
+      Version   : 3,
+      CreatedAt : time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+      Files     : []domain.BundleFile{
+        { Path: "acme/v1/a.proto", SHA256: "aa", Size: 1, Version: 3 },
+        { Path: "acme/v1/b.proto", SHA256: "bb", Size: 1, Version: 3 },
+      },
+    },
+    Files    : map[string][]byte{
+      "acme/v1/b.proto" : []byte("b"),
+      "acme/v1/a.proto" : []byte("a"),
+    },
+  }
+
+  for _, format := range []ArchiveFormat{ ArchiveZip, ArchiveTarGz, ArchiveTar }{
+    var buf bytes.Buffer
+    assert.NoError(t, WriteBundle(&buf, format, bundle), format)
+
+    // ->> Bundles can be uploaded again as is, the manifest is skipped.
+    files, err := ExtractArchive(format, bytes.NewReader(buf.Bytes()), "")
+    assert.NoError(t, err, format)
+    assert.Equal(t, bundle.Files, files, format)
+  }
+
+  var buf bytes.Buffer
+  assert.NoError(t, WriteBundle(&buf, ArchiveZip, bundle))
+  archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+  assert.NoError(t, err)
+
+  names := []string{}
+  for _, f := range archive.File {
+    names = append(names, f.Name)
+  }
+  assert.Equal(t, []string{ "acme/v1/a.proto", "acme/v1/b.proto", BundleManifestFile }, names)
+
+  rc, err := archive.File[2].Open()
+  assert.NoError(t, err)
+  data, _ := io.ReadAll(rc)
+  var manifest domain.BundleManifest
+  assert.NoError(t, json.Unmarshal(data, &manifest))
+  assert.Equal(t, bundle.Manifest, manifest)
+
+  assert.ErrorIs(t, WriteBundle(&buf, "rar", bundle), ErrInvalidArchive)
+}
+
+func TestFindVersion(t *testing.T) {
+  versions := []domain.SchemaVersion{
+    { Version: 1, Tag: "1.0.0" },
+    { Version: 2, Tag: "1.1.0" },
+    { Version: 3 },
+  }
+  for id, want := range map[string]int{
+    "latest" : 3,
+    ""       : 3,
+    "1"      : 1,
+    "1.1.0"  : 2,
+    "v1.0.0" : 1,
+  }{
+    v, err := findVersion(versions, id)
+    if assert.NoError(t, err, id) {
+      assert.Equal(t, want, v.Version, id)
+    }
+  }
+  for _, id := range []string{ "4", "2.0.0", "nope" }{
+    _, err := findVersion(versions, id)
+    assert.ErrorIs(t, err, ErrSchemaVersionNotFound, id)
+  }
+  _, err := findVersion(nil, "latest")
+  assert.ErrorIs(t, err, ErrSchemaVersionNotFound)
+}
@@ -0,0 +1,25 @@
+package application
+
+import (
+	"errors"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+)
+
+var (
+  ErrInvalidUploadRequest  = errors.New("schema upload requires a subject and at least one file")
+  ErrSchemaCompileFailed   = errors.New("failed to compile uploaded schema")
+  ErrSchemaHistoryFailed   = errors.New("failed to load previous schema versions")
+  ErrInvalidVersionTag     = errors.New("schema upload requires a valid semver tag")
+  ErrVersionTagExists      = domain.ErrVersionTagExists
+  ErrSchemaVersionNotFound = errors.New("schema version not found")
+  ErrPolicyViolated        = errors.New("schema violates entity policies")
+  ErrInvalidSchemaPath     = errors.New("schema file paths must be relative and stay within the upload")
+  ErrInvalidArchive        = errors.New("failed to read uploaded schema archive")
+  ErrUploadTooLarge        = errors.New("schema upload is too large")
+  ErrSchemaGraphFailed     = errors.New("failed to write schema graph")
+  ErrSchemaFileNotFound    = errors.New("schema file not found in version")
+  ErrStagedUploadExpired   = errors.New("staged upload has expired")
+  ErrStagedFileMissing     = errors.New("staged upload is missing files")
+  ErrDescriptorUnsupported = errors.New("schema format has no file descriptors to export")
+)
@@ -2,22 +2,36 @@ package application
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
+	"github.com/TylerAldrich814/Fidicus/internal/shared/utils"
 )
 
 type Service struct {
-  blob domain.SchemaBlobRepository
-  grph domain.SchemaGraphRepository
-  psql domain.SchemaSQLRepository
+  blob   domain.SchemaBlobRepository
+  grph   domain.SchemaGraphRepository
+  psql   domain.SchemaSQLRepository
+  loader domain.SchemaLoader
 }
 
 func NewService(
-  blob domain.SchemaBlobRepository,
-  grph domain.SchemaGraphRepository,
-  psql domain.SchemaSQLRepository,
+  blob   domain.SchemaBlobRepository,
+  grph   domain.SchemaGraphRepository,
+  psql   domain.SchemaSQLRepository,
+  loader domain.SchemaLoader,
 ) *Service {
-  return &Service{ blob, grph, psql, }
+  return &Service{ blob, grph, psql, loader }
 }
 
 func(s *Service) Shutdown() error {
@@ -39,9 +53,1006 @@ func(s *Service) Shutdown() error {
   return nil
 }
 
+// UploadSchemaRequest defines a new Subject version to be registered.
+// Files are keyed by their import path relative to the Subject root, see
+// CleanSchemaPath. Descriptor is a FileDescriptorSet, binary or protojson
+// encoded, uploaded instead of Files. Tag is required when the Entity uses
+// domain.VersioningSemver. AccountID is recorded as the version's author.
+type UploadSchemaRequest struct {
+  EntityID   users.EntityID
+  AccountID  users.AccountID
+  Subject    string
+  Tag        string
+  Files      map[string][]byte
+  Descriptor []byte
+}
+
+// UploadSchemaResult defines an accepted Subject version, along with any
+// warnings reported while compiling it.
+type UploadSchemaResult struct {
+  *domain.SchemaVersion
+  Diagnostics []domain.Diagnostic `json:"diagnostics,omitempty"`
+  // Unchanged is true when the upload matched the Subject's latest version,
+  // which is returned as is rather than registering a new one.
+  Unchanged   bool                `json:"unchanged,omitempty"`
+}
+
+// UploadSchema - Compiles the uploaded files, checks them against the Entity's
+// Policies and the Subject's CompatibilityMode and, when accepted, stores them
+// under the Subject's next version, writes their graph and records the
+// version. New Subjects are only registered along with their first accepted
+// version. Compile errors and error severity Policy violations implement
+// domain.DiagnosticError, listing every problem found.
+//
+// File contents are stored as content-addressed blobs, referenced by the
+// version's domain.VersionManifest, so unchanged files are only stored once.
+// Graph keys of everything the Entity defines itself are prefixed with its
+// domain.GraphNamespace, so Entities sharing package names never collide.
+// Packages are versioned by the Entity's VersioningScheme, using the new
+// version's number or tag. The scheme is recorded with the version, which is
+// always recompiled under it.
+// Uploading the exact files of the latest version, under the same or no tag,
+// returns that version instead of registering a new one. Soft-deleted
+// versions are never compared against, but keep their version number and tag.
+//
+// A Descriptor is stored as is, as the version's only file, under
+// domain.DescriptorSetPath. It's linked and run through the same graph, lint
+// and compatibility checks as uploaded sources.
+//
+// Potential Errors:
+//   - ErrInvalidUploadRequest
+//   - ErrInvalidSchemaPath
+//   - ErrUploadTooLarge
+//   - ErrInvalidVersionTag
+//   - ErrVersionTagExists
+//   - ErrSchemaCompileFailed
+//   - ErrPolicyViolated
+//   - ErrSchemaHistoryFailed
+//   - ErrSchemaGraphFailed
+//   - *domain.CompatibilityError :: The upload breaks the Subject's CompatibilityMode.
+//   - Any error returned by the Blob or SQL repositories.
 func(s *Service) UploadSchema(
   ctx context.Context,
+  req UploadSchemaRequest,
+)( *UploadSchemaResult, error ){
+  var pushLog = utils.NewLogHandlerFunc(
+    "UploadSchema",
+    log.Fields{
+      "entity_id" : req.EntityID.String(),
+      "subject"   : req.Subject,
+    },
+  )
+  if len(req.Descriptor) != 0 {
+    if len(req.Files) != 0 {
+      return nil, fmt.Errorf("%w: a descriptor set is uploaded on its own", ErrInvalidUploadRequest)
+    }
+    req.Files = map[string][]byte{
+      domain.DescriptorSetPath(req.Descriptor): req.Descriptor,
+    }
+  }
+  if !domain.IsSubjectName(req.Subject) || len(req.Files) == 0 {
+    return nil, ErrInvalidUploadRequest
+  }
+  files, err := cleanUploadFiles(req.Files)
+  if err != nil {
+    return nil, err
+  }
+
+  scheme, tag, err := s.resolveTag(ctx, req.EntityID, req.Tag)
+  if err != nil {
+    return nil, err
+  }
+
+  // ->> New Subjects are only registered along with their first version,
+  //     so rejected uploads never leave an empty Subject behind.
+  subject, err := s.psql.GetSubject(ctx, req.EntityID, req.Subject)
+  if errors.Is(err, domain.ErrSubjectNotFound) {
+    subject, err = &domain.Subject{ EntityID: req.EntityID, Name: req.Subject }, nil
+  }
+  if err != nil {
+    return nil, err
+  }
+  mode, err := s.resolveCompatibility(ctx, subject)
+  if err != nil {
+    return nil, err
+  }
+
+  versions := []domain.SchemaVersion{}
+  if subject.ID != uuid.Nil {
+    if versions, err = s.psql.ListSchemaVersions(ctx, subject.ID); err != nil {
+      return nil, err
+    }
+  }
+  next := 1
+  if len(versions) != 0 {
+    next = versions[len(versions)-1].Version + 1
+  }
+
+  // ->> Packages are versioned by the version being registered, so the
+  //     upload is only compiled once its version number is known.
+  candidate, err := s.loader(ctx, loadContext(subject.Name, domain.SchemaVersion{
+    EntityID   : req.EntityID,
+    Version    : next,
+    Tag        : tag,
+    Versioning : scheme,
+  }), files)
+  if err != nil {
+    pushLog(utils.LogErro, "failed to compile uploaded schema: %s", err.Error())
+    return nil, fmt.Errorf("%w: %w", ErrSchemaCompileFailed, err)
+  }
+
+  active   := domain.ActiveVersions(versions)
+  manifest := domain.NewVersionManifest(files)
+  if len(active) != 0 {
+    latest := active[len(active)-1]
+    if tag == "" || tag == latest.Tag {
+      stored := latest.Manifest
+      if stored == nil {
+        stored, _ = s.versionManifest(ctx, subject.Name, latest)
+      }
+      if stored != nil && stored.Equal(manifest) {
+        return &UploadSchemaResult{
+          SchemaVersion : &latest,
+          Diagnostics   : diagnosticsOf(candidate),
+          Unchanged     : true,
+        }, nil
+      }
+    }
+  }
+
+  if tag != "" {
+    for _, v := range versions {
+      if v.Tag == tag {
+        return nil, fmt.Errorf("%w: %q", ErrVersionTagExists, tag)
+      }
+    }
+  }
+
+  // ->> Only load the versions the CompatibilityMode will actually compare against.
+  var history []domain.VersionedSchema
+  if mode != domain.CompatibilityNone && len(active) != 0 {
+    against := active
+    if !mode.IsTransitive() {
+      against = active[len(active)-1:]
+    }
+    for _, v := range against {
+      schema, err := s.loadVersion(ctx, subject.Name, v)
+      if err != nil {
+        pushLog(utils.LogErro, "failed to load version %d: %s", v.Version, err.Error())
+        return nil, fmt.Errorf("%w: version %d", ErrSchemaHistoryFailed, v.Version)
+      }
+      history = append(history, domain.VersionedSchema{
+        Version : v.Version,
+        Schema  : schema,
+      })
+    }
+  }
+
+  lint, err := s.lint(ctx, req.EntityID, candidate)
+  if err != nil {
+    return nil, err
+  }
+  policies, err := s.evaluatePolicies(ctx, req.EntityID, candidate)
+  if err != nil {
+    return nil, err
+  }
+  diagnostics := append(append(diagnosticsOf(candidate), lint...), policies...)
+  for _, d := range policies {
+    if d.Severity == domain.SeverityError {
+      pushLog(utils.LogErro, "upload violates policy %q", d.Rule)
+      return nil, fmt.Errorf("%w: %w", ErrPolicyViolated, &domain.DiagnosticsError{ Items: diagnostics })
+    }
+  }
+
+  violations, err := mode.Check(candidate, history)
+  if err != nil {
+    return nil, err
+  }
+  if len(violations) != 0 {
+    return nil, &domain.CompatibilityError{
+      Subject    : subject.Name,
+      Mode       : mode,
+      Violations : violations,
+    }
+  }
+
+  prefix, err := domain.VersionPrefix(req.EntityID, subject.Name, next)
+  if err != nil {
+    return nil, err
+  }
+  version := &domain.SchemaVersion{
+    SubjectID  : subject.ID,
+    EntityID   : req.EntityID,
+    Version    : next,
+    Tag        : tag,
+    Versioning : scheme,
+    BlobURL    : prefix,
+    Manifest   : &manifest,
+    AuthorID   : req.AccountID,
+  }
+
+  // ->> Compile the graph before anything is stored, so graph errors are
+  //     reported like any other compile error.
+  if err := candidate.ParseSchemaFiles(ctx); err != nil {
+    pushLog(utils.LogErro, "failed to compile schema graph: %s", err.Error())
+    return nil, fmt.Errorf("%w: %w", ErrSchemaCompileFailed, err)
+  }
+
+  // ->> Blobs and the graph are only written once the version number and
+  //     tag are claimed, so a concurrent upload losing the race never
+  //     replaces the graph. The claim is released when either write fails.
+  if err := s.psql.CreateSchemaVersion(ctx, subject.Name, version, func() error {
+    for _, file := range manifest.Files {
+      if _, err := s.blob.PutBlob(ctx, req.EntityID, files[file.Path]); err != nil {
+        return err
+      }
+    }
+    if err := s.grph.WriteSchema(ctx, candidate); err != nil {
+      pushLog(utils.LogErro, "failed to write schema graph: %s", err.Error())
+      return fmt.Errorf("%w: %w", ErrSchemaGraphFailed, err)
+    }
+    return nil
+  }); err != nil {
+    return nil, err
+  }
+
+  // ->> The catalog is the source of truth; the manifest is mirrored next to
+  //     the version so Blob Storage can be audited on its own. It's only
+  //     written once the version number is claimed, and never overwritten.
+  if data, err := json.Marshal(manifest); err != nil {
+    pushLog(utils.LogErro, "failed to encode version manifest: %s", err.Error())
+  } else if err := s.blob.PutSchema(ctx, req.EntityID, domain.SchemaRef{
+    Subject : subject.Name,
+    Version : version.Version,
+    Path    : VersionManifestFile,
+  }, data); err != nil {
+    pushLog(utils.LogWarn, "failed to mirror version manifest: %s", err.Error())
+  }
+
+  return &UploadSchemaResult{
+    SchemaVersion : version,
+    Diagnostics   : diagnostics,
+  }, nil
+}
+
+// ValidationResult defines the outcome of validating a stored Subject version.
+// Valid is false when any error severity Diagnostic was reported.
+type ValidationResult struct {
+  Subject     string              `json:"subject"`
+  Version     int                 `json:"version"`
+  Valid       bool                `json:"valid"`
+  Diagnostics []domain.Diagnostic `json:"diagnostics"`
+}
+
+// ValidateSchema - Recompiles a stored Subject version, returning every
+// Diagnostic reported. A 'version' below 1 validates the latest version.
+//
+// Potential Errors:
+//   - ErrSchemaVersionNotFound
+//   - ErrSchemaHistoryFailed
+//   - Any error returned by the Blob or SQL repositories.
+func(s *Service) ValidateSchema(
+  ctx      context.Context,
+  entityID users.EntityID,
+  subject  string,
+  version  int,
+)( *ValidationResult, error ){
+  sub, err := s.psql.GetSubject(ctx, entityID, subject)
+  if err != nil {
+    return nil, err
+  }
+  versions, err := s.psql.ListSchemaVersions(ctx, sub.ID)
+  if err != nil {
+    return nil, err
+  }
+  versions = domain.ActiveVersions(versions)
+
+  var target *domain.SchemaVersion
+  for i := range versions {
+    if versions[i].Version == version || (version < 1 && i == len(versions)-1) {
+      target = &versions[i]
+    }
+  }
+  if target == nil {
+    return nil, ErrSchemaVersionNotFound
+  }
+
+  result := &ValidationResult{
+    Subject     : sub.Name,
+    Version     : target.Version,
+    Valid       : true,
+    Diagnostics : []domain.Diagnostic{},
+  }
+
+  schema, err := s.loadVersion(ctx, sub.Name, *target)
+  var diagErr domain.DiagnosticError
+  switch {
+  case errors.As(err, &diagErr):
+    result.Diagnostics = diagErr.Diagnostics()
+  case err != nil:
+    return nil, fmt.Errorf("%w: version %d", ErrSchemaHistoryFailed, target.Version)
+  default:
+    lint, err := s.lint(ctx, entityID, schema)
+    if err != nil {
+      return nil, err
+    }
+    policies, err := s.evaluatePolicies(ctx, entityID, schema)
+    if err != nil {
+      return nil, err
+    }
+    result.Diagnostics = append(result.Diagnostics, diagnosticsOf(schema)...)
+    result.Diagnostics = append(result.Diagnostics, lint...)
+    result.Diagnostics = append(result.Diagnostics, policies...)
+  }
+
+  for _, d := range result.Diagnostics {
+    if d.Severity == domain.SeverityError {
+      result.Valid = false
+    }
+  }
+  return result, nil
+}
+
+// ListSchemaVersions - Returns the version history of a Subject, oldest
+// first. Soft-deleted versions are only listed when 'includeDeleted' is set.
+//
+// Potential Errors:
+//   - Any error returned by the SQL repository.
+func(s *Service) ListSchemaVersions(
+  ctx            context.Context,
+  entityID       users.EntityID,
+  subject        string,
+  includeDeleted bool,
+)( []domain.SchemaVersion, error ){
+  sub, err := s.psql.GetSubject(ctx, entityID, subject)
+  if err != nil {
+    return nil, err
+  }
+  versions, err := s.psql.ListSchemaVersions(ctx, sub.ID)
+  if err != nil {
+    return nil, err
+  }
+  if !includeDeleted {
+    versions = domain.ActiveVersions(versions)
+  }
+  return versions, nil
+}
+
+// DeleteSchemaVersion - Soft-deletes a Subject version, see findVersion for
+// 'version'. Deleted versions can't be downloaded or validated, and are
+// skipped by compatibility checks, but their files are kept so they can be
+// restored. Returns the deleted version.
+//
+// Potential Errors:
+//   - ErrSchemaVersionNotFound
+//   - Any error returned by the SQL repository.
+func(s *Service) DeleteSchemaVersion(
+  ctx      context.Context,
+  entityID users.EntityID,
+  subject  string,
+  version  string,
+)( *domain.SchemaVersion, error ){
+  return s.setVersionDeleted(ctx, entityID, subject, version, true)
+}
+
+// RestoreSchemaVersion - Restores a soft-deleted Subject version, see
+// findVersion for 'version'. Returns the restored version.
+//
+// Potential Errors:
+//   - ErrSchemaVersionNotFound
+//   - ErrVersionTagExists :: Another live version has since claimed its tag.
+//   - Any error returned by the SQL repository.
+func(s *Service) RestoreSchemaVersion(
+  ctx      context.Context,
+  entityID users.EntityID,
+  subject  string,
+  version  string,
+)( *domain.SchemaVersion, error ){
+  return s.setVersionDeleted(ctx, entityID, subject, version, false)
+}
+
+func(s *Service) setVersionDeleted(
+  ctx      context.Context,
+  entityID users.EntityID,
+  subject  string,
+  version  string,
+  deleted  bool,
+)( *domain.SchemaVersion, error ){
+  if strings.EqualFold(strings.TrimSpace(version), "latest") {
+    return nil, fmt.Errorf("%w: versions must be named explicitly", ErrSchemaVersionNotFound)
+  }
+  sub, err := s.psql.GetSubject(ctx, entityID, subject)
+  if err != nil {
+    return nil, err
+  }
+  versions, err := s.psql.ListSchemaVersions(ctx, sub.ID)
+  if err != nil {
+    return nil, err
+  }
+  target, err := findVersion(versions, version)
+  if err != nil {
+    return nil, err
+  }
+  if err := s.psql.SetSchemaVersionDeleted(ctx, sub.ID, target.Version, deleted); err != nil {
+    return nil, err
+  }
+
+  target.DeletedAt = nil
+  if deleted {
+    now := time.Now().UTC()
+    target.DeletedAt = &now
+  }
+  return target, nil
+}
+
+// DownloadSchema - Returns a stored Subject version as a self-contained
+// SchemaBundle. 'version' is a version number, a semver tag or "latest". When
+// 'paths' is empty every file of the version is bundled, otherwise only
+// 'paths' and the files they transitively import. Shared imports the Schema
+// can provide, e.g. bundled google/api protos, are included so the bundle
+// compiles on its own.
+//
+// Potential Errors:
+//   - ErrSchemaVersionNotFound
+//   - ErrSchemaFileNotFound
+//   - ErrInvalidSchemaPath
+//   - ErrSchemaHistoryFailed
+//   - Any error returned by the Blob or SQL repositories.
+func(s *Service) DownloadSchema(
+  ctx      context.Context,
+  entityID users.EntityID,
+  subject  string,
+  version  string,
+  paths    []string,
+)( *domain.SchemaBundle, error ){
+  sub, err := s.psql.GetSubject(ctx, entityID, subject)
+  if err != nil {
+    return nil, err
+  }
+  versions, err := s.psql.ListSchemaVersions(ctx, sub.ID)
+  if err != nil {
+    return nil, err
+  }
+  target, err := findVersion(domain.ActiveVersions(versions), version)
+  if err != nil {
+    return nil, err
+  }
+
+  stored, err := s.downloadVersion(ctx, sub.Name, *target)
+  if err != nil {
+    return nil, err
+  }
+
+  requested := make([]string, 0, len(paths))
+  for _, p := range paths {
+    c, err := CleanSchemaPath(p)
+    if err != nil {
+      return nil, err
+    }
+    if _, ok := stored[c]; !ok {
+      return nil, fmt.Errorf("%w: %q", ErrSchemaFileNotFound, c)
+    }
+    requested = append(requested, c)
+  }
+  if len(requested) == 0 {
+    for p := range stored {
+      requested = append(requested, p)
+    }
+  }
+  sort.Strings(requested)
+
+  bundle := &domain.SchemaBundle{
+    Manifest : domain.BundleManifest{
+      Subject   : sub.Name,
+      Version   : target.Version,
+      Tag       : target.Tag,
+      CreatedAt : target.CreatedAt,
+      Files     : []domain.BundleFile{},
+    },
+    Files    : map[string][]byte{},
+  }
+  for _, p := range requested {
+    bundle.Files[p] = stored[p]
+  }
+
+  // ->> Only formats able to resolve their imports can be trimmed down, or
+  //     bring their shared imports along.
+  schema, err := s.loader(ctx, loadContext(sub.Name, *target), stored)
+  if err != nil {
+    return nil, fmt.Errorf("%w: version %d", ErrSchemaHistoryFailed, target.Version)
+  }
+  if bundleable, ok := schema.(domain.Bundleable); ok {
+    for _, p := range bundleable.TransitiveImports(requested) {
+      if data, ok := stored[p]; ok {
+        bundle.Files[p] = data
+      } else if data, ok := bundleable.SharedSource(p); ok {
+        bundle.Files[p] = data
+      }
+    }
+  } else if len(paths) != 0 {
+    bundle.Files = stored
+  }
+
+  for p, data := range bundle.Files {
+    file := domain.BundleFile{
+      Path   : p,
+      SHA256 : domain.BlobDigest(data),
+      Size   : len(data),
+    }
+    if _, ok := stored[p]; ok {
+      file.Version = target.Version
+    } else {
+      file.Shared = true
+    }
+    bundle.Manifest.Files = append(bundle.Manifest.Files, file)
+  }
+  sort.Slice(bundle.Manifest.Files, func(i, j int) bool {
+    return bundle.Manifest.Files[i].Path < bundle.Manifest.Files[j].Path
+  })
+  return bundle, nil
+}
+
+// AnalyzeImpact - Returns every stored element, Service and Package of the
+// Entity that transitively depends on the element 'target' refers to, along
+// with the relationships followed to reach each one. 'target' is keyed by the
+// element's fully-qualified name, suffixed with its version under the
+// registry and semver schemes. It's resolved among the Entity's own
+// definitions first, then among shared ones, e.g. google.protobuf.Timestamp.
+// Reported keys are stored keys, see domain.NamespacedKey.
+//
+// Potential Errors:
+//   - domain.ErrUnknownImpactKind    :: 'target' isn't a Message, Enum, field or Method.
+//   - domain.ErrImpactTargetNotFound :: Neither the Entity nor shared definitions hold 'target'.
+//   - Any error returned by the Graph repository.
+func(s *Service) AnalyzeImpact(
+  ctx      context.Context,
+  entityID users.EntityID,
+  target   domain.NodeRef,
+  maxDepth int,
+)( *domain.ImpactReport, error ){
+  switch target.Label {
+  case domain.LabelMessage, domain.LabelEnum, domain.LabelParameter, domain.LabelMethod:
+  default:
+    return nil, fmt.Errorf("%w: %q", domain.ErrUnknownImpactKind, target.Label)
+  }
+  // ->> Keys of another Entity's definitions are never resolvable.
+  if domain.KeyNamespace(target.Key) != "" {
+    return nil, fmt.Errorf("%w: %s %q", domain.ErrImpactTargetNotFound, target.Label, target.Key)
+  }
+
+  namespace := domain.GraphNamespace(entityID)
+  owned     := domain.NodeRef{ Label: target.Label, Key: domain.NamespacedKey(entityID, target.Key) }
+  report, err := s.grph.AnalyzeImpact(ctx, namespace, owned, maxDepth)
+  if errors.Is(err, domain.ErrImpactTargetNotFound) && owned != target {
+    return s.grph.AnalyzeImpact(ctx, namespace, target, maxDepth)
+  }
+  return report, err
+}
+
+// GetCompatibility - Returns the effective CompatibilityMode of a Subject, or
+// the Entity's default when 'subject' is empty.
+func(s *Service) GetCompatibility(
+  ctx      context.Context,
+  entityID users.EntityID,
+  subject  string,
+)( domain.CompatibilityMode, error ){
+  if subject == "" {
+    return s.psql.GetCompatibility(ctx, entityID)
+  }
+
+  sub, err := s.psql.GetSubject(ctx, entityID, subject)
+  if err != nil {
+    return "", err
+  }
+  return s.resolveCompatibility(ctx, sub)
+}
+
+// SetCompatibility - Sets the Entity's default CompatibilityMode when 'subject'
+// is empty, otherwise overrides the Subject's mode, registering the Subject
+// if needed.
+func(s *Service) SetCompatibility(
+  ctx      context.Context,
+  entityID users.EntityID,
+  subject  string,
+  mode     domain.CompatibilityMode,
+) error {
+  if subject == "" {
+    return s.psql.SetCompatibility(ctx, entityID, mode)
+  }
+
+  if _, err := s.psql.CreateSubject(ctx, entityID, subject); err != nil {
+    return err
+  }
+  return s.psql.SetSubjectCompatibility(ctx, entityID, subject, mode)
+}
+
+// ClearCompatibility - Removes a Subject's override, so it inherits the
+// Entity's default CompatibilityMode again.
+func(s *Service) ClearCompatibility(
+  ctx      context.Context,
+  entityID users.EntityID,
+  subject  string,
+) error {
+  return s.psql.SetSubjectCompatibility(ctx, entityID, subject, "")
+}
+
+func(s *Service) resolveCompatibility(
+  ctx     context.Context,
+  subject *domain.Subject,
+)( domain.CompatibilityMode, error ){
+  if subject.Compatibility != "" {
+    return subject.Compatibility, nil
+  }
+  return s.psql.GetCompatibility(ctx, subject.EntityID)
+}
+
+// GetVersioning - Returns the Entity's VersioningScheme.
+func(s *Service) GetVersioning(
+  ctx      context.Context,
+  entityID users.EntityID,
+)( domain.VersioningScheme, error ){
+  return s.psql.GetVersioning(ctx, entityID)
+}
+
+// SetVersioning - Sets the Entity's VersioningScheme. Previously registered
+// versions keep the tags and the scheme they were published with.
+func(s *Service) SetVersioning(
+  ctx      context.Context,
+  entityID users.EntityID,
+  scheme   domain.VersioningScheme,
+) error {
+  return s.psql.SetVersioning(ctx, entityID, scheme)
+}
+
+// GetLintConfig - Returns the Entity's LintConfig.
+func(s *Service) GetLintConfig(
+  ctx      context.Context,
+  entityID users.EntityID,
+)( domain.LintConfig, error ){
+  return s.psql.GetLintConfig(ctx, entityID)
+}
+
+// SetLintConfig - Sets the Entity's LintConfig, used by every following
+// upload and validation.
+//
+// Potential Errors:
+//   - domain.ErrUnknownLintRuleSet
+func(s *Service) SetLintConfig(
+  ctx      context.Context,
+  entityID users.EntityID,
+  config   domain.LintConfig,
+) error {
+  if err := config.Validate(); err != nil {
+    return err
+  }
+  return s.psql.SetLintConfig(ctx, entityID, config)
+}
+
+// lint - Lints 'schema' with the Entity's LintConfig, when its format supports linting.
+func(s *Service) lint(
+  ctx      context.Context,
+  entityID users.EntityID,
+  schema   domain.Schema,
+)( []domain.Diagnostic, error ){
+  lintable, ok := schema.(domain.Lintable)
+  if !ok {
+    return nil, nil
+  }
+  config, err := s.psql.GetLintConfig(ctx, entityID)
+  if err != nil {
+    return nil, err
+  }
+  return lintable.Lint(config), nil
+}
+
+// ListPolicies - Returns every Policy of the Entity.
+func(s *Service) ListPolicies(
+  ctx      context.Context,
+  entityID users.EntityID,
+)( []domain.Policy, error ){
+  return s.psql.ListPolicies(ctx, entityID)
+}
+
+// PutPolicy - Creates or replaces one of the Entity's Policies, evaluated by
+// every following upload and validation.
+//
+// Potential Errors:
+//   - domain.ErrInvalidPolicy
+//   - domain.ErrUnknownPolicyTarget
+func(s *Service) PutPolicy(
+  ctx      context.Context,
+  entityID users.EntityID,
+  policy   domain.Policy,
+)( *domain.Policy, error ){
+  if err := policy.Validate(); err != nil {
+    return nil, err
+  }
+  if err := s.psql.PutPolicy(ctx, entityID, policy); err != nil {
+    return nil, err
+  }
+  return &policy, nil
+}
+
+// DeletePolicy - Removes one of the Entity's Policies.
+func(s *Service) DeletePolicy(
+  ctx      context.Context,
+  entityID users.EntityID,
+  name     string,
 ) error {
+  return s.psql.DeletePolicy(ctx, entityID, name)
+}
+
+// evaluatePolicies - Evaluates the Entity's Policies over 'schema', when its
+// format supports them.
+func(s *Service) evaluatePolicies(
+  ctx      context.Context,
+  entityID users.EntityID,
+  schema   domain.Schema,
+)( []domain.Diagnostic, error ){
+  evaluable, ok := schema.(domain.PolicyEvaluable)
+  if !ok {
+    return nil, nil
+  }
+  policies, err := s.psql.ListPolicies(ctx, entityID)
+  if err != nil || len(policies) == 0 {
+    return nil, err
+  }
+  return evaluable.EvaluatePolicies(policies), nil
+}
+
+// resolveTag - Validates an upload's tag against the Entity's VersioningScheme,
+// returning the scheme and the tag in canonical form. Semver Entities must tag
+// every upload; other schemes accept an optional tag, which must still be
+// valid semver.
+func(s *Service) resolveTag(
+  ctx      context.Context,
+  entityID users.EntityID,
+  tag      string,
+)( domain.VersioningScheme, string, error ){
+  scheme, err := s.psql.GetVersioning(ctx, entityID)
+  if err != nil {
+    return "", "", err
+  }
+  if strings.TrimSpace(tag) == "" {
+    if scheme == domain.VersioningSemver {
+      return "", "", ErrInvalidVersionTag
+    }
+    return scheme, "", nil
+  }
+
+  v, err := domain.ParseSemver(tag)
+  if err != nil {
+    return "", "", fmt.Errorf("%w: %s", ErrInvalidVersionTag, err.Error())
+  }
+  return scheme, v.String(), nil
+}
+
+// DescriptorSet defines the exported FileDescriptorSet of a Subject version.
+type DescriptorSet struct {
+  Subject string
+  Version int
+  Tag     string
+  Data    []byte
+}
+
+// ExportDescriptors - Compiles a stored Subject version and returns its files
+// as a binary google.protobuf.FileDescriptorSet, for consumers decoding
+// messages dynamically, e.g. gRPC-JSON transcoders. 'version' is a version
+// number, a semver tag or "latest".
+//
+// Potential Errors:
+//   - ErrSchemaVersionNotFound
+//   - ErrSchemaHistoryFailed
+//   - ErrDescriptorUnsupported :: The version's Schema format has no descriptors.
+//   - Any error returned by the Blob or SQL repositories.
+func(s *Service) ExportDescriptors(
+  ctx      context.Context,
+  entityID users.EntityID,
+  subject  string,
+  version  string,
+  opts     domain.DescriptorOptions,
+)( *DescriptorSet, error ){
+  sub, err := s.psql.GetSubject(ctx, entityID, subject)
+  if err != nil {
+    return nil, err
+  }
+  versions, err := s.psql.ListSchemaVersions(ctx, sub.ID)
+  if err != nil {
+    return nil, err
+  }
+  target, err := findVersion(domain.ActiveVersions(versions), version)
+  if err != nil {
+    return nil, err
+  }
+
+  schema, err := s.loadVersion(ctx, sub.Name, *target)
+  if err != nil {
+    return nil, fmt.Errorf("%w: version %d", ErrSchemaHistoryFailed, target.Version)
+  }
+  exportable, ok := schema.(domain.DescriptorExportable)
+  if !ok {
+    return nil, ErrDescriptorUnsupported
+  }
+  data, err := exportable.FileDescriptorSet(opts)
+  if err != nil {
+    return nil, err
+  }
+
+  return &DescriptorSet{
+    Subject : sub.Name,
+    Version : target.Version,
+    Tag     : target.Tag,
+    Data    : data,
+  }, nil
+}
+
+// loadVersion - Downloads and compiles every file stored for a Subject
+// version, under the VersioningScheme it was published with.
+func(s *Service) loadVersion(
+  ctx     context.Context,
+  subject string,
+  version domain.SchemaVersion,
+)( domain.ComparableSchema, error ){
+  files, err := s.downloadVersion(ctx, subject, version)
+  if err != nil {
+    return nil, err
+  }
+  return s.loader(ctx, loadContext(subject, version), files)
+}
+
+// loadContext - Returns the LoadContext a Subject version is compiled with,
+// resolving its Packages' versions by the version's own VersioningScheme.
+func loadContext(
+  subject string,
+  version domain.SchemaVersion,
+) domain.LoadContext {
+  return domain.LoadContext{
+    EntityID : version.EntityID,
+    Subject  : subject,
+    Version  : domain.VersionContext{
+      Scheme   : version.Versioning,
+      Registry : version.Version,
+      Tag      : version.Tag,
+    },
+  }
+}
+
+// downloadVersion - Downloads every file stored for a Subject version, keyed
+// by its path relative to the version. Every blob is verified against the
+// digest the version's manifest recorded.
+func(s *Service) downloadVersion(
+  ctx     context.Context,
+  subject string,
+  version domain.SchemaVersion,
+)( map[string][]byte, error ){
+  manifest := version.Manifest
+  if manifest == nil {
+    stored, err := s.versionManifest(ctx, subject, version)
+    if err != nil {
+      // ->> Versions stored before blobs were content-addressed have no
+      //     manifest, their files are kept under the version itself.
+      return s.downloadLegacyVersion(ctx, subject, version)
+    }
+    manifest = stored
+  }
+
+  files := make(map[string][]byte, len(manifest.Files))
+  for _, file := range manifest.Files {
+    data, err := s.blob.GetBlob(ctx, version.EntityID, file.Digest)
+    if err != nil {
+      return nil, err
+    }
+    files[file.Path] = data
+  }
+  return files, nil
+}
+
+// versionManifest - Downloads the VersionManifest mirrored next to a Subject
+// version's files, for versions recorded before the catalog kept them.
+func(s *Service) versionManifest(
+  ctx     context.Context,
+  subject string,
+  version domain.SchemaVersion,
+)( *domain.VersionManifest, error ){
+  data, err := s.blob.DownloadSchema(ctx, version.EntityID, domain.SchemaRef{
+    Subject : subject,
+    Version : version.Version,
+    Path    : VersionManifestFile,
+  })
+  if err != nil {
+    return nil, err
+  }
+  var manifest domain.VersionManifest
+  if err := json.Unmarshal(data, &manifest); err != nil {
+    return nil, err
+  }
+  return &manifest, nil
+}
+
+func(s *Service) downloadLegacyVersion(
+  ctx     context.Context,
+  subject string,
+  version domain.SchemaVersion,
+)( map[string][]byte, error ){
+  paths, err := s.blob.ListSchemas(ctx, version.EntityID, subject, version.Version)
+  if err != nil {
+    return nil, err
+  }
+  if len(paths) == 0 {
+    return nil, errors.New("no files stored for version")
+  }
+
+  files := make(map[string][]byte, len(paths))
+  for _, p := range paths {
+    data, err := s.blob.DownloadSchema(ctx, version.EntityID, domain.SchemaRef{
+      Subject : subject,
+      Version : version.Version,
+      Path    : p,
+    })
+    if err != nil {
+      return nil, err
+    }
+    files[p] = data
+  }
+  return files, nil
+}
+
+// findVersion - Returns the version of 'versions', ordered oldest to newest,
+// matching 'id': a version number, a semver tag or "latest".
+func findVersion(
+  versions []domain.SchemaVersion,
+  id       string,
+)( *domain.SchemaVersion, error ){
+  id = strings.TrimSpace(id)
+  if len(versions) == 0 {
+    return nil, ErrSchemaVersionNotFound
+  }
+  if id == "" || strings.EqualFold(id, "latest") {
+    return &versions[len(versions)-1], nil
+  }
+
+  match := func(v domain.SchemaVersion) bool { return false }
+  if number, err := strconv.Atoi(id); err == nil {
+    match = func(v domain.SchemaVersion) bool { return v.Version == number }
+  } else if tag, err := domain.ParseSemver(id); err == nil {
+    match = func(v domain.SchemaVersion) bool { return v.Tag == tag.String() }
+  }
+  for i := range versions {
+    if match(versions[i]) {
+      return &versions[i], nil
+    }
+  }
+  return nil, fmt.Errorf("%w: %q", ErrSchemaVersionNotFound, id)
+}
+
+// cleanUploadFiles - Validates every uploaded path with CleanSchemaPath,
+// returning the files keyed by their canonical path.
+func cleanUploadFiles(files map[string][]byte)( map[string][]byte, error ){
+  if len(files) > MaxUploadFiles {
+    return nil, ErrUploadTooLarge
+  }
+  cleaned := make(map[string][]byte, len(files))
+  for p, data := range files {
+    c, err := CleanSchemaPath(p)
+    if err != nil {
+      return nil, err
+    }
+    if _, ok := cleaned[c]; ok {
+      return nil, fmt.Errorf("%w: %q is uploaded twice", ErrInvalidSchemaPath, c)
+    }
+    cleaned[c] = data
+  }
+  return cleaned, nil
+}
 
+// diagnosticsOf - Returns the warnings 'schema' kept from compiling, if any.
+func diagnosticsOf(schema domain.Schema) []domain.Diagnostic {
+  if d, ok := schema.(domain.Diagnosable); ok {
+    return d.Diagnostics()
+  }
   return nil
 }
+
+// VersionManifestFile -- The path, relative to a Subject version, its
+// domain.VersionManifest is stored under.
+const VersionManifestFile = "manifest.json"
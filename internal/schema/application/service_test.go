@@ -0,0 +1,1114 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	repo "github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/repository"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/proto"
+	"github.com/TylerAldrich814/Fidicus/internal/shared/role"
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
+)
+
+var errNotFaked = errors.New("not implemented by the fake")
+
+// fakeBlob -- An in-memory domain.SchemaBlobRepository, keyed like S3.
+type fakeBlob struct {
+  objects   map[string][]byte
+  downloads int
+}
+
+func newFakeBlob() *fakeBlob {
+  return &fakeBlob{ objects: map[string][]byte{} }
+}
+
+func(b *fakeBlob) put(key string, err error, data []byte) error {
+  if err != nil {
+    return err
+  }
+  if _, ok := b.objects[key]; !ok {
+    b.objects[key] = data
+  }
+  return nil
+}
+
+func(b *fakeBlob) get(key string, err error)( []byte, error ){
+  if err != nil {
+    return nil, err
+  }
+  data, ok := b.objects[key]
+  if !ok {
+    return nil, fs.ErrNotExist
+  }
+  return data, nil
+}
+
+func(b *fakeBlob) list(prefix string, err error)( []string, error ){
+  if err != nil {
+    return nil, err
+  }
+  paths := []string{}
+  for key := range b.objects {
+    if strings.HasPrefix(key, prefix) {
+      paths = append(paths, strings.TrimPrefix(key, prefix))
+    }
+  }
+  sort.Strings(paths)
+  return paths, nil
+}
+
+func(b *fakeBlob) UploadSchema(context.Context, users.EntityID, domain.SchemaRef, string) error {
+  return errNotFaked
+}
+
+func(b *fakeBlob) PutSchema(_ context.Context, entityID users.EntityID, ref domain.SchemaRef, data []byte) error {
+  key, err := ref.Key(entityID)
+  return b.put(key, err, data)
+}
+
+func(b *fakeBlob) DownloadSchema(_ context.Context, entityID users.EntityID, ref domain.SchemaRef)( []byte, error ){
+  return b.get(ref.Key(entityID))
+}
+
+func(b *fakeBlob) DeleteSchema(_ context.Context, entityID users.EntityID, ref domain.SchemaRef) error {
+  key, err := ref.Key(entityID)
+  if err != nil {
+    return err
+  }
+  delete(b.objects, key)
+  return nil
+}
+
+func(b *fakeBlob) ListSchemas(_ context.Context, entityID users.EntityID, subject string, version int)( []string, error ){
+  prefix, err := domain.VersionPrefix(entityID, subject, version)
+  return b.list(prefix, err)
+}
+
+func(b *fakeBlob) PutBlob(_ context.Context, entityID users.EntityID, data []byte)( string, error ){
+  digest   := domain.BlobDigest(data)
+  key, err := domain.BlobKey(entityID, digest)
+  return digest, b.put(key, err, data)
+}
+
+func(b *fakeBlob) GetBlob(_ context.Context, entityID users.EntityID, digest string)( []byte, error ){
+  return b.get(domain.BlobKey(entityID, digest))
+}
+
+func(b *fakeBlob) GeneratePresignedURL(_ context.Context, entityID users.EntityID, digest string, _ time.Duration)( string, error ){
+  key, err := domain.BlobKey(entityID, digest)
+  return "https://blob.test/" + key, err
+}
+
+func(b *fakeBlob) GeneratePresignedPutURL(_ context.Context, entityID users.EntityID, uploadID uuid.UUID, path string, _ time.Duration)( string, error ){
+  key, err := domain.StagedFileKey(entityID, uploadID, path)
+  return "https://blob.test/" + key, err
+}
+
+func(b *fakeBlob) ListStagedFiles(_ context.Context, entityID users.EntityID, uploadID uuid.UUID)( []domain.StagedObject, error ){
+  prefix, err := domain.StagedUploadPrefix(entityID, uploadID)
+  paths, err := b.list(prefix, err)
+  if err != nil {
+    return nil, err
+  }
+  objects := make([]domain.StagedObject, 0, len(paths))
+  for _, p := range paths {
+    objects = append(objects, domain.StagedObject{ Path: p, Size: int64(len(b.objects[prefix+p])) })
+  }
+  return objects, nil
+}
+
+func(b *fakeBlob) DownloadStagedFile(_ context.Context, entityID users.EntityID, uploadID uuid.UUID, path string, limit int64)( []byte, error ){
+  b.downloads++
+  data, err := b.get(domain.StagedFileKey(entityID, uploadID, path))
+  if err == nil && int64(len(data)) > limit+1 {
+    data = data[:limit+1]
+  }
+  return data, err
+}
+
+func(b *fakeBlob) DeleteStagedUpload(_ context.Context, entityID users.EntityID, uploadID uuid.UUID) error {
+  prefix, err := domain.StagedUploadPrefix(entityID, uploadID)
+  if err != nil {
+    return err
+  }
+  for key := range b.objects {
+    if strings.HasPrefix(key, prefix) {
+      delete(b.objects, key)
+    }
+  }
+  return nil
+}
+
+func(b *fakeBlob) Shutdown() error { return nil }
+
+// fakeGraph -- An in-memory domain.SchemaGraphRepository, replacing what
+// every Owner held before the way the Neo4j repository does.
+type fakeGraph struct {
+  graph  domain.SchemaGraph
+  writes int
+  err    error
+}
+
+func(g *fakeGraph) WriteSchema(_ context.Context, schema domain.Schema) error {
+  if g.err != nil {
+    return g.err
+  }
+  write, err := schema.Graph()
+  if err != nil {
+    return err
+  }
+  g.apply(write)
+  g.writes++
+  return nil
+}
+
+// apply - Writes 'write' into the fake's graph the way the cypher.Writer's
+// statements write it into Neo4j. For every Owner of 'write', nodes it held
+// that 'write' no longer contains are removed along with their relationships,
+// and every outgoing relationship of the nodes it still holds is dropped.
+// Nodes are then merged on their Key, and relationships on their endpoints.
+func(g *fakeGraph) apply(write *domain.SchemaGraph) {
+  owners, held := write.Owners()
+  rewritten := map[string]bool{}
+  for _, owner := range owners {
+    rewritten[owner] = true
+  }
+  kept := map[domain.NodeRef]bool{}
+  for _, refs := range held {
+    for _, ref := range refs {
+      kept[ref] = true
+    }
+  }
+
+  nodes   := map[domain.NodeRef]int{}
+  removed := map[domain.NodeRef]bool{}
+  owned   := map[domain.NodeRef]bool{}
+  merged  := []domain.GraphNode{}
+  for _, node := range append(append([]domain.GraphNode{}, g.graph.Nodes...), write.Nodes...) {
+    ref := domain.NodeRef{ Label: node.Label, Key: node.Key }
+    if rewritten[node.Owner] && !kept[ref] {
+      removed[ref] = true
+      continue
+    }
+    if i, ok := nodes[ref]; ok {
+      merged[i] = node
+    } else {
+      nodes[ref] = len(merged)
+      merged = append(merged, node)
+    }
+  }
+  for _, node := range merged {
+    if rewritten[node.Owner] {
+      owned[domain.NodeRef{ Label: node.Label, Key: node.Key }] = true
+    }
+  }
+
+  type edgeRef struct {
+    Type     domain.EdgeType
+    From, To domain.NodeRef
+  }
+  seen  := map[edgeRef]int{}
+  edges := []domain.GraphEdge{}
+  add   := func(edge domain.GraphEdge, existing bool) {
+    ref := edgeRef{
+      Type : edge.Type,
+      From : domain.NodeRef{ Label: edge.FromLabel, Key: edge.FromKey },
+      To   : domain.NodeRef{ Label: edge.ToLabel,   Key: edge.ToKey   },
+    }
+    if removed[ref.From] || removed[ref.To] || (existing && owned[ref.From]) {
+      return
+    }
+    if i, ok := seen[ref]; ok {
+      edges[i] = edge
+      return
+    }
+    seen[ref] = len(edges)
+    edges = append(edges, edge)
+  }
+  for _, edge := range g.graph.Edges {
+    add(edge, true)
+  }
+  for _, edge := range write.Edges {
+    add(edge, false)
+  }
+
+  g.graph.Nodes = merged
+  g.graph.Edges = edges
+}
+
+func(g *fakeGraph) GetPackage(context.Context, users.EntityID, string)( *domain.PackageNode, error ){
+  return nil, errNotFaked
+}
+
+func(g *fakeGraph) GetLatestPackage(context.Context, users.EntityID, string, bool)( *domain.PackageNode, error ){
+  return nil, errNotFaked
+}
+
+func(g *fakeGraph) ListMessages(context.Context, users.EntityID, string)( []domain.MessageNode, error ){
+  return nil, errNotFaked
+}
+
+func(g *fakeGraph) GetServiceMethods(context.Context, users.EntityID, string, string)( []domain.MethodNode, error ){
+  return nil, errNotFaked
+}
+
+func(g *fakeGraph) AnalyzeImpact(ctx context.Context, namespace string, target domain.NodeRef, maxDepth int)( *domain.ImpactReport, error ){
+  return g.graph.Impact(ctx, namespace, target, maxDepth)
+}
+
+func(g *fakeGraph) Shutdown() error { return nil }
+
+// keys - Returns the Key of every stored node labeled 'label', sorted.
+func(g *fakeGraph) keys(label domain.NodeLabel) []string {
+  keys := []string{}
+  for _, node := range g.graph.Nodes {
+    if node.Label == label {
+      keys = append(keys, node.Key)
+    }
+  }
+  sort.Strings(keys)
+  return keys
+}
+
+// fakeSQL -- An in-memory domain.SchemaSQLRepository, enforcing the same
+// uniqueness constraints as the Postgres schema.
+type fakeSQL struct {
+  compat     map[users.EntityID]domain.CompatibilityMode
+  versioning map[users.EntityID]domain.VersioningScheme
+  lint       map[users.EntityID]domain.LintConfig
+  policies   map[users.EntityID][]domain.Policy
+  subjects   map[string]*domain.Subject
+  versions   map[uuid.UUID][]domain.SchemaVersion
+  staged     map[uuid.UUID]*domain.StagedUpload
+  // claimErr, when set, is returned by CreateSchemaVersion as if a
+  // concurrent upload had claimed the version first.
+  claimErr   error
+}
+
+func newFakeSQL() *fakeSQL {
+  return &fakeSQL{
+    compat     : map[users.EntityID]domain.CompatibilityMode{},
+    versioning : map[users.EntityID]domain.VersioningScheme{},
+    lint       : map[users.EntityID]domain.LintConfig{},
+    policies   : map[users.EntityID][]domain.Policy{},
+    subjects   : map[string]*domain.Subject{},
+    versions   : map[uuid.UUID][]domain.SchemaVersion{},
+    staged     : map[uuid.UUID]*domain.StagedUpload{},
+  }
+}
+
+func subjectKey(entityID users.EntityID, name string) string {
+  return entityID.String() + "/" + name
+}
+
+func(s *fakeSQL) CreateAccessRole(context.Context, role.Role) error { return nil }
+
+func(s *fakeSQL) GetCompatibility(_ context.Context, entityID users.EntityID)( domain.CompatibilityMode, error ){
+  if mode, ok := s.compat[entityID]; ok {
+    return mode, nil
+  }
+  return domain.DefaultCompatibility, nil
+}
+
+func(s *fakeSQL) SetCompatibility(_ context.Context, entityID users.EntityID, mode domain.CompatibilityMode) error {
+  s.compat[entityID] = mode
+  return nil
+}
+
+func(s *fakeSQL) GetVersioning(_ context.Context, entityID users.EntityID)( domain.VersioningScheme, error ){
+  if scheme, ok := s.versioning[entityID]; ok {
+    return scheme, nil
+  }
+  return domain.DefaultVersioning, nil
+}
+
+func(s *fakeSQL) SetVersioning(_ context.Context, entityID users.EntityID, scheme domain.VersioningScheme) error {
+  s.versioning[entityID] = scheme
+  return nil
+}
+
+func(s *fakeSQL) GetLintConfig(_ context.Context, entityID users.EntityID)( domain.LintConfig, error ){
+  if config, ok := s.lint[entityID]; ok {
+    return config, nil
+  }
+  return domain.DefaultLintConfig, nil
+}
+
+func(s *fakeSQL) SetLintConfig(_ context.Context, entityID users.EntityID, config domain.LintConfig) error {
+  s.lint[entityID] = config
+  return nil
+}
+
+func(s *fakeSQL) ListPolicies(_ context.Context, entityID users.EntityID)( []domain.Policy, error ){
+  return s.policies[entityID], nil
+}
+
+func(s *fakeSQL) PutPolicy(_ context.Context, entityID users.EntityID, policy domain.Policy) error {
+  s.policies[entityID] = append(s.policies[entityID], policy)
+  return nil
+}
+
+func(s *fakeSQL) DeletePolicy(context.Context, users.EntityID, string) error {
+  return errNotFaked
+}
+
+func(s *fakeSQL) GetSubject(_ context.Context, entityID users.EntityID, name string)( *domain.Subject, error ){
+  sub, ok := s.subjects[subjectKey(entityID, name)]
+  if !ok {
+    return nil, fmt.Errorf("%w: %w", repo.ErrDBSubjectNotFound, domain.ErrSubjectNotFound)
+  }
+  copied := *sub
+  return &copied, nil
+}
+
+func(s *fakeSQL) CreateSubject(ctx context.Context, entityID users.EntityID, name string)( *domain.Subject, error ){
+  if _, ok := s.subjects[subjectKey(entityID, name)]; !ok {
+    s.subjects[subjectKey(entityID, name)] = &domain.Subject{
+      ID       : uuid.New(),
+      EntityID : entityID,
+      Name     : name,
+    }
+  }
+  return s.GetSubject(ctx, entityID, name)
+}
+
+func(s *fakeSQL) SetSubjectCompatibility(_ context.Context, entityID users.EntityID, name string, mode domain.CompatibilityMode) error {
+  sub, ok := s.subjects[subjectKey(entityID, name)]
+  if !ok {
+    return repo.ErrDBSubjectNotFound
+  }
+  sub.Compatibility = mode
+  return nil
+}
+
+func(s *fakeSQL) ListSchemaVersions(_ context.Context, subjectID uuid.UUID)( []domain.SchemaVersion, error ){
+  return append([]domain.SchemaVersion{}, s.versions[subjectID]...), nil
+}
+
+// CreateSchemaVersion - Mirrors the (subject_id, version) unique constraint
+// and the schemas_subject_tag_key index. New Subjects are only registered
+// along with the version, like the transaction they're both written in, and
+// nothing is recorded when 'publish' fails.
+func(s *fakeSQL) CreateSchemaVersion(
+  _       context.Context,
+  subject string,
+  version *domain.SchemaVersion,
+  publish func() error,
+) error {
+  if s.claimErr != nil {
+    return s.claimErr
+  }
+  sub, ok := s.subjects[subjectKey(version.EntityID, subject)]
+  if !ok {
+    sub = &domain.Subject{ ID: uuid.New(), EntityID: version.EntityID, Name: subject }
+  }
+  for _, v := range s.versions[sub.ID] {
+    if v.Version == version.Version {
+      return repo.ErrDBSchemaVersionExists
+    }
+    if version.Tag != "" && v.Tag == version.Tag && v.DeletedAt == nil {
+      return domain.ErrVersionTagExists
+    }
+  }
+  if err := publish(); err != nil {
+    return err
+  }
+  s.subjects[subjectKey(version.EntityID, subject)] = sub
+  version.ID        = uuid.New()
+  version.SubjectID = sub.ID
+  version.CreatedAt = time.Now().UTC()
+  s.versions[sub.ID] = append(s.versions[sub.ID], *version)
+  return nil
+}
+
+// SetSchemaVersionDeleted - Mirrors the schemas_subject_tag_key index on restore.
+func(s *fakeSQL) SetSchemaVersionDeleted(_ context.Context, subjectID uuid.UUID, version int, deleted bool) error {
+  versions := s.versions[subjectID]
+  for i := range versions {
+    if versions[i].Version != version {
+      continue
+    }
+    for _, v := range versions {
+      if !deleted && v.Tag != "" && v.Tag == versions[i].Tag && v.Version != version && v.DeletedAt == nil {
+        return domain.ErrVersionTagExists
+      }
+    }
+    if deleted {
+      now := time.Now().UTC()
+      versions[i].DeletedAt = &now
+    } else {
+      versions[i].DeletedAt = nil
+    }
+    return nil
+  }
+  return repo.ErrDBSchemaVersionNotFound
+}
+
+func(s *fakeSQL) CreateStagedUpload(_ context.Context, upload *domain.StagedUpload) error {
+  upload.ID        = uuid.New()
+  upload.CreatedAt = time.Now().UTC()
+  copied := *upload
+  s.staged[upload.ID] = &copied
+  return nil
+}
+
+func(s *fakeSQL) GetStagedUpload(_ context.Context, entityID users.EntityID, id uuid.UUID)( *domain.StagedUpload, error ){
+  upload, ok := s.staged[id]
+  if !ok || upload.EntityID != entityID {
+    return nil, repo.ErrDBStagedUploadNotFound
+  }
+  copied := *upload
+  return &copied, nil
+}
+
+func(s *fakeSQL) DeleteStagedUpload(_ context.Context, entityID users.EntityID, id uuid.UUID) error {
+  upload, ok := s.staged[id]
+  if !ok || upload.EntityID != entityID {
+    return repo.ErrDBStagedUploadNotFound
+  }
+  delete(s.staged, id)
+  return nil
+}
+
+func(s *fakeSQL) DeleteExpiredStagedUploads(_ context.Context, now time.Time)( []domain.StagedUpload, error ){
+  expired := []domain.StagedUpload{}
+  for id, upload := range s.staged {
+    if upload.IsExpired(now) {
+      expired = append(expired, *upload)
+      delete(s.staged, id)
+    }
+  }
+  return expired, nil
+}
+
+func(s *fakeSQL) Shutdown() error { return nil }
+
+// testService -- A Service over in-memory repositories, compiling uploads
+// with the protobuf SchemaLoader.
+type testService struct {
+  *Service
+  blob *fakeBlob
+  grph *fakeGraph
+  psql *fakeSQL
+}
+
+func newTestService() *testService {
+  t := &testService{
+    blob : newFakeBlob(),
+    grph : &fakeGraph{},
+    psql : newFakeSQL(),
+  }
+  t.Service = NewService(t.blob, t.grph, t.psql, proto.NewSchemaLoader())
+  return t
+}
+
+// storeState -- What an upload may have written, compared to make sure
+// rejected uploads leave every repository untouched.
+type storeState struct {
+  Objects  int
+  Writes   int
+  Versions int
+}
+
+func(t *testService) state() storeState {
+  versions := 0
+  for _, v := range t.psql.versions {
+    versions += len(v)
+  }
+  return storeState{
+    Objects  : len(t.blob.objects),
+    Writes   : t.grph.writes,
+    Versions : versions,
+  }
+}
+
+func(t *testService) upload(
+  entityID users.EntityID,
+  subject  string,
+  tag      string,
+  source   string,
+)( *UploadSchemaResult, error ){
+  return t.UploadSchema(context.Background(), UploadSchemaRequest{
+    EntityID  : entityID,
+    AccountID : users.NewAccountID(),
+    Subject   : subject,
+    Tag       : tag,
+    Files     : map[string][]byte{ "acme/orders/v1/orders.proto": []byte(source) },
+  })
+}
+
+// graphKey - Returns the graph key of an Entity's own definition 'name'.
+func graphKey(entityID users.EntityID, name string) string {
+  return domain.GraphNamespace(entityID) + "/" + name
+}
+
+// ordersProto - Returns the acme.orders.v1 package, with an Order message
+// made of 'fields'.
+func ordersProto(fields ...string) string {
+  return packageProto("acme.orders.v1", fields...)
+}
+
+// packageProto - Returns the package 'pkg', with an Order message made of 'fields'.
+func packageProto(pkg string, fields ...string) string {
+  return fmt.Sprintf(`syntax = "proto3";
+package %s;
+
+message Order {
+  %s
+}
+`, pkg, strings.Join(fields, "\n  "))
+}
+
+func TestUploadSchema(t *testing.T) {
+  svc    := newTestService()
+  entity := users.NewEntityID()
+
+  first, err := svc.upload(entity, "orders", "", ordersProto(
+    `string id = 1;`,
+    `string note = 2;`,
+  ))
+  if err != nil {
+    t.Fatal(err)
+  }
+  assert.Equal(t, 1, first.Version)
+  assert.False(t, first.Unchanged)
+  assert.Len(t, first.Manifest.Files, 1)
+  assert.Equal(t, storeState{ Objects: 2, Writes: 1, Versions: 1 }, svc.state())
+  assert.Contains(t, svc.grph.keys(domain.LabelMessage), graphKey(entity, "acme.orders.v1.Order"))
+
+  // ->> The exact files of the latest version return it as is.
+  again, err := svc.upload(entity, "orders", "", ordersProto(
+    `string id = 1;`,
+    `string note = 2;`,
+  ))
+  assert.NoError(t, err)
+  assert.True(t, again.Unchanged)
+  assert.Equal(t, 1, again.Version)
+  assert.Equal(t, storeState{ Objects: 2, Writes: 1, Versions: 1 }, svc.state())
+
+  // ->> Incompatible uploads list every violation and write nothing.
+  before := svc.state()
+  _, err = svc.upload(entity, "orders", "", ordersProto(
+    `string id = 1;`,
+    `int64 note = 2;`,
+  ))
+  var compatErr *domain.CompatibilityError
+  if assert.ErrorAs(t, err, &compatErr) {
+    assert.Equal(t, "orders", compatErr.Subject)
+    assert.Equal(t, domain.CompatibilityBackward, compatErr.Mode)
+    assert.NotEmpty(t, compatErr.Violations)
+    for _, v := range compatErr.Violations {
+      assert.Equal(t, 1, v.Version)
+      assert.Equal(t, domain.DirectionBackward, v.Direction)
+    }
+    assert.Equal(t, "FIELD_SAME_TYPE", compatErr.Violations[0].RuleID)
+  }
+  assert.Equal(t, before, svc.state())
+
+  second, err := svc.upload(entity, "orders", "1.1.0", ordersProto(
+    `string id = 1;`,
+    `string note = 2;`,
+    `string customer = 3;`,
+  ))
+  if err != nil {
+    t.Fatal(err)
+  }
+  assert.Equal(t, 2, second.Version)
+  assert.Equal(t, "1.1.0", second.Tag)
+  assert.Equal(t, storeState{ Objects: 4, Writes: 2, Versions: 2 }, svc.state())
+  assert.Contains(t, svc.grph.keys(domain.LabelParameter), graphKey(entity, "acme.orders.v1.Order.customer"))
+
+  // ->> Tags can't be claimed twice.
+  before = svc.state()
+  _, err = svc.upload(entity, "orders", "v1.1.0", ordersProto(
+    `string id = 1;`,
+    `string note = 2;`,
+    `string customer = 3;`,
+    `string currency = 4;`,
+  ))
+  assert.ErrorIs(t, err, ErrVersionTagExists)
+  assert.Equal(t, before, svc.state())
+
+  // ->> Error severity Policies block the upload before anything is stored.
+  svc.psql.policies[entity] = []domain.Policy{{
+    Name       : "tenant-id",
+    Target     : domain.PolicyMessage,
+    Expression : `message.fields.exists(f, f.name == "tenant_id")`,
+    Severity   : domain.SeverityError,
+  }}
+  _, err = svc.upload(entity, "orders", "", ordersProto(
+    `string id = 1;`,
+    `string note = 2;`,
+    `string customer = 3;`,
+    `string currency = 4;`,
+  ))
+  assert.ErrorIs(t, err, ErrPolicyViolated)
+  var diagErr domain.DiagnosticError
+  if assert.ErrorAs(t, err, &diagErr) {
+    rules := []string{}
+    for _, d := range diagErr.Diagnostics() {
+      rules = append(rules, d.Rule)
+    }
+    assert.Contains(t, rules, "tenant-id")
+  }
+  assert.Equal(t, before, svc.state())
+  svc.psql.policies[entity] = nil
+
+  // ->> Nothing is written unless the version number is claimed.
+  svc.psql.claimErr = repo.ErrDBSchemaVersionExists
+  _, err = svc.upload(entity, "orders", "", ordersProto(
+    `string id = 1;`,
+    `string note = 2;`,
+    `string customer = 3;`,
+    `string currency = 4;`,
+  ))
+  assert.ErrorIs(t, err, repo.ErrDBSchemaVersionExists)
+  assert.Equal(t, before, svc.state())
+  svc.psql.claimErr = nil
+
+  // ->> The version is only recorded once its graph is written.
+  svc.grph.err = errors.New("graph unavailable")
+  _, err = svc.upload(entity, "orders", "", ordersProto(
+    `string id = 1;`,
+    `string note = 2;`,
+    `string customer = 3;`,
+    `string currency = 4;`,
+  ))
+  assert.ErrorIs(t, err, ErrSchemaGraphFailed)
+  assert.Equal(t, before.Versions, svc.state().Versions)
+  assert.Equal(t, before.Writes, svc.state().Writes)
+  svc.grph.err = nil
+
+  // ->> Retrying reuses the blobs the failed attempt stored.
+  third, err := svc.upload(entity, "orders", "", ordersProto(
+    `string id = 1;`,
+    `string note = 2;`,
+    `string customer = 3;`,
+    `string currency = 4;`,
+  ))
+  assert.NoError(t, err)
+  assert.Equal(t, 3, third.Version)
+  assert.Equal(t, storeState{ Objects: 6, Writes: 3, Versions: 3 }, svc.state())
+
+  _, err = svc.upload(entity, "orders/v1", "", ordersProto())
+  assert.ErrorIs(t, err, ErrInvalidUploadRequest)
+  _, err = svc.upload(entity, "orders", "", `syntax = "proto3"; message {`)
+  assert.ErrorIs(t, err, ErrSchemaCompileFailed)
+
+  // ->> Subjects are only registered along with their first accepted version.
+  _, err = svc.upload(entity, "payments", "", `syntax = "proto3"; message {`)
+  assert.ErrorIs(t, err, ErrSchemaCompileFailed)
+  _, err = svc.psql.GetSubject(context.Background(), entity, "payments")
+  assert.ErrorIs(t, err, domain.ErrSubjectNotFound)
+}
+
+func TestUploadSchemaNamespaces(t *testing.T) {
+  svc := newTestService()
+  a   := users.NewEntityID()
+  b   := users.NewEntityID()
+
+  // ->> Both Entities own a package named acme.orders.v1, with different fields.
+  _, err := svc.upload(a, "orders", "", ordersProto(`string id = 1;`, `string note = 2;`))
+  assert.NoError(t, err)
+  _, err = svc.upload(b, "orders", "", ordersProto(`string id = 1;`, `int64 total = 2;`))
+  assert.NoError(t, err)
+
+  assert.ElementsMatch(t, []string{
+    graphKey(a, "acme.orders.v1.Order"),
+    graphKey(b, "acme.orders.v1.Order"),
+  }, svc.grph.keys(domain.LabelMessage))
+  assert.ElementsMatch(t, []string{
+    graphKey(a, "acme.orders.v1.Order.id"),
+    graphKey(a, "acme.orders.v1.Order.note"),
+    graphKey(b, "acme.orders.v1.Order.id"),
+    graphKey(b, "acme.orders.v1.Order.total"),
+  }, svc.grph.keys(domain.LabelParameter))
+
+  // ->> Rewriting one Entity's package leaves the other's untouched.
+  _, err = svc.upload(a, "orders", "", ordersProto(`string id = 1;`, `string note = 2;`, `string customer = 3;`))
+  assert.NoError(t, err)
+  assert.Contains(t, svc.grph.keys(domain.LabelParameter), graphKey(b, "acme.orders.v1.Order.total"))
+  assert.NotContains(t, svc.grph.keys(domain.LabelParameter), graphKey(b, "acme.orders.v1.Order.customer"))
+
+  // ->> Reloaded versions are compiled under their Entity's namespace too.
+  result, err := svc.ValidateSchema(context.Background(), b, "orders", 0)
+  assert.NoError(t, err)
+  assert.True(t, result.Valid)
+}
+
+func TestAnalyzeImpactNamespaces(t *testing.T) {
+  ctx := context.Background()
+  svc := newTestService()
+  a   := users.NewEntityID()
+  b   := users.NewEntityID()
+
+  source := `syntax = "proto3";
+package acme.orders.v1;
+
+import "google/protobuf/timestamp.proto";
+
+message Order {
+  string id = 1;
+  google.protobuf.Timestamp created_at = 2;
+}
+`
+  _, err := svc.upload(a, "orders", "", source)
+  assert.NoError(t, err)
+  _, err = svc.upload(b, "orders", "", source)
+  assert.NoError(t, err)
+
+  keys := func(nodes []domain.ImpactedNode) []string {
+    out := []string{}
+    for _, node := range nodes {
+      out = append(out, node.Key)
+    }
+    return out
+  }
+
+  // ->> Shared definitions only report the caller's dependents.
+  timestamp := domain.NodeRef{ Label: domain.LabelMessage, Key: "google.protobuf.Timestamp" }
+  report, err := svc.AnalyzeImpact(ctx, a, timestamp, 0)
+  if err != nil {
+    t.Fatal(err)
+  }
+  assert.Equal(t, timestamp, report.Target)
+  assert.Contains(t, keys(report.Elements), graphKey(a, "acme.orders.v1.Order.created_at"))
+  assert.Contains(t, keys(report.Elements), graphKey(a, "acme.orders.v1.Order"))
+  for _, node := range append(append(report.Elements, report.Services...), report.Packages...) {
+    assert.NotEqual(t, domain.GraphNamespace(b), domain.KeyNamespace(node.Key), node.Key)
+  }
+
+  // ->> Names resolve to the caller's own definitions first.
+  order := domain.NodeRef{ Label: domain.LabelMessage, Key: "acme.orders.v1.Order" }
+  report, err = svc.AnalyzeImpact(ctx, b, order, 0)
+  if err != nil {
+    t.Fatal(err)
+  }
+  assert.Equal(t, graphKey(b, "acme.orders.v1.Order"), report.Target.Key)
+  for _, node := range append(append(report.Elements, report.Services...), report.Packages...) {
+    assert.NotEqual(t, domain.GraphNamespace(a), domain.KeyNamespace(node.Key), node.Key)
+  }
+
+  // ->> Another Entity's definitions can't be targeted, nor can unknown ones.
+  _, err = svc.AnalyzeImpact(ctx, b, domain.NodeRef{
+    Label : domain.LabelMessage,
+    Key   : graphKey(a, "acme.orders.v1.Order"),
+  }, 0)
+  assert.ErrorIs(t, err, domain.ErrImpactTargetNotFound)
+  _, err = svc.AnalyzeImpact(ctx, users.NewEntityID(), order, 0)
+  assert.ErrorIs(t, err, domain.ErrImpactTargetNotFound)
+}
+
+func TestUploadSchemaVersioning(t *testing.T) {
+  tests := []struct{
+    scheme   domain.VersioningScheme
+    pkg      string
+    tags     []string
+    packages []string
+    versions []string
+  }{
+    {
+      scheme   : domain.VersioningPackageSuffix,
+      pkg      : "acme.orders.v1beta1",
+      tags     : []string{ "", "" },
+      packages : []string{ "acme.orders.v1beta1" },
+      versions : []string{ "v1beta1" },
+    },
+    {
+      scheme   : domain.VersioningRegistry,
+      pkg      : "acme.orders",
+      tags     : []string{ "", "" },
+      packages : []string{ "acme.orders@1", "acme.orders@2" },
+      versions : []string{ "1", "2" },
+    },
+    {
+      scheme   : domain.VersioningSemver,
+      pkg      : "acme.orders",
+      tags     : []string{ "1.0.0", "v1.1.0-beta.1" },
+      packages : []string{ "acme.orders@1.0.0", "acme.orders@1.1.0-beta.1" },
+      versions : []string{ "1.0.0", "1.1.0-beta.1" },
+    },
+  }
+
+  for _, tt := range tests {
+    t.Run(string(tt.scheme), func(t *testing.T){
+      ctx    := context.Background()
+      svc    := newTestService()
+      entity := users.NewEntityID()
+      assert.NoError(t, svc.SetVersioning(ctx, entity, tt.scheme))
+
+      fields := []string{ `string id = 1;` }
+      for i, tag := range tt.tags {
+        fields = append(fields, fmt.Sprintf("string note_%d = %d;", i, i+2))
+        result, err := svc.upload(entity, "orders", tag, packageProto(tt.pkg, fields...))
+        if !assert.NoError(t, err) {
+          return
+        }
+        assert.Equal(t, i+1, result.Version)
+      }
+
+      packages := []string{}
+      versions := []string{}
+      for _, node := range svc.grph.graph.Nodes {
+        if node.Label == domain.LabelPackage {
+          packages = append(packages, node.Key)
+          versions = append(versions, node.Props["version"].(string))
+        }
+      }
+      want := []string{}
+      for _, pkg := range tt.packages {
+        want = append(want, graphKey(entity, pkg))
+      }
+      assert.Equal(t, want, packages)
+      assert.Equal(t, tt.versions, versions)
+
+      // ->> Reloaded versions resolve their Packages the same way.
+      for i := range tt.tags {
+        result, err := svc.ValidateSchema(ctx, entity, "orders", i+1)
+        assert.NoError(t, err)
+        assert.True(t, result.Valid)
+      }
+    })
+  }
+
+  ctx    := context.Background()
+  svc    := newTestService()
+  entity := users.NewEntityID()
+
+  // ->> The package suffix scheme needs a version segment.
+  _, err := svc.upload(entity, "orders", "", packageProto("acme.orders", `string id = 1;`))
+  assert.ErrorIs(t, err, ErrSchemaCompileFailed)
+
+  // ->> Semver Entities must tag every upload.
+  assert.NoError(t, svc.SetVersioning(ctx, entity, domain.VersioningSemver))
+  _, err = svc.upload(entity, "orders", "", packageProto("acme.orders", `string id = 1;`))
+  assert.ErrorIs(t, err, ErrInvalidVersionTag)
+  _, err = svc.upload(entity, "orders", "one", packageProto("acme.orders", `string id = 1;`))
+  assert.ErrorIs(t, err, ErrInvalidVersionTag)
+
+  // ->> Versions keep the scheme they were published with, so changing it
+  //     never breaks how past versions are reloaded and compared against.
+  entity = users.NewEntityID()
+  assert.NoError(t, svc.SetVersioning(ctx, entity, domain.VersioningRegistry))
+  first, err := svc.upload(entity, "orders", "", packageProto("acme.orders", `string id = 1;`))
+  assert.NoError(t, err)
+  assert.Equal(t, domain.VersioningRegistry, first.Versioning)
+  assert.NoError(t, svc.SetVersioning(ctx, entity, domain.VersioningSemver))
+  second, err := svc.upload(entity, "orders", "1.0.0", packageProto("acme.orders", `string id = 1;`, `string note = 2;`))
+  assert.NoError(t, err)
+  assert.Equal(t, domain.VersioningSemver, second.Versioning)
+  result, err := svc.ValidateSchema(ctx, entity, "orders", 1)
+  assert.NoError(t, err)
+  assert.True(t, result.Valid)
+  assert.Contains(t, svc.grph.keys(domain.LabelPackage), graphKey(entity, "acme.orders@1"))
+}
+
+func TestUploadSchemaTransitive(t *testing.T) {
+  tests := []struct{
+    mode       domain.CompatibilityMode
+    violations []domain.CompatibilityDirection
+  }{
+    { mode: domain.CompatibilityBackward },
+    { mode: domain.CompatibilityForward },
+    { mode: domain.CompatibilityFull },
+    {
+      mode       : domain.CompatibilityBackwardTransitive,
+      violations : []domain.CompatibilityDirection{ domain.DirectionBackward },
+    },
+    {
+      mode       : domain.CompatibilityForwardTransitive,
+      violations : []domain.CompatibilityDirection{ domain.DirectionForward },
+    },
+    {
+      mode       : domain.CompatibilityFullTransitive,
+      violations : []domain.CompatibilityDirection{ domain.DirectionBackward, domain.DirectionForward },
+    },
+  }
+
+  for _, tt := range tests {
+    t.Run(string(tt.mode), func(t *testing.T){
+      ctx    := context.Background()
+      svc    := newTestService()
+      entity := users.NewEntityID()
+
+      // ->> Version 2 breaks version 1 while the Subject checks nothing.
+      assert.NoError(t, svc.SetCompatibility(ctx, entity, "orders", domain.CompatibilityNone))
+      _, err := svc.upload(entity, "orders", "", ordersProto(`string id = 1;`, `string note = 2;`))
+      assert.NoError(t, err)
+      _, err = svc.upload(entity, "orders", "", ordersProto(`string id = 1;`, `int64 note = 2;`))
+      assert.NoError(t, err)
+
+      // ->> Version 3 only documents version 2, so it's compatible with it
+      //     either way, but not with version 1.
+      assert.NoError(t, svc.SetCompatibility(ctx, entity, "orders", tt.mode))
+      before := svc.state()
+      v3     := ordersProto(`string id = 1; // Unique per tenant.`, `int64 note = 2;`)
+      result, err := svc.upload(entity, "orders", "", v3)
+      if len(tt.violations) == 0 {
+        assert.NoError(t, err)
+        assert.Equal(t, 3, result.Version)
+        return
+      }
+
+      var compatErr *domain.CompatibilityError
+      if assert.ErrorAs(t, err, &compatErr) {
+        assert.Equal(t, tt.mode, compatErr.Mode)
+        directions := []domain.CompatibilityDirection{}
+        for _, v := range compatErr.Violations {
+          assert.Equal(t, 1, v.Version)
+          assert.Equal(t, "FIELD_SAME_TYPE", v.RuleID)
+          directions = append(directions, v.Direction)
+        }
+        assert.Equal(t, tt.violations, directions)
+      }
+      assert.Equal(t, before, svc.state())
+
+      // ->> Soft-deleted versions are never compared against.
+      _, err = svc.DeleteSchemaVersion(ctx, entity, "orders", "1")
+      assert.NoError(t, err)
+      result, err = svc.upload(entity, "orders", "", v3)
+      assert.NoError(t, err)
+      assert.Equal(t, 3, result.Version)
+    })
+  }
+}
+
+func TestDeleteRestoreSchemaVersion(t *testing.T) {
+  ctx    := context.Background()
+  svc    := newTestService()
+  entity := users.NewEntityID()
+
+  _, err := svc.upload(entity, "orders", "1.0.0", ordersProto(`string id = 1;`))
+  assert.NoError(t, err)
+  _, err = svc.upload(entity, "orders", "1.1.0", ordersProto(`string id = 1;`, `string note = 2;`))
+  assert.NoError(t, err)
+
+  _, err = svc.DeleteSchemaVersion(ctx, entity, "orders", "latest")
+  assert.ErrorIs(t, err, ErrSchemaVersionNotFound)
+
+  deleted, err := svc.DeleteSchemaVersion(ctx, entity, "orders", "1.1.0")
+  if err != nil {
+    t.Fatal(err)
+  }
+  assert.Equal(t, 2, deleted.Version)
+  assert.NotNil(t, deleted.DeletedAt)
+
+  active, err := svc.ListSchemaVersions(ctx, entity, "orders", false)
+  assert.NoError(t, err)
+  assert.Len(t, active, 1)
+  all, err := svc.ListSchemaVersions(ctx, entity, "orders", true)
+  assert.NoError(t, err)
+  assert.Len(t, all, 2)
+
+  bundle, err := svc.DownloadSchema(ctx, entity, "orders", "latest", nil)
+  assert.NoError(t, err)
+  assert.Equal(t, 1, bundle.Manifest.Version)
+  _, err = svc.DownloadSchema(ctx, entity, "orders", "2", nil)
+  assert.ErrorIs(t, err, ErrSchemaVersionNotFound)
+
+  // ->> Deleted versions keep their version number and tag.
+  _, err = svc.upload(entity, "orders", "1.1.0", ordersProto(`string id = 1;`, `string other = 2;`))
+  assert.ErrorIs(t, err, ErrVersionTagExists)
+
+  restored, err := svc.RestoreSchemaVersion(ctx, entity, "orders", "2")
+  assert.NoError(t, err)
+  assert.Nil(t, restored.DeletedAt)
+  bundle, err = svc.DownloadSchema(ctx, entity, "orders", "latest", nil)
+  assert.NoError(t, err)
+  assert.Equal(t, 2, bundle.Manifest.Version)
+  assert.Equal(t, "1.1.0", bundle.Manifest.Tag)
+
+  // ->> Restoring a version whose tag was claimed in the meantime, e.g. by a
+  //     concurrent upload, is refused.
+  _, err = svc.DeleteSchemaVersion(ctx, entity, "orders", "2")
+  assert.NoError(t, err)
+  assert.NoError(t, svc.psql.CreateSchemaVersion(ctx, "orders", &domain.SchemaVersion{
+    SubjectID : restored.SubjectID,
+    EntityID  : entity,
+    Version   : 3,
+    Tag       : "1.1.0",
+  }, func() error { return nil }))
+  _, err = svc.RestoreSchemaVersion(ctx, entity, "orders", "2")
+  assert.ErrorIs(t, err, ErrVersionTagExists)
+}
+
+func TestUploadDescriptorSet(t *testing.T) {
+  ctx    := context.Background()
+  svc    := newTestService()
+  entity := users.NewEntityID()
+
+  descriptor := func(source string) []byte {
+    files, err := proto.NewSourceFiles(ctx, map[string][]byte{
+      "acme/orders/v1/orders.proto": []byte(source),
+    })
+    if err != nil {
+      t.Fatal(err)
+    }
+    data, err := files.FileDescriptorSet(domain.DescriptorOptions{})
+    if err != nil {
+      t.Fatal(err)
+    }
+    return data
+  }
+  upload := func(data []byte)( *UploadSchemaResult, error ){
+    return svc.UploadSchema(ctx, UploadSchemaRequest{
+      EntityID   : entity,
+      Subject    : "orders",
+      Descriptor : data,
+    })
+  }
+
+  first, err := upload(descriptor(ordersProto(`string id = 1;`, `string note = 2;`)))
+  if err != nil {
+    t.Fatal(err)
+  }
+  assert.Equal(t, 1, first.Version)
+  assert.Equal(t, domain.DescriptorSetFile, first.Manifest.Files[0].Path)
+  assert.Contains(t, svc.grph.keys(domain.LabelParameter), graphKey(entity, "acme.orders.v1.Order.note"))
+
+  again, err := upload(descriptor(ordersProto(`string id = 1;`, `string note = 2;`)))
+  assert.NoError(t, err)
+  assert.True(t, again.Unchanged)
+
+  // ->> Stored descriptor sets are compared against like sources.
+  before := svc.state()
+  _, err = upload(descriptor(ordersProto(`string id = 1;`, `int64 note = 2;`)))
+  var compatErr *domain.CompatibilityError
+  assert.ErrorAs(t, err, &compatErr)
+  assert.Equal(t, before, svc.state())
+
+  // ->> Sources are checked against a stored descriptor set, and vice versa.
+  second, err := svc.upload(entity, "orders", "", ordersProto(
+    `string id = 1;`,
+    `string note = 2;`,
+    `string customer = 3;`,
+  ))
+  assert.NoError(t, err)
+  assert.Equal(t, 2, second.Version)
+  _, err = upload(descriptor(ordersProto(`string id = 1;`)))
+  assert.ErrorAs(t, err, &compatErr)
+
+  exported, err := svc.ExportDescriptors(ctx, entity, "orders", "1", domain.DescriptorOptions{})
+  assert.NoError(t, err)
+  assert.Equal(t, descriptor(ordersProto(`string id = 1;`, `string note = 2;`)), exported.Data)
+
+  _, err = svc.UploadSchema(ctx, UploadSchemaRequest{
+    EntityID   : entity,
+    Subject    : "orders",
+    Descriptor : descriptor(ordersProto(`string id = 1;`)),
+    Files      : map[string][]byte{ "a.proto": []byte(`syntax = "proto3";`) },
+  })
+  assert.ErrorIs(t, err, ErrInvalidUploadRequest)
+  _, err = upload([]byte("not a descriptor set"))
+  assert.ErrorIs(t, err, ErrSchemaCompileFailed)
+}
@@ -0,0 +1,225 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
+	"github.com/TylerAldrich814/Fidicus/internal/shared/utils"
+)
+
+// StagedUploadRequest defines a Subject version whose files will be uploaded
+// directly into Blob Storage. Files lists the import path of every file, see
+// UploadSchemaRequest.
+type StagedUploadRequest struct {
+  EntityID  users.EntityID
+  AccountID users.AccountID
+  Subject   string
+  Tag       string
+  Files     []string
+}
+
+// StagedFile defines the presigned URL a single staged file is PUT to.
+type StagedFile struct {
+  Path string `json:"path"`
+  URL  string `json:"url"`
+}
+
+// StagedUploadResult defines a started StagedUpload, along with the URL
+// every one of its files must be uploaded to before it expires.
+type StagedUploadResult struct {
+  *domain.StagedUpload
+  URLs []StagedFile `json:"urls"`
+}
+
+// CreateStagedUpload - Starts a StagedUpload, issuing a presigned PUT URL for
+// every file, all valid for domain.StagedUploadTTL. Nothing is compiled until
+// the upload is finalized with FinalizeStagedUpload, which also refuses
+// uploads larger than MaxUploadSize. Expired uploads are purged along the way.
+//
+// Potential Errors:
+//   - ErrInvalidUploadRequest
+//   - ErrInvalidSchemaPath
+//   - ErrUploadTooLarge
+//   - ErrInvalidVersionTag
+//   - Any error returned by the Blob or SQL repositories.
+func(s *Service) CreateStagedUpload(
+  ctx context.Context,
+  req StagedUploadRequest,
+)( *StagedUploadResult, error ){
+  if !domain.IsSubjectName(req.Subject) || len(req.Files) == 0 {
+    return nil, ErrInvalidUploadRequest
+  }
+  expected := make(map[string][]byte, len(req.Files))
+  for _, p := range req.Files {
+    if _, ok := expected[p]; ok {
+      return nil, fmt.Errorf("%w: %q is uploaded twice", ErrInvalidSchemaPath, p)
+    }
+    expected[p] = nil
+  }
+  cleaned, err := cleanUploadFiles(expected)
+  if err != nil {
+    return nil, err
+  }
+  _, tag, err := s.resolveTag(ctx, req.EntityID, req.Tag)
+  if err != nil {
+    return nil, err
+  }
+
+  s.purgeExpiredUploads(ctx)
+
+  files := make([]string, 0, len(cleaned))
+  for p := range cleaned {
+    files = append(files, p)
+  }
+  sort.Strings(files)
+
+  now    := time.Now().UTC()
+  upload := &domain.StagedUpload{
+    EntityID  : req.EntityID,
+    AccountID : req.AccountID,
+    Subject   : req.Subject,
+    Tag       : tag,
+    Files     : files,
+    ExpiresAt : now.Add(domain.StagedUploadTTL),
+  }
+  if err := s.psql.CreateStagedUpload(ctx, upload); err != nil {
+    return nil, err
+  }
+
+  result := &StagedUploadResult{
+    StagedUpload : upload,
+    URLs         : make([]StagedFile, 0, len(files)),
+  }
+  for _, p := range files {
+    url, err := s.blob.GeneratePresignedPutURL(ctx, upload.EntityID, upload.ID, p, domain.StagedUploadTTL)
+    if err != nil {
+      return nil, err
+    }
+    result.URLs = append(result.URLs, StagedFile{ Path: p, URL: url })
+  }
+  return result, nil
+}
+
+// FinalizeStagedUpload - Reads every staged file of a StagedUpload and runs
+// them through UploadSchema, promoting them into a published Subject version.
+// Uploads larger than MaxUploadSize are refused before anything is read.
+// The staged files are discarded once published. When the upload is
+// rejected it's kept, so files can be uploaded again and finalized before
+// it expires.
+//
+// Potential Errors:
+//   - ErrStagedUploadExpired
+//   - ErrStagedFileMissing
+//   - ErrUploadTooLarge
+//   - Any error returned by UploadSchema.
+//   - Any error returned by the Blob or SQL repositories.
+func(s *Service) FinalizeStagedUpload(
+  ctx      context.Context,
+  entityID users.EntityID,
+  id       uuid.UUID,
+)( *UploadSchemaResult, error ){
+  upload, err := s.psql.GetStagedUpload(ctx, entityID, id)
+  if err != nil {
+    return nil, err
+  }
+  if upload.IsExpired(time.Now()) {
+    return nil, ErrStagedUploadExpired
+  }
+
+  objects, err := s.blob.ListStagedFiles(ctx, upload.EntityID, upload.ID)
+  if err != nil {
+    return nil, err
+  }
+  staged := make(map[string]int64, len(objects))
+  for _, o := range objects {
+    staged[o.Path] = o.Size
+  }
+  missing := []string{}
+  size    := int64(0)
+  for _, p := range upload.Files {
+    stat, ok := staged[p]
+    if !ok {
+      missing = append(missing, p)
+    }
+    size += stat
+  }
+  if len(missing) != 0 {
+    return nil, fmt.Errorf("%w: %s", ErrStagedFileMissing, strings.Join(missing, ", "))
+  }
+  if size > MaxUploadSize {
+    return nil, ErrUploadTooLarge
+  }
+
+  // ->> Files may still be replaced after they're listed, so every read is
+  //     bounded by what's left of MaxUploadSize.
+  files := make(map[string][]byte, len(upload.Files))
+  size   = 0
+  for _, p := range upload.Files {
+    data, err := s.blob.DownloadStagedFile(ctx, upload.EntityID, upload.ID, p, MaxUploadSize-size)
+    if err != nil {
+      return nil, err
+    }
+    if size += int64(len(data)); size > MaxUploadSize {
+      return nil, ErrUploadTooLarge
+    }
+    files[p] = data
+  }
+
+  result, err := s.UploadSchema(ctx, UploadSchemaRequest{
+    EntityID  : upload.EntityID,
+    AccountID : upload.AccountID,
+    Subject   : upload.Subject,
+    Tag       : upload.Tag,
+    Files     : files,
+  })
+  if err != nil {
+    return nil, err
+  }
+
+  s.discardStagedUpload(ctx, *upload)
+  if err := s.psql.DeleteStagedUpload(ctx, upload.EntityID, upload.ID); err != nil {
+    utils.NewLogHandlerFunc(
+      "FinalizeStagedUpload",
+      log.Fields{ "upload_id": upload.ID.String() },
+    )(utils.LogWarn, "failed to delete finalized upload: %s", err.Error())
+  }
+  return result, nil
+}
+
+// purgeExpiredUploads - Removes every expired StagedUpload along with its
+// staged files. Failures are only logged; the bucket's lifecycle rules
+// remove staged files regardless.
+func(s *Service) purgeExpiredUploads(ctx context.Context) {
+  expired, err := s.psql.DeleteExpiredStagedUploads(ctx, time.Now().UTC())
+  if err != nil {
+    utils.NewLogHandlerFunc(
+      "purgeExpiredUploads",
+      log.Fields{},
+    )(utils.LogWarn, "failed to purge expired uploads: %s", err.Error())
+    return
+  }
+  for _, upload := range expired {
+    s.discardStagedUpload(ctx, upload)
+  }
+}
+
+// discardStagedUpload - Deletes every file staged for 'upload'.
+func(s *Service) discardStagedUpload(
+  ctx    context.Context,
+  upload domain.StagedUpload,
+) {
+  if err := s.blob.DeleteStagedUpload(ctx, upload.EntityID, upload.ID); err != nil {
+    utils.NewLogHandlerFunc(
+      "discardStagedUpload",
+      log.Fields{ "upload_id": upload.ID.String() },
+    )(utils.LogWarn, "failed to delete staged files: %s", err.Error())
+  }
+}
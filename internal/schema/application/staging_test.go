@@ -0,0 +1,97 @@
+package application
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
+)
+
+func TestFinalizeStagedUpload(t *testing.T) {
+  ctx    := context.Background()
+  svc    := newTestService()
+  entity := users.NewEntityID()
+
+  stage := func(source string) *StagedUploadResult {
+    staged, err := svc.CreateStagedUpload(ctx, StagedUploadRequest{
+      EntityID : entity,
+      Subject  : "orders",
+      Files    : []string{ "acme/orders/v1/orders.proto" },
+    })
+    if err != nil {
+      t.Fatal(err)
+    }
+    if source != "" {
+      key, err := domain.StagedFileKey(entity, staged.ID, "acme/orders/v1/orders.proto")
+      if err != nil {
+        t.Fatal(err)
+      }
+      svc.blob.objects[key] = []byte(source)
+    }
+    return staged
+  }
+
+  _, err := svc.CreateStagedUpload(ctx, StagedUploadRequest{
+    EntityID : entity,
+    Subject  : "orders",
+    Files    : []string{ "a.proto", "./a.proto" },
+  })
+  assert.ErrorIs(t, err, ErrInvalidSchemaPath)
+
+  // ->> Nothing is published until every file is staged.
+  staged := stage("")
+  assert.Len(t, staged.URLs, 1)
+  assert.Equal(t, "acme/orders/v1/orders.proto", staged.URLs[0].Path)
+  _, err = svc.FinalizeStagedUpload(ctx, entity, staged.ID)
+  assert.ErrorIs(t, err, ErrStagedFileMissing)
+  assert.Equal(t, storeState{}, svc.state())
+
+  staged = stage(ordersProto(`string id = 1;`, `string note = 2;`))
+  result, err := svc.FinalizeStagedUpload(ctx, entity, staged.ID)
+  if err != nil {
+    t.Fatal(err)
+  }
+  assert.Equal(t, 1, result.Version)
+  assert.NotContains(t, svc.psql.staged, staged.ID)
+  paths, err := svc.blob.ListStagedFiles(ctx, entity, staged.ID)
+  assert.NoError(t, err)
+  assert.Empty(t, paths)
+
+  // ->> Rejected uploads are kept, so they can be fixed and finalized again.
+  staged = stage(ordersProto(`string id = 1;`, `int64 note = 2;`))
+  before := svc.state()
+  _, err = svc.FinalizeStagedUpload(ctx, entity, staged.ID)
+  var compatErr *domain.CompatibilityError
+  assert.ErrorAs(t, err, &compatErr)
+  assert.Equal(t, before, svc.state())
+  assert.Contains(t, svc.psql.staged, staged.ID)
+
+  // ->> Other Entities can't finalize the upload.
+  _, err = svc.FinalizeStagedUpload(ctx, users.NewEntityID(), staged.ID)
+  assert.Error(t, err)
+
+  // ->> Oversized files are refused before they're downloaded.
+  staged = stage(strings.Repeat(" ", int(MaxUploadSize)+1))
+  before = svc.state()
+  svc.blob.downloads = 0
+  _, err = svc.FinalizeStagedUpload(ctx, entity, staged.ID)
+  assert.ErrorIs(t, err, ErrUploadTooLarge)
+  assert.Zero(t, svc.blob.downloads)
+  assert.Equal(t, before, svc.state())
+
+  svc.psql.staged[staged.ID].ExpiresAt = time.Now().Add(-time.Minute)
+  _, err = svc.FinalizeStagedUpload(ctx, entity, staged.ID)
+  assert.ErrorIs(t, err, ErrStagedUploadExpired)
+
+  // ->> Expired uploads are purged, staged files included, by the next one.
+  stage("")
+  assert.NotContains(t, svc.psql.staged, staged.ID)
+  paths, err = svc.blob.ListStagedFiles(ctx, entity, staged.ID)
+  assert.NoError(t, err)
+  assert.Empty(t, paths)
+}
@@ -0,0 +1,274 @@
+package application
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+)
+
+const (
+  // MaxUploadSize -- The largest upload, archive or multipart body, accepted.
+  MaxUploadSize int64 = 64 << 20
+  // MaxArchiveSize -- The most a tar archive may decompress to, skipped
+  // entries included, so compressed padding can't be inflated endlessly.
+  MaxArchiveSize int64 = 4 * MaxUploadSize
+  // MaxUploadFiles -- The most schema files a single upload may contain.
+  MaxUploadFiles = 10_000
+)
+
+// SchemaExtensions -- File extensions kept when extracting an archive.
+// Anything else in the tree, e.g. READMEs or build files, is skipped. Only
+// protobuf sources are accepted, since uploads are compiled by the Service's
+// protobuf SchemaLoader; OpenAPI and GraphQL documents can't be uploaded yet.
+var SchemaExtensions = []string{ ".proto" }
+
+// CleanSchemaPath - Validates an uploaded file's path, returning it in
+// canonical form. Paths must be relative, use forward slashes and stay within
+// the upload's root, outside of the ReservedPathPrefix.
+//
+// Potential Errors:
+//   - ErrInvalidSchemaPath
+func CleanSchemaPath(p string)( string, error ){
+  cleaned, err := domain.CleanBlobPath(p)
+  if err != nil {
+    return "", fmt.Errorf("%w: %q", ErrInvalidSchemaPath, p)
+  }
+  if strings.HasPrefix(cleaned+"/", ReservedPathPrefix) {
+    return "", fmt.Errorf("%w: %q is reserved", ErrInvalidSchemaPath, p)
+  }
+  return cleaned, nil
+}
+
+// ArchiveFormat defines the archive formats an upload can be sent as.
+//
+// Possible Values
+//    - ArchiveZip   :: .zip
+//    - ArchiveTarGz :: .tar.gz or .tgz
+//    - ArchiveTar   :: .tar
+type ArchiveFormat string
+const (
+  ArchiveZip   ArchiveFormat = "zip"
+  ArchiveTarGz ArchiveFormat = "tar.gz"
+  ArchiveTar   ArchiveFormat = "tar"
+)
+
+// DetectArchiveFormat - Returns the ArchiveFormat of 'filename', by its
+// extension, falling back to 'contentType'. Returns false when neither
+// names a supported archive.
+func DetectArchiveFormat(
+  filename    string,
+  contentType string,
+)( ArchiveFormat, bool ){
+  name := strings.ToLower(filename)
+  switch {
+  case strings.HasSuffix(name, ".zip"):
+    return ArchiveZip, true
+  case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+    return ArchiveTarGz, true
+  case strings.HasSuffix(name, ".tar"):
+    return ArchiveTar, true
+  }
+
+  switch strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0])) {
+  case "application/zip", "application/x-zip-compressed":
+    return ArchiveZip, true
+  case "application/gzip", "application/x-gzip", "application/x-tar+gzip":
+    return ArchiveTarGz, true
+  case "application/x-tar":
+    return ArchiveTar, true
+  }
+  return "", false
+}
+
+// ExtractArchive - Reads every schema file out of an archive, keyed by its
+// path relative to 'root'. Files outside 'root', and files without one of
+// the SchemaExtensions, are skipped. Every kept path is validated with
+// CleanSchemaPath.
+//
+// Potential Errors:
+//   - ErrInvalidArchive
+//   - ErrInvalidSchemaPath
+//   - ErrUploadTooLarge
+func ExtractArchive(
+  format ArchiveFormat,
+  r      io.Reader,
+  root   string,
+)( map[string][]byte, error ){
+  root = strings.Trim(root, "/")
+  if root != "" {
+    cleaned, err := CleanSchemaPath(root)
+    if err != nil {
+      return nil, err
+    }
+    root = cleaned + "/"
+  }
+
+  extracted := &extraction{ root: root, files: map[string][]byte{} }
+  var err error
+  switch format {
+  case ArchiveZip:
+    err = extracted.zip(r)
+  case ArchiveTarGz:
+    var gz *gzip.Reader
+    if gz, err = gzip.NewReader(r); err == nil {
+      err = extracted.tar(gz)
+      _ = gz.Close()
+    }
+  case ArchiveTar:
+    err = extracted.tar(r)
+  default:
+    return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidArchive, format)
+  }
+  if err != nil {
+    if errors.Is(err, ErrInvalidSchemaPath) || errors.Is(err, ErrUploadTooLarge) {
+      return nil, err
+    }
+    return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+  }
+  if len(extracted.files) == 0 {
+    return nil, fmt.Errorf("%w: no schema files found", ErrInvalidArchive)
+  }
+  return extracted.files, nil
+}
+
+// extraction -- Collects the files of an archive while enforcing the upload limits.
+type extraction struct {
+  root  string
+  files map[string][]byte
+  size  int64
+}
+
+func(e *extraction) zip(r io.Reader) error {
+  data, err := io.ReadAll(io.LimitReader(r, MaxUploadSize+1))
+  if err != nil {
+    return err
+  }
+  if int64(len(data)) > MaxUploadSize {
+    return ErrUploadTooLarge
+  }
+  archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+  if err != nil {
+    return err
+  }
+  for _, f := range archive.File {
+    if f.FileInfo().IsDir() || !f.Mode().IsRegular() {
+      continue
+    }
+    if err := e.add(f.Name, func()( io.ReadCloser, error ){ return f.Open() }); err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+func(e *extraction) tar(r io.Reader) error {
+  // ->> Skipped entries are still read through to reach the next header, so
+  //     everything read counts towards MaxArchiveSize.
+  limited := &io.LimitedReader{ R: r, N: MaxArchiveSize+1 }
+  archive := tar.NewReader(limited)
+  for {
+    header, err := archive.Next()
+    if limited.N <= 0 {
+      return ErrUploadTooLarge
+    }
+    if err == io.EOF {
+      return nil
+    }
+    if err != nil {
+      return err
+    }
+    if header.Typeflag != tar.TypeReg {
+      continue
+    }
+    if err := e.add(header.Name, func()( io.ReadCloser, error ){
+      return io.NopCloser(archive), nil
+    }); err != nil {
+      return err
+    }
+  }
+}
+
+// add - Reads a single archived file, when it's a schema file under the root.
+func(e *extraction) add(
+  name string,
+  open func()( io.ReadCloser, error ),
+) error {
+  name = strings.TrimPrefix(name, "./")
+  // ->> Skip hidden files, e.g. the "._" metadata macOS adds to archives.
+  if !hasSchemaExtension(name) ||
+     strings.HasPrefix(path.Base(name), ".") ||
+     strings.HasPrefix(name, "__MACOSX/") {
+    return nil
+  }
+  p, err := CleanSchemaPath(name)
+  if err != nil {
+    return err
+  }
+  if e.root != "" {
+    if !strings.HasPrefix(p, e.root) {
+      return nil
+    }
+    p = strings.TrimPrefix(p, e.root)
+  }
+  if _, ok := e.files[p]; ok {
+    return fmt.Errorf("%w: %q is uploaded twice", ErrInvalidSchemaPath, p)
+  }
+  if len(e.files) >= MaxUploadFiles {
+    return ErrUploadTooLarge
+  }
+
+  rc, err := open()
+  if err != nil {
+    return err
+  }
+  defer rc.Close()
+
+  data, err := io.ReadAll(io.LimitReader(rc, MaxUploadSize-e.size+1))
+  if err != nil {
+    return err
+  }
+  if e.size += int64(len(data)); e.size > MaxUploadSize {
+    return ErrUploadTooLarge
+  }
+  e.files[p] = data
+  return nil
+}
+
+// AddUploadFile - Adds a single uploaded file to 'files', keyed by its
+// canonical path. Paths are never overwritten, so two files sharing one,
+// e.g. a "files" part colliding with an archive entry, reject the upload.
+//
+// Potential Errors:
+//   - ErrInvalidSchemaPath
+func AddUploadFile(
+  files map[string][]byte,
+  p     string,
+  data  []byte,
+) error {
+  cleaned, err := CleanSchemaPath(p)
+  if err != nil {
+    return err
+  }
+  if _, ok := files[cleaned]; ok {
+    return fmt.Errorf("%w: %q is uploaded twice", ErrInvalidSchemaPath, cleaned)
+  }
+  files[cleaned] = data
+  return nil
+}
+
+func hasSchemaExtension(name string) bool {
+  for _, ext := range SchemaExtensions {
+    if strings.HasSuffix(strings.ToLower(name), ext) {
+      return true
+    }
+  }
+  return false
+}
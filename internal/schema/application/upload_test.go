@@ -0,0 +1,140 @@
+package application
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanSchemaPath(t *testing.T) {
+  tests := []struct{
+    path string
+    want string
+    err  bool
+  }{
+    { path: "acme/v1/a.proto",      want: "acme/v1/a.proto" },
+    { path: "./acme//v1/a.proto",   want: "acme/v1/a.proto" },
+    { path: "../a.proto",           err: true },
+    { path: "acme/../../a.proto",   err: true },
+    { path: "/etc/a.proto",         err: true },
+    { path: "acme\\v1\\a.proto",    err: true },
+    { path: "C:/a.proto",           err: true },
+    { path: " ",                    err: true },
+    { path: ".fidicus/a.proto",     err: true },
+    { path: "./.fidicus",           err: true },
+  }
+  for _, tt := range tests {
+    got, err := CleanSchemaPath(tt.path)
+    if tt.err {
+      assert.ErrorIs(t, err, ErrInvalidSchemaPath, tt.path)
+      continue
+    }
+    assert.NoError(t, err, tt.path)
+    assert.Equal(t, tt.want, got)
+  }
+}
+
+func TestExtractArchive(t *testing.T) {
+  entries := map[string]string{
+    "repo/proto/acme/v1/a.proto" : `syntax = "proto3";`,
+    "repo/proto/acme/v1/b.proto" : `syntax = "proto3";`,
+    "repo/proto/._a.proto"       : "",
+    "repo/README.md"             : "# acme",
+    "repo/other/c.proto"         : `syntax = "proto3";`,
+  }
+
+  var zipped bytes.Buffer
+  zw := zip.NewWriter(&zipped)
+  for name, content := range entries {
+    f, _ := zw.Create(name)
+    _, _ = f.Write([]byte(content))
+  }
+  assert.NoError(t, zw.Close())
+
+  var tarred bytes.Buffer
+  gz := gzip.NewWriter(&tarred)
+  tw := tar.NewWriter(gz)
+  for name, content := range entries {
+    assert.NoError(t, tw.WriteHeader(&tar.Header{
+      Name     : name,
+      Mode     : 0o644,
+      Size     : int64(len(content)),
+      Typeflag : tar.TypeReg,
+    }))
+    _, _ = tw.Write([]byte(content))
+  }
+  assert.NoError(t, tw.Close())
+  assert.NoError(t, gz.Close())
+
+  for format, data := range map[ArchiveFormat][]byte{
+    ArchiveZip   : zipped.Bytes(),
+    ArchiveTarGz : tarred.Bytes(),
+  }{
+    files, err := ExtractArchive(format, bytes.NewReader(data), "/repo/proto/")
+    assert.NoError(t, err, format)
+    assert.Len(t, files, 2, format)
+    assert.Contains(t, files, "acme/v1/a.proto")
+    assert.Contains(t, files, "acme/v1/b.proto")
+  }
+
+  _, err := ExtractArchive(ArchiveZip, bytes.NewReader(zipped.Bytes()), "missing")
+  assert.ErrorIs(t, err, ErrInvalidArchive)
+
+  _, err = ExtractArchive(ArchiveTarGz, bytes.NewReader([]byte("not gzip")), "")
+  assert.ErrorIs(t, err, ErrInvalidArchive)
+
+  var escaping bytes.Buffer
+  tw = tar.NewWriter(&escaping)
+  assert.NoError(t, tw.WriteHeader(&tar.Header{ Name: "../evil.proto", Mode: 0o644, Typeflag: tar.TypeReg }))
+  assert.NoError(t, tw.Close())
+  _, err = ExtractArchive(ArchiveTar, &escaping, "")
+  assert.ErrorIs(t, err, ErrInvalidSchemaPath)
+
+  // ->> Skipped entries count towards the decompressed size too.
+  var padded bytes.Buffer
+  gz  = gzip.NewWriter(&padded)
+  tw  = tar.NewWriter(gz)
+  pad := make([]byte, 1<<20)
+  assert.NoError(t, tw.WriteHeader(&tar.Header{
+    Name     : "README.md",
+    Mode     : 0o644,
+    Size     : MaxArchiveSize,
+    Typeflag : tar.TypeReg,
+  }))
+  for written := int64(0); written < MaxArchiveSize; written += int64(len(pad)) {
+    _, _ = tw.Write(pad)
+  }
+  assert.NoError(t, tw.WriteHeader(&tar.Header{ Name: "a.proto", Mode: 0o644, Typeflag: tar.TypeReg }))
+  assert.NoError(t, tw.Close())
+  assert.NoError(t, gz.Close())
+  _, err = ExtractArchive(ArchiveTarGz, &padded, "")
+  assert.ErrorIs(t, err, ErrUploadTooLarge)
+
+  var duplicated bytes.Buffer
+  tw = tar.NewWriter(&duplicated)
+  for _, name := range []string{ "acme/a.proto", "./acme/a.proto" } {
+    assert.NoError(t, tw.WriteHeader(&tar.Header{ Name: name, Mode: 0o644, Typeflag: tar.TypeReg }))
+  }
+  assert.NoError(t, tw.Close())
+  _, err = ExtractArchive(ArchiveTar, &duplicated, "")
+  assert.ErrorIs(t, err, ErrInvalidSchemaPath)
+
+  files := map[string][]byte{}
+  assert.NoError(t, AddUploadFile(files, "acme/a.proto", []byte("a")))
+  assert.ErrorIs(t, AddUploadFile(files, "./acme//a.proto", []byte("b")), ErrInvalidSchemaPath)
+  assert.ErrorIs(t, AddUploadFile(files, "../a.proto", nil), ErrInvalidSchemaPath)
+  assert.Equal(t, map[string][]byte{ "acme/a.proto": []byte("a") }, files)
+
+  format, ok := DetectArchiveFormat("schemas.TGZ", "")
+  assert.True(t, ok)
+  assert.Equal(t, ArchiveTarGz, format)
+  format, ok = DetectArchiveFormat("", "application/zip; charset=binary")
+  assert.True(t, ok)
+  assert.Equal(t, ArchiveZip, format)
+  _, ok = DetectArchiveFormat("a.proto", "text/plain")
+  assert.False(t, ok)
+}
@@ -0,0 +1,228 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
+)
+
+var ErrInvalidBlobKey = errors.New("blob keys must stay within their entity's namespace")
+
+// Blob Storage keys are always built from their parts, never accepted raw,
+// so every key is scoped to the Entity owning it:
+//    - entities/{entity}/subjects/{subject}/versions/{n}/{path} :: Subject version files and manifests.
+//    - entities/{entity}/blobs/sha256/{digest}                  :: Content-addressed file contents.
+//    - staging/{entity}/{upload}/{path}                         :: Files of a StagedUpload.
+
+// SchemaRef defines a file of a Subject version, by its path within the version.
+type SchemaRef struct {
+  Subject string
+  Version int
+  Path    string
+}
+
+// CleanBlobPath - Validates a file path relative to its namespace, returning
+// it in canonical form. Paths must be relative, use forward slashes and
+// never step outside of their namespace.
+//
+// Potential Errors:
+//   - ErrInvalidBlobKey
+func CleanBlobPath(p string)( string, error ){
+  switch {
+  case strings.TrimSpace(p) == "",
+       strings.ContainsAny(p, "\\\x00:"),
+       strings.HasPrefix(p, "/"):
+    return "", fmt.Errorf("%w: %q", ErrInvalidBlobKey, p)
+  }
+  for _, part := range strings.Split(p, "/") {
+    if part == ".." {
+      return "", fmt.Errorf("%w: %q", ErrInvalidBlobKey, p)
+    }
+  }
+  cleaned := path.Clean(p)
+  if cleaned == "." {
+    return "", fmt.Errorf("%w: %q", ErrInvalidBlobKey, p)
+  }
+  return cleaned, nil
+}
+
+// VersionPrefix - Returns the prefix every file of a Subject version is stored under.
+//
+// Potential Errors:
+//   - ErrInvalidBlobKey
+func VersionPrefix(
+  entityID users.EntityID,
+  subject  string,
+  version  int,
+)( string, error ){
+  entity, err := entitySegment(entityID)
+  if err != nil {
+    return "", err
+  }
+  if !IsSubjectName(subject) || version < 1 {
+    return "", fmt.Errorf("%w: subject %q version %d", ErrInvalidBlobKey, subject, version)
+  }
+  return path.Join(
+    "entities", entity,
+    "subjects", subject,
+    "versions", fmt.Sprint(version),
+  ) + "/", nil
+}
+
+// Key - Returns the key of the Subject version file 'r' refers to.
+//
+// Potential Errors:
+//   - ErrInvalidBlobKey
+func(r SchemaRef) Key(entityID users.EntityID)( string, error ){
+  prefix, err := VersionPrefix(entityID, r.Subject, r.Version)
+  if err != nil {
+    return "", err
+  }
+  p, err := CleanBlobPath(r.Path)
+  if err != nil {
+    return "", err
+  }
+  return prefix + p, nil
+}
+
+// BlobKey - Returns the key of an Entity's content-addressed blob.
+//
+// Potential Errors:
+//   - ErrInvalidBlobKey
+func BlobKey(
+  entityID users.EntityID,
+  digest   string,
+)( string, error ){
+  entity, err := entitySegment(entityID)
+  if err != nil {
+    return "", err
+  }
+  if !IsBlobDigest(digest) {
+    return "", fmt.Errorf("%w: digest %q", ErrInvalidBlobKey, digest)
+  }
+  return path.Join("entities", entity, "blobs", "sha256", digest), nil
+}
+
+// StagedUploadPrefix - Returns the prefix every file of a StagedUpload is
+// uploaded under, within StagingPrefix.
+//
+// Potential Errors:
+//   - ErrInvalidBlobKey
+func StagedUploadPrefix(
+  entityID users.EntityID,
+  uploadID uuid.UUID,
+)( string, error ){
+  entity, err := entitySegment(entityID)
+  if err != nil {
+    return "", err
+  }
+  if uploadID == uuid.Nil {
+    return "", fmt.Errorf("%w: missing upload id", ErrInvalidBlobKey)
+  }
+  return StagingPrefix + path.Join(entity, uploadID.String()) + "/", nil
+}
+
+// StagedFileKey - Returns the key a single file of a StagedUpload is uploaded to.
+//
+// Potential Errors:
+//   - ErrInvalidBlobKey
+func StagedFileKey(
+  entityID users.EntityID,
+  uploadID uuid.UUID,
+  file     string,
+)( string, error ){
+  prefix, err := StagedUploadPrefix(entityID, uploadID)
+  if err != nil {
+    return "", err
+  }
+  p, err := CleanBlobPath(file)
+  if err != nil {
+    return "", err
+  }
+  return prefix + p, nil
+}
+
+// IsSubjectName - Returns true when 'subject' can name a Subject. Names are a
+// single key segment.
+func IsSubjectName(subject string) bool {
+  return strings.TrimSpace(subject) != "" &&
+         subject != "." && subject != ".." &&
+         !strings.ContainsAny(subject, "/\\\x00")
+}
+
+func entitySegment(entityID users.EntityID)( string, error ){
+  if entityID == users.NilEntity() {
+    return "", fmt.Errorf("%w: missing entity", ErrInvalidBlobKey)
+  }
+  return entityID.String(), nil
+}
+
+// BlobDigest - Returns the hex encoded SHA-256 digest of 'data', which
+// content-addressed blobs are stored and verified by.
+func BlobDigest(data []byte) string {
+  sum := sha256.Sum256(data)
+  return hex.EncodeToString(sum[:])
+}
+
+// IsBlobDigest - Returns true when 'digest' is a well-formed BlobDigest.
+func IsBlobDigest(digest string) bool {
+  if len(digest) != sha256.Size*2 {
+    return false
+  }
+  for _, c := range digest {
+    if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+      return false
+    }
+  }
+  return true
+}
+
+// ManifestFile defines a single file of a Subject version, by the digest
+// of the blob holding its content.
+type ManifestFile struct {
+  Path   string `json:"path"`
+  Digest string `json:"digest"`
+  Size   int    `json:"size"`
+}
+
+// VersionManifest defines every file of a Subject version, ordered by path.
+type VersionManifest struct {
+  Files []ManifestFile `json:"files"`
+}
+
+// NewVersionManifest - Builds the VersionManifest of 'files', keyed by path.
+func NewVersionManifest(files map[string][]byte) VersionManifest {
+  manifest := VersionManifest{ Files: make([]ManifestFile, 0, len(files)) }
+  for path, data := range files {
+    manifest.Files = append(manifest.Files, ManifestFile{
+      Path   : path,
+      Digest : BlobDigest(data),
+      Size   : len(data),
+    })
+  }
+  sort.Slice(manifest.Files, func(i, j int) bool {
+    return manifest.Files[i].Path < manifest.Files[j].Path
+  })
+  return manifest
+}
+
+// Equal - Returns true when both manifests list the same paths with the same content.
+func(m VersionManifest) Equal(o VersionManifest) bool {
+  if len(m.Files) != len(o.Files) {
+    return false
+  }
+  for i := range m.Files {
+    if m.Files[i].Path != o.Files[i].Path || m.Files[i].Digest != o.Files[i].Digest {
+      return false
+    }
+  }
+  return true
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
+)
+
+func TestBlobKeys(t *testing.T) {
+  entityID := users.EntityID(uuid.MustParse("6f1c3c84-7a4f-4d0e-9a53-0f0d2c1b7e11"))
+  uploadID := uuid.MustParse("0b6a3f6e-2f43-4c55-8d7e-5d9c1f2a3b44")
+  digest   := BlobDigest([]byte("a"))
+
+  key, err := SchemaRef{ Subject: "orders", Version: 3, Path: "./acme//v1/a.proto" }.Key(entityID)
+  assert.NoError(t, err)
+  assert.Equal(t,
+    "entities/6f1c3c84-7a4f-4d0e-9a53-0f0d2c1b7e11/subjects/orders/versions/3/acme/v1/a.proto",
+    key,
+  )
+  key, err = BlobKey(entityID, digest)
+  assert.NoError(t, err)
+  assert.Equal(t, "entities/6f1c3c84-7a4f-4d0e-9a53-0f0d2c1b7e11/blobs/sha256/"+digest, key)
+  key, err = StagedFileKey(entityID, uploadID, "a.proto")
+  assert.NoError(t, err)
+  assert.Equal(t,
+    "staging/6f1c3c84-7a4f-4d0e-9a53-0f0d2c1b7e11/0b6a3f6e-2f43-4c55-8d7e-5d9c1f2a3b44/a.proto",
+    key,
+  )
+
+  for _, ref := range []SchemaRef{
+    { Subject: "orders", Version: 1, Path: "../../../other/subjects/orders/versions/1/a.proto" },
+    { Subject: "orders", Version: 1, Path: "acme/../../a.proto" },
+    { Subject: "orders", Version: 1, Path: "/a.proto" },
+    { Subject: "orders", Version: 1, Path: "acme\\a.proto" },
+    { Subject: "orders", Version: 1, Path: "C:/a.proto" },
+    { Subject: "orders", Version: 1, Path: "." },
+    { Subject: "..",     Version: 1, Path: "a.proto" },
+    { Subject: "a/b",    Version: 1, Path: "a.proto" },
+    { Subject: "orders", Version: 0, Path: "a.proto" },
+  }{
+    _, err := ref.Key(entityID)
+    assert.ErrorIs(t, err, ErrInvalidBlobKey, ref)
+  }
+  _, err = SchemaRef{ Subject: "orders", Version: 1, Path: "a.proto" }.Key(users.NilEntity())
+  assert.ErrorIs(t, err, ErrInvalidBlobKey)
+  _, err = BlobKey(entityID, "../"+digest[3:])
+  assert.ErrorIs(t, err, ErrInvalidBlobKey)
+  _, err = StagedFileKey(entityID, uuid.Nil, "a.proto")
+  assert.ErrorIs(t, err, ErrInvalidBlobKey)
+  _, err = StagedFileKey(entityID, uploadID, "../a.proto")
+  assert.ErrorIs(t, err, ErrInvalidBlobKey)
+
+  for subject, valid := range map[string]bool{
+    "orders"      : true,
+    "orders.v1"   : true,
+    ""            : false,
+    " "           : false,
+    "."           : false,
+    ".."          : false,
+    "a/b"         : false,
+    "a\\b"      : false,
+  }{
+    assert.Equal(t, valid, IsSubjectName(subject), subject)
+  }
+}
+
+func TestStagedUpload(t *testing.T) {
+  now    := time.Now()
+  upload := StagedUpload{ ExpiresAt: now.Add(StagedUploadTTL) }
+  assert.False(t, upload.IsExpired(now))
+  assert.True(t, upload.IsExpired(upload.ExpiresAt))
+}
+
+func TestVersionManifest(t *testing.T) {
+  digest := BlobDigest([]byte("syntax = \"proto3\";"))
+  assert.True(t, IsBlobDigest(digest))
+  assert.False(t, IsBlobDigest(digest[1:]))
+  assert.False(t, IsBlobDigest("../"+digest[3:]))
+  assert.False(t, IsBlobDigest(string(append([]byte{ 'A' }, digest[1:]...))))
+
+  manifest := NewVersionManifest(map[string][]byte{
+    "b.proto" : []byte("b"),
+    "a.proto" : []byte("a"),
+  })
+  assert.Equal(t, []ManifestFile{
+    { Path: "a.proto", Digest: BlobDigest([]byte("a")), Size: 1 },
+    { Path: "b.proto", Digest: BlobDigest([]byte("b")), Size: 1 },
+  }, manifest.Files)
+
+  assert.True(t, manifest.Equal(NewVersionManifest(map[string][]byte{
+    "a.proto" : []byte("a"),
+    "b.proto" : []byte("b"),
+  })))
+  assert.False(t, manifest.Equal(NewVersionManifest(map[string][]byte{
+    "a.proto" : []byte("a"),
+    "b.proto" : []byte("B"),
+  })))
+  assert.False(t, manifest.Equal(NewVersionManifest(map[string][]byte{
+    "a.proto" : []byte("a"),
+    "c.proto" : []byte("b"),
+  })))
+  assert.False(t, manifest.Equal(NewVersionManifest(map[string][]byte{
+    "a.proto" : []byte("a"),
+  })))
+}
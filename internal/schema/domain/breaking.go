@@ -0,0 +1,33 @@
+package domain
+
+// BreakingCategory defines what kind of consumer a BreakingChange affects.
+//
+// Possible Values
+//    - BreakingWire   :: Serialized data or RPC calls stop being understood.
+//    - BreakingSource :: Generated code or JSON mappings stop compiling/matching.
+type BreakingCategory string
+const (
+  BreakingWire   BreakingCategory = "wire"
+  BreakingSource BreakingCategory = "source"
+)
+
+// Severity defines how serious a reported Schema finding is.
+type Severity string
+const (
+  SeverityError   Severity = "error"
+  SeverityWarning Severity = "warning"
+)
+
+// BreakingChange defines a single incompatibility detected between two
+// versions of the same Schema. Path is the fully-qualified element name,
+// File/Line/Column point at the element within the version it was found in.
+type BreakingChange struct {
+  RuleID   string           `json:"rule_id"`
+  Category BreakingCategory `json:"category"`
+  Severity Severity         `json:"severity"`
+  Path     string           `json:"path"`
+  File     string           `json:"file,omitempty"`
+  Line     int              `json:"line,omitempty"`
+  Column   int              `json:"column,omitempty"`
+  Message  string           `json:"message"`
+}
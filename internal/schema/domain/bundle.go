@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+// Bundleable defines a Schema that can resolve the imports of its files, so
+// any subset of them can be distributed along with everything they need.
+type Bundleable interface {
+  // TransitiveImports - Returns every file 'paths' transitively import,
+  // excluding 'paths' themselves, sorted.
+  TransitiveImports(paths []string) []string
+  // SharedSource - Returns the source of a shared import, e.g. a bundled or
+  // platform proto. Returns false for imports every toolchain already
+  // provides, such as the well-known types, and for unknown paths.
+  SharedSource(path string)( []byte, bool )
+}
+
+// BundleFile defines a single file of a SchemaBundle. Version is the Subject
+// version the file was stored under, zero for Shared imports.
+type BundleFile struct {
+  Path    string `json:"path"`
+  SHA256  string `json:"sha256"`
+  Size    int    `json:"size"`
+  Version int    `json:"version,omitempty"`
+  Shared  bool   `json:"shared,omitempty"`
+}
+
+// BundleManifest defines the contents of a SchemaBundle, listing every file
+// with its digest so consumers can verify what they pulled.
+type BundleManifest struct {
+  Subject   string       `json:"subject"`
+  Version   int          `json:"version"`
+  Tag       string       `json:"tag,omitempty"`
+  CreatedAt time.Time    `json:"created_at"`
+  Files     []BundleFile `json:"files"`
+}
+
+// SchemaBundle defines a self-contained set of Schema files, keyed by their
+// import path, along with their manifest.
+type SchemaBundle struct {
+  Manifest BundleManifest
+  Files    map[string][]byte
+}
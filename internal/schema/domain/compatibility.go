@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CompatibilityMode defines which previously registered versions a new Subject
+// version must stay compatible with, and in which direction.
+//
+// Possible Values
+//    - CompatibilityNone               :: No checks are performed.
+//    - CompatibilityBackward           :: New readers can read data written with the latest version.
+//    - CompatibilityBackwardTransitive :: New readers can read data written with any previous version.
+//    - CompatibilityForward            :: Readers of the latest version can read data written by the new version.
+//    - CompatibilityForwardTransitive  :: Readers of any previous version can read data written by the new version.
+//    - CompatibilityFull               :: Both Backward and Forward against the latest version.
+//    - CompatibilityFullTransitive     :: Both Backward and Forward against every previous version.
+type CompatibilityMode string
+const (
+  CompatibilityNone               CompatibilityMode = "NONE"
+  CompatibilityBackward           CompatibilityMode = "BACKWARD"
+  CompatibilityBackwardTransitive CompatibilityMode = "BACKWARD_TRANSITIVE"
+  CompatibilityForward            CompatibilityMode = "FORWARD"
+  CompatibilityForwardTransitive  CompatibilityMode = "FORWARD_TRANSITIVE"
+  CompatibilityFull               CompatibilityMode = "FULL"
+  CompatibilityFullTransitive     CompatibilityMode = "FULL_TRANSITIVE"
+)
+
+// DefaultCompatibility is used when neither an Entity nor a Subject declare a mode.
+const DefaultCompatibility = CompatibilityBackward
+
+var compatibilityFromString = map[string]CompatibilityMode{
+  "NONE"                : CompatibilityNone,
+  "BACKWARD"            : CompatibilityBackward,
+  "BACKWARD_TRANSITIVE" : CompatibilityBackwardTransitive,
+  "FORWARD"             : CompatibilityForward,
+  "FORWARD_TRANSITIVE"  : CompatibilityForwardTransitive,
+  "FULL"                : CompatibilityFull,
+  "FULL_TRANSITIVE"     : CompatibilityFullTransitive,
+}
+
+var (
+  ErrUnknownCompatibilityMode  = errors.New("unknown compatibility mode")
+  ErrIncomparableSchemas       = errors.New("schemas of different formats cannot be compared")
+)
+
+// ParseCompatibilityMode - Converts a case-insensitive string into a CompatibilityMode.
+func ParseCompatibilityMode(mode string)( CompatibilityMode, error ){
+  m, ok := compatibilityFromString[strings.ToUpper(strings.TrimSpace(mode))]
+  if !ok {
+    return "", fmt.Errorf("%w: %q", ErrUnknownCompatibilityMode, mode)
+  }
+  return m, nil
+}
+
+// IsTransitive - Returns true when every previous version must be checked,
+// rather than only the latest one.
+func(m CompatibilityMode) IsTransitive() bool {
+  return m == CompatibilityBackwardTransitive ||
+         m == CompatibilityForwardTransitive  ||
+         m == CompatibilityFullTransitive
+}
+
+func(m CompatibilityMode) checksBackward() bool {
+  return m == CompatibilityBackward           ||
+         m == CompatibilityBackwardTransitive ||
+         m == CompatibilityFull               ||
+         m == CompatibilityFullTransitive
+}
+
+func(m CompatibilityMode) checksForward() bool {
+  return m == CompatibilityForward           ||
+         m == CompatibilityForwardTransitive ||
+         m == CompatibilityFull              ||
+         m == CompatibilityFullTransitive
+}
+
+// ComparableSchema defines a Schema that can be diffed against a previously
+// registered version of itself.
+type ComparableSchema interface {
+  Schema
+  // BreakingChanges returns every change introduced when moving from 'previous' to this Schema.
+  BreakingChanges(previous Schema)( []BreakingChange, error )
+}
+
+// VersionedSchema pairs a compiled Schema with the Subject version it was registered as.
+type VersionedSchema struct {
+  Version int
+  Schema  ComparableSchema
+}
+
+// CompatibilityDirection defines which way a version pair was compared.
+type CompatibilityDirection string
+const (
+  DirectionBackward CompatibilityDirection = "backward"
+  DirectionForward  CompatibilityDirection = "forward"
+)
+
+// CompatibilityViolation defines a breaking change found against a specific
+// previously registered version.
+type CompatibilityViolation struct {
+  Version   int                    `json:"version"`
+  Direction CompatibilityDirection `json:"direction"`
+  BreakingChange
+}
+
+// CompatibilityError is returned when a candidate version violates its
+// Subject's CompatibilityMode. Violations lists every offending change.
+type CompatibilityError struct {
+  Subject    string                   `json:"subject"`
+  Mode       CompatibilityMode        `json:"mode"`
+  Violations []CompatibilityViolation `json:"violations"`
+}
+
+func(e *CompatibilityError) Error() string {
+  return fmt.Sprintf(
+    "schema is not %s compatible with subject %q: %d violation(s)",
+    e.Mode,
+    e.Subject,
+    len(e.Violations),
+  )
+}
+
+// Check - Compares 'candidate' against 'history', ordered from oldest to newest,
+// according to the CompatibilityMode. Only error severity BreakingChanges are
+// considered violations; warnings never block a new version.
+func(m CompatibilityMode) Check(
+  candidate ComparableSchema,
+  history   []VersionedSchema,
+)( []CompatibilityViolation, error ){
+  if m == CompatibilityNone || len(history) == 0 {
+    return nil, nil
+  }
+  if _, ok := compatibilityFromString[string(m)]; !ok {
+    return nil, fmt.Errorf("%w: %q", ErrUnknownCompatibilityMode, m)
+  }
+
+  against := history
+  if !m.IsTransitive() {
+    against = history[len(history)-1:]
+  }
+
+  violations := []CompatibilityViolation{}
+  collect := func(
+    version   int,
+    direction CompatibilityDirection,
+    changes   []BreakingChange,
+  ){
+    for _, c := range changes {
+      if c.Severity != SeverityError {
+        continue
+      }
+      violations = append(violations, CompatibilityViolation{
+        Version        : version,
+        Direction      : direction,
+        BreakingChange : c,
+      })
+    }
+  }
+
+  for _, prev := range against {
+    if m.checksBackward() {
+      changes, err := candidate.BreakingChanges(prev.Schema)
+      if err != nil {
+        return nil, err
+      }
+      collect(prev.Version, DirectionBackward, changes)
+    }
+    if m.checksForward() {
+      changes, err := prev.Schema.BreakingChanges(candidate)
+      if err != nil {
+        return nil, err
+      }
+      collect(prev.Version, DirectionForward, changes)
+    }
+  }
+
+  return violations, nil
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSchema - A ComparableSchema whose breaking changes are predetermined per
+// (previous, next) version pair.
+type fakeSchema struct {
+  version int
+  breaks  map[[2]int][]BreakingChange
+}
+
+func(f *fakeSchema) ParseSchemaFiles(ctx context.Context) error { return nil }
+func(f *fakeSchema) Graph()( *SchemaGraph, error ){ return &SchemaGraph{}, nil }
+func(f *fakeSchema) Cyphers()( []CypherStatement, error ){ return nil, nil }
+func(f *fakeSchema) BreakingChanges(previous Schema)( []BreakingChange, error ){
+  prev := previous.(*fakeSchema)
+  return f.breaks[[2]int{ prev.version, f.version }], nil
+}
+
+func TestCompatibilityModeCheck(t *testing.T) {
+  breaks := map[[2]int][]BreakingChange{
+    // ->> v1 -> candidate removes a field: breaks backward against v1 only.
+    { 1, 3 }: {{ RuleID: "FIELD_NO_DELETE", Severity: SeverityError }},
+    // ->> candidate -> v2 adds a field readers of v2 can't ignore: breaks forward against v2.
+    { 3, 2 }: {{ RuleID: "FIELD_SAME_TYPE", Severity: SeverityError }},
+    // ->> Warnings never block.
+    { 2, 3 }: {{ RuleID: "FIELD_SAME_NAME", Severity: SeverityWarning }},
+  }
+  history := []VersionedSchema{
+    { Version: 1, Schema: &fakeSchema{ 1, breaks } },
+    { Version: 2, Schema: &fakeSchema{ 2, breaks } },
+  }
+  candidate := &fakeSchema{ 3, breaks }
+
+  tests := []struct{
+    mode     CompatibilityMode
+    expected []CompatibilityViolation
+  }{
+    { CompatibilityNone,               nil },
+    { CompatibilityBackward,           []CompatibilityViolation{} },
+    { CompatibilityBackwardTransitive, []CompatibilityViolation{
+      { 1, DirectionBackward, breaks[[2]int{ 1, 3 }][0] },
+    }},
+    { CompatibilityForward,            []CompatibilityViolation{
+      { 2, DirectionForward, breaks[[2]int{ 3, 2 }][0] },
+    }},
+    { CompatibilityForwardTransitive,  []CompatibilityViolation{
+      { 2, DirectionForward, breaks[[2]int{ 3, 2 }][0] },
+    }},
+    { CompatibilityFull,               []CompatibilityViolation{
+      { 2, DirectionForward, breaks[[2]int{ 3, 2 }][0] },
+    }},
+    { CompatibilityFullTransitive,     []CompatibilityViolation{
+      { 1, DirectionBackward, breaks[[2]int{ 1, 3 }][0] },
+      { 2, DirectionForward,  breaks[[2]int{ 3, 2 }][0] },
+    }},
+  }
+
+  for _, tt := range tests {
+    t.Run(string(tt.mode), func(t *testing.T){
+      violations, err := tt.mode.Check(candidate, history)
+      assert.NoError(t, err)
+      assert.Equal(t, tt.expected, violations)
+    })
+  }
+
+  // ->> The first version of a Subject is always accepted.
+  violations, err := CompatibilityFullTransitive.Check(candidate, nil)
+  assert.NoError(t, err)
+  assert.Empty(t, violations)
+}
+
+func TestParseCompatibilityMode(t *testing.T) {
+  mode, err := ParseCompatibilityMode(" full_transitive ")
+  assert.NoError(t, err)
+  assert.Equal(t, CompatibilityFullTransitive, mode)
+
+  _, err = ParseCompatibilityMode("SIDEWAYS")
+  assert.ErrorIs(t, err, ErrUnknownCompatibilityMode)
+}
@@ -0,0 +1,52 @@
+package domain
+
+import "bytes"
+
+const (
+  // DescriptorSetFile -- The path a binary FileDescriptorSet upload is stored
+  // under, as its version's only file.
+  DescriptorSetFile     = "descriptor.binpb"
+  // DescriptorSetJSONFile -- The path a protojson FileDescriptorSet upload is
+  // stored under, as its version's only file.
+  DescriptorSetJSONFile = "descriptor.json"
+)
+
+// DescriptorSetPath - Returns the path an uploaded FileDescriptorSet is stored
+// under, DescriptorSetJSONFile when 'data' is protojson encoded.
+func DescriptorSetPath(data []byte) string {
+  if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+    return DescriptorSetJSONFile
+  }
+  return DescriptorSetFile
+}
+
+// IsDescriptorSetUpload - Returns true when 'files' holds a single uploaded
+// FileDescriptorSet rather than Schema sources.
+func IsDescriptorSetUpload(files map[string][]byte) bool {
+  if len(files) != 1 {
+    return false
+  }
+  for path := range files {
+    return path == DescriptorSetFile || path == DescriptorSetJSONFile
+  }
+  return false
+}
+
+// DescriptorOptions defines what an exported FileDescriptorSet contains.
+//    - SourceInfo :: Keep each file's SourceCodeInfo, i.e. comments and spans.
+//    - Imports    :: Include every file the Schema's files transitively
+//                    import, well-known types included, like protoc's
+//                    --include_imports.
+type DescriptorOptions struct {
+  SourceInfo bool
+  Imports    bool
+}
+
+// DescriptorExportable defines a Schema that can export its compiled files as
+// a serialized google.protobuf.FileDescriptorSet, e.g. for dynamic message
+// decoding or gRPC-JSON transcoding.
+type DescriptorExportable interface {
+  // FileDescriptorSet - Returns the binary encoded FileDescriptorSet of the
+  // Schema's files, every file listed after the files it imports.
+  FileDescriptorSet(opts DescriptorOptions)( []byte, error )
+}
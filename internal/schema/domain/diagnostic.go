@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Diagnostic defines a single error or warning reported while compiling or
+// linting a Schema, positioned within the file it was found in. Rule and Path
+// are set for lint findings, naming the rule and the offending element.
+type Diagnostic struct {
+  File     string   `json:"file,omitempty"`
+  Line     int      `json:"line,omitempty"`
+  Column   int      `json:"column,omitempty"`
+  Severity Severity `json:"severity"`
+  Rule     string   `json:"rule,omitempty"`
+  Path     string   `json:"path,omitempty"`
+  Message  string   `json:"message"`
+}
+
+func(d Diagnostic) String() string {
+  switch {
+  case d.File == "":
+    return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+  case d.Line == 0:
+    return fmt.Sprintf("%s: %s: %s", d.File, d.Severity, d.Message)
+  }
+  return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// Diagnosable defines a compiled Schema that kept the warnings reported
+// while it was compiled.
+type Diagnosable interface {
+  Diagnostics() []Diagnostic
+}
+
+// DiagnosticError defines a compile error that can describe itself as a
+// list of Diagnostics, so callers can report every problem at once.
+type DiagnosticError interface {
+  error
+  Diagnostics() []Diagnostic
+}
+
+// DiagnosticsError is returned when a Schema fails to compile. Diagnostics
+// holds every error, and any warnings, reported during compilation.
+type DiagnosticsError struct {
+  Items []Diagnostic `json:"diagnostics"`
+}
+
+func(e *DiagnosticsError) Error() string {
+  errs := 0
+  var first *Diagnostic
+  for i, d := range e.Items {
+    if d.Severity != SeverityError {
+      continue
+    }
+    if first == nil {
+      first = &e.Items[i]
+    }
+    errs++
+  }
+  if first == nil {
+    return "schema failed to compile"
+  }
+  if errs == 1 {
+    return first.String()
+  }
+  return fmt.Sprintf("%s (and %d more error(s))", first.String(), errs-1)
+}
+
+func(e *DiagnosticsError) Diagnostics() []Diagnostic {
+  return e.Items
+}
+
+// SortDiagnostics - Orders Diagnostics by file, line and column, keeping the
+// reported order of Diagnostics at the same position.
+func SortDiagnostics(diagnostics []Diagnostic) {
+  sort.SliceStable(diagnostics, func(i, j int) bool {
+    a, b := diagnostics[i], diagnostics[j]
+    if a.File != b.File {
+      return a.File < b.File
+    }
+    if a.Line != b.Line {
+      return a.Line < b.Line
+    }
+    return a.Column < b.Column
+  })
+}
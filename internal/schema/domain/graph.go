@@ -0,0 +1,246 @@
+package domain
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
+)
+
+// NodeLabel defines the label of a Schema Graph node.
+type NodeLabel string
+const (
+  LabelPackage   NodeLabel = "Package"
+  LabelMessage   NodeLabel = "Message"
+  LabelParameter NodeLabel = "Parameter"
+  LabelEnum      NodeLabel = "Enum"
+  LabelEnumValue NodeLabel = "EnumValue"
+  LabelService   NodeLabel = "Service"
+  LabelMethod    NodeLabel = "Method"
+  LabelOneof     NodeLabel = "Oneof"
+  LabelExtension NodeLabel = "Extension"
+
+  LabelAPI             NodeLabel = "API"
+  LabelAPIPath         NodeLabel = "APIPath"
+  LabelOperation       NodeLabel = "Operation"
+  LabelAPIParameter    NodeLabel = "APIParameter"
+  LabelRequestBody     NodeLabel = "RequestBody"
+  LabelResponse        NodeLabel = "Response"
+  LabelComponentSchema NodeLabel = "ComponentSchema"
+
+  LabelGraphQLType      NodeLabel = "GraphQLType"
+  LabelGraphQLField     NodeLabel = "GraphQLField"
+  LabelGraphQLOperation NodeLabel = "GraphQLOperation"
+  LabelGraphQLArgument  NodeLabel = "GraphQLArgument"
+  LabelGraphQLEnumValue NodeLabel = "GraphQLEnumValue"
+  LabelGraphQLDirective NodeLabel = "GraphQLDirective"
+)
+
+// EdgeType defines the type of a Schema Graph relationship.
+type EdgeType string
+const (
+  EdgeDefinedIn    EdgeType = "DEFINED_IN"
+  EdgeImports      EdgeType = "IMPORTS"
+  EdgeHasParameter EdgeType = "HAS_PARAMETER"
+  EdgeUsesMsgType  EdgeType = "USES_MSG_TYPE"
+  EdgeUsesEnumType EdgeType = "USES_ENUM_TYPE"
+  EdgeFromPackage  EdgeType = "FROM_PACKAGE"
+  EdgeHasValue     EdgeType = "HAS_VALUE"
+  EdgeAlias        EdgeType = "ALIAS"
+  EdgeInput        EdgeType = "INPUT"
+  EdgeOutput       EdgeType = "OUTPUT"
+  EdgeRPCMethod    EdgeType = "RPC_METHOD"
+  EdgeNestedIn     EdgeType = "NESTED_IN"
+  EdgeHasOneof     EdgeType = "HAS_ONEOF"
+  EdgeOneofField   EdgeType = "ONEOF_FIELD"
+  EdgeExtends      EdgeType = "EXTENDS"
+
+  EdgeHasPath        EdgeType = "HAS_PATH"
+  EdgeHasOperation   EdgeType = "HAS_OPERATION"
+  EdgeHasRequestBody EdgeType = "HAS_REQUEST_BODY"
+  EdgeHasResponse    EdgeType = "HAS_RESPONSE"
+  EdgeUsesSchema     EdgeType = "USES_SCHEMA"
+
+  EdgeImplements     EdgeType = "IMPLEMENTS"
+  EdgeUnionMember    EdgeType = "UNION_MEMBER"
+  EdgeHasField       EdgeType = "HAS_FIELD"
+  EdgeReturns        EdgeType = "RETURNS"
+  EdgeHasArgument    EdgeType = "HAS_ARGUMENT"
+  EdgeArgType        EdgeType = "ARG_TYPE"
+  EdgeRootField      EdgeType = "ROOT_FIELD"
+  EdgeUsesDirective  EdgeType = "USES_DIRECTIVE"
+)
+
+// GraphNode defines a single Schema Graph node. Key is a stable identifier,
+// unique per Label, that nodes are merged on. Owner identifies the write this
+// node is part of, e.g. a Subject's Package; every write replaces whatever
+// its Owners held before. Nodes without an Owner, e.g. shared imports, are
+// only ever merged.
+type GraphNode struct {
+  Label NodeLabel
+  Key   string
+  Owner string
+  Props map[string]any
+}
+
+// GraphEdge defines a single Schema Graph relationship between two nodes,
+// each referenced by Label and Key.
+type GraphEdge struct {
+  Type      EdgeType
+  FromLabel NodeLabel
+  FromKey   string
+  ToLabel   NodeLabel
+  ToKey     string
+  Props     map[string]any
+}
+
+// SchemaGraph defines the nodes and relationships a compiled Schema is made of.
+type SchemaGraph struct {
+  Nodes []GraphNode
+  Edges []GraphEdge
+}
+
+// AddNode - Appends a new node to the graph.
+func(g *SchemaGraph) AddNode(
+  label NodeLabel,
+  key   string,
+  props map[string]any,
+) {
+  g.Nodes = append(g.Nodes, GraphNode{
+    Label : label,
+    Key   : key,
+    Props : props,
+  })
+}
+
+// AddEdge - Appends a new relationship to the graph.
+func(g *SchemaGraph) AddEdge(
+  edge     EdgeType,
+  fromLbl  NodeLabel,
+  fromKey  string,
+  toLbl    NodeLabel,
+  toKey    string,
+) {
+  g.Edges = append(g.Edges, GraphEdge{
+    Type      : edge,
+    FromLabel : fromLbl,
+    FromKey   : fromKey,
+    ToLabel   : toLbl,
+    ToKey     : toKey,
+  })
+}
+
+// AddEdgeWithProps - Appends a new relationship, carrying 'props', to the graph.
+func(g *SchemaGraph) AddEdgeWithProps(
+  edge     EdgeType,
+  fromLbl  NodeLabel,
+  fromKey  string,
+  toLbl    NodeLabel,
+  toKey    string,
+  props    map[string]any,
+) {
+  g.Edges = append(g.Edges, GraphEdge{
+    Type      : edge,
+    FromLabel : fromLbl,
+    FromKey   : fromKey,
+    ToLabel   : toLbl,
+    ToKey     : toKey,
+    Props     : props,
+  })
+}
+
+// Merge - Appends every node and relationship of 'other' to the graph.
+func(g *SchemaGraph) Merge(other *SchemaGraph) {
+  g.Nodes = append(g.Nodes, other.Nodes...)
+  g.Edges = append(g.Edges, other.Edges...)
+}
+
+// Owners - Returns every Owner of the graph's nodes, in the order they first
+// appear, along with the nodes each one holds.
+func(g *SchemaGraph) Owners()( []string, map[string][]NodeRef ){
+  owners := []string{}
+  held   := map[string][]NodeRef{}
+  for _, node := range g.Nodes {
+    if node.Owner == "" {
+      continue
+    }
+    if _, ok := held[node.Owner]; !ok {
+      owners = append(owners, node.Owner)
+    }
+    held[node.Owner] = append(held[node.Owner], NodeRef{ Label: node.Label, Key: node.Key })
+  }
+  return owners, held
+}
+
+// GraphNamespace - Returns the prefix the graph keys of everything an Entity
+// defines itself are stored under, e.g. "<entity id>/acme.orders.v1.Order".
+// Shared definitions, e.g. google.protobuf, keep their plain key. Empty for
+// the nil Entity.
+func GraphNamespace(entityID users.EntityID) string {
+  if entityID == users.NilEntity() {
+    return ""
+  }
+  return entityID.String()
+}
+
+// NamespacedKey - Returns the graph key 'name' is stored under when the
+// Entity defines it itself, see GraphNamespace.
+func NamespacedKey(entityID users.EntityID, name string) string {
+  if ns := GraphNamespace(entityID); ns != "" {
+    return ns + "/" + name
+  }
+  return name
+}
+
+// KeyNamespace - Returns the GraphNamespace 'key' is stored under, empty for
+// shared definitions.
+func KeyNamespace(key string) string {
+  i := strings.Index(key, "/")
+  if i == -1 {
+    return ""
+  }
+  if _, err := uuid.Parse(key[:i]); err != nil {
+    return ""
+  }
+  return key[:i]
+}
+
+// CypherStatement defines a single Cypher query and the parameters it is run with.
+type CypherStatement struct {
+  Query  string
+  Params map[string]any
+}
+
+// PackageNode defines a stored Package node.
+type PackageNode struct {
+  Name      string    `json:"name"`
+  Package   string    `json:"package"`
+  Version   string    `json:"version"`
+  Stability Stability `json:"stability"`
+  Syntax    string    `json:"syntax"`
+  Imports   []string  `json:"imports"`
+}
+
+// MessageNode defines a stored Message node.
+type MessageNode struct {
+  Name       string `json:"name"`
+  Package    string `json:"package"`
+  Version    string `json:"version"`
+  Deprecated bool   `json:"deprecated"`
+}
+
+// MethodNode defines a stored RPC Method node, along with the names of its
+// input and output Messages. Options lists custom method options as
+// "fully.qualified.name=value".
+type MethodNode struct {
+  Name            string   `json:"name"`
+  Service         string   `json:"service"`
+  Input           string   `json:"input"`
+  Output          string   `json:"output"`
+  ClientStreaming bool     `json:"client_streaming"`
+  ServerStreaming bool     `json:"server_streaming"`
+  Idempotency     string   `json:"idempotency,omitempty"`
+  Deprecated      bool     `json:"deprecated"`
+  Options         []string `json:"options,omitempty"`
+}
@@ -0,0 +1,240 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+  ErrUnknownImpactKind    = errors.New("unknown impact target kind")
+  ErrImpactTargetNotFound = errors.New("impact target doesn't exist in the schema graph")
+)
+
+// impactKinds -- The Schema elements an impact analysis can start from, by
+// the name clients refer to them with.
+var impactKinds = map[string]NodeLabel{
+  "MESSAGE" : LabelMessage,
+  "ENUM"    : LabelEnum,
+  "FIELD"   : LabelParameter,
+  "METHOD"  : LabelMethod,
+}
+
+// ParseImpactKind - Converts a case-insensitive element kind, e.g. "message"
+// or "field", into the NodeLabel its nodes are stored under.
+func ParseImpactKind(kind string)( NodeLabel, error ){
+  label, ok := impactKinds[strings.ToUpper(strings.TrimSpace(kind))]
+  if !ok {
+    return "", fmt.Errorf("%w: %q", ErrUnknownImpactKind, kind)
+  }
+  return label, nil
+}
+
+// NodeRef defines a reference to a single Schema Graph node.
+type NodeRef struct {
+  Label NodeLabel `json:"label"`
+  Key   string    `json:"key"`
+}
+
+// Adjacent defines a relationship found next to a node while traversing the
+// Schema Graph. Outgoing is true when From is the relationship's start node.
+type Adjacent struct {
+  From     NodeRef
+  Edge     EdgeType
+  Outgoing bool
+  To       NodeRef
+}
+
+// impactFlow -- The relationships a change propagates along. True when the
+// relationship's end node depends on its start node, e.g. a Method depends on
+// its INPUT Message; false when the start node depends on the end node, e.g.
+// a Parameter depends on the Message it USES_MSG_TYPE.
+var impactFlow = map[EdgeType]bool{
+  EdgeUsesMsgType  : false,
+  EdgeUsesEnumType : false,
+  EdgeHasParameter : false,
+  EdgeInput        : true,
+  EdgeOutput       : true,
+  EdgeRPCMethod    : true,
+  EdgeDefinedIn    : true,
+  EdgeImports      : false,
+}
+
+// ImpactEdges - Returns every EdgeType an impact analysis traverses.
+func ImpactEdges() []EdgeType {
+  edges := make([]EdgeType, 0, len(impactFlow))
+  for edge := range impactFlow {
+    edges = append(edges, edge)
+  }
+  sort.Slice(edges, func(i, j int) bool { return edges[i] < edges[j] })
+  return edges
+}
+
+// dependent - Returns the node of 'adj' that depends on adj.From, if any.
+func(adj Adjacent) dependent()( NodeRef, bool ){
+  forward, ok := impactFlow[adj.Edge]
+  if !ok || forward != adj.Outgoing {
+    return NodeRef{}, false
+  }
+  return adj.To, true
+}
+
+// ImpactStep defines a single hop of an ImpactPath, reaching Node over Edge.
+type ImpactStep struct {
+  Edge EdgeType `json:"edge"`
+  Node NodeRef  `json:"node"`
+}
+
+// ImpactedNode defines a node that transitively depends on an impact
+// analysis' target. Path lists every hop taken from the target, Depth is its length.
+type ImpactedNode struct {
+  NodeRef
+  Depth int          `json:"depth"`
+  Path  []ImpactStep `json:"path"`
+}
+
+// ImpactReport defines every dependent of Target, split into the Packages
+// and Services affected and every other Element, e.g. Messages, Parameters
+// and Methods. Each list is ordered by depth, then by key.
+type ImpactReport struct {
+  Target   NodeRef        `json:"target"`
+  Elements []ImpactedNode `json:"elements"`
+  Services []ImpactedNode `json:"services"`
+  Packages []ImpactedNode `json:"packages"`
+}
+
+// ImpactExpander defines how an impact analysis reads the Schema Graph. It
+// returns every relationship, in either direction, touching a frontier node.
+type ImpactExpander func(ctx context.Context, frontier []NodeRef)( []Adjacent, error )
+
+// AnalyzeImpact - Walks the Schema Graph breadth first from 'target', through
+// 'expand', following every relationship in ImpactEdges in the direction a
+// change propagates. Each dependent is reported once, with the shortest path
+// reaching it. A 'maxDepth' below 1 walks the whole graph.
+//
+// Only shared nodes and nodes stored under 'namespace', see GraphNamespace,
+// are reported or walked through, so one Entity's dependents never show up
+// in another's report.
+func AnalyzeImpact(
+  ctx       context.Context,
+  namespace string,
+  target    NodeRef,
+  maxDepth  int,
+  expand    ImpactExpander,
+)( *ImpactReport, error ){
+  report := &ImpactReport{
+    Target   : target,
+    Elements : []ImpactedNode{},
+    Services : []ImpactedNode{},
+    Packages : []ImpactedNode{},
+  }
+
+  paths    := map[NodeRef][]ImpactStep{ target: {} }
+  frontier := []NodeRef{ target }
+  for depth := 1; len(frontier) != 0 && (maxDepth < 1 || depth <= maxDepth); depth++ {
+    adjacent, err := expand(ctx, frontier)
+    if err != nil {
+      return nil, err
+    }
+    // ->> Keep each level stable, whatever order the graph returns it in.
+    sort.SliceStable(adjacent, func(i, j int) bool {
+      a, b := adjacent[i], adjacent[j]
+      if a.From != b.From {
+        return a.From.Key < b.From.Key || a.From.Key == b.From.Key && a.From.Label < b.From.Label
+      }
+      if a.Edge != b.Edge {
+        return a.Edge < b.Edge
+      }
+      return a.To.Key < b.To.Key || a.To.Key == b.To.Key && a.To.Label < b.To.Label
+    })
+
+    next := []NodeRef{}
+    for _, adj := range adjacent {
+      from, ok := paths[adj.From]
+      if !ok || len(from) != depth-1 {
+        continue
+      }
+      node, ok := adj.dependent()
+      if !ok {
+        continue
+      }
+      if ns := KeyNamespace(node.Key); ns != "" && ns != namespace {
+        continue
+      }
+      if _, seen := paths[node]; seen {
+        continue
+      }
+      path := append(append([]ImpactStep{}, from...), ImpactStep{ Edge: adj.Edge, Node: node })
+      paths[node] = path
+      next = append(next, node)
+
+      impacted := ImpactedNode{ NodeRef: node, Depth: depth, Path: path }
+      switch node.Label {
+      case LabelPackage:
+        report.Packages = append(report.Packages, impacted)
+      case LabelService:
+        report.Services = append(report.Services, impacted)
+      default:
+        report.Elements = append(report.Elements, impacted)
+      }
+    }
+    frontier = next
+  }
+
+  for _, nodes := range [][]ImpactedNode{ report.Elements, report.Services, report.Packages } {
+    sort.SliceStable(nodes, func(i, j int) bool {
+      if nodes[i].Depth != nodes[j].Depth {
+        return nodes[i].Depth < nodes[j].Depth
+      }
+      return nodes[i].Key < nodes[j].Key
+    })
+  }
+  return report, nil
+}
+
+// Impact - Runs AnalyzeImpact over the in-memory graph.
+//
+// Potential Errors:
+//    - ErrImpactTargetNotFound
+func(g *SchemaGraph) Impact(
+  ctx       context.Context,
+  namespace string,
+  target    NodeRef,
+  maxDepth  int,
+)( *ImpactReport, error ){
+  found := false
+  for _, node := range g.Nodes {
+    if node.Label == target.Label && node.Key == target.Key {
+      found = true
+      break
+    }
+  }
+  if !found {
+    return nil, fmt.Errorf("%w: %s %q", ErrImpactTargetNotFound, target.Label, target.Key)
+  }
+
+  // ->> Index every relationship by both of its nodes, once.
+  adjacent := map[NodeRef][]Adjacent{}
+  for _, edge := range g.Edges {
+    if _, ok := impactFlow[edge.Type]; !ok {
+      continue
+    }
+    from := NodeRef{ Label: edge.FromLabel, Key: edge.FromKey }
+    to   := NodeRef{ Label: edge.ToLabel,   Key: edge.ToKey   }
+    adjacent[from] = append(adjacent[from], Adjacent{ From: from, Edge: edge.Type, Outgoing: true,  To: to   })
+    adjacent[to]   = append(adjacent[to],   Adjacent{ From: to,   Edge: edge.Type, Outgoing: false, To: from })
+  }
+
+  return AnalyzeImpact(ctx, namespace, target, maxDepth, func(
+    _        context.Context,
+    frontier []NodeRef,
+  )( []Adjacent, error ){
+    found := []Adjacent{}
+    for _, node := range frontier {
+      found = append(found, adjacent[node]...)
+    }
+    return found, nil
+  })
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// LintRuleSet defines which group of lint rules an Entity's Schemas are checked against.
+//
+// Possible Values
+//    - LintMinimal :: Only file layout rules, e.g. packages matching their directory.
+//    - LintBasic   :: Minimal, plus naming conventions.
+//    - LintDefault :: Basic, plus enum value prefixes, zero values and RPC request/response rules.
+//    - LintCustom  :: Only the rules listed in LintConfig.Rules.
+type LintRuleSet string
+const (
+  LintMinimal LintRuleSet = "MINIMAL"
+  LintBasic   LintRuleSet = "BASIC"
+  LintDefault LintRuleSet = "DEFAULT"
+  LintCustom  LintRuleSet = "CUSTOM"
+)
+
+var lintRuleSetFromString = map[string]LintRuleSet{
+  "MINIMAL" : LintMinimal,
+  "BASIC"   : LintBasic,
+  "DEFAULT" : LintDefault,
+  "CUSTOM"  : LintCustom,
+}
+
+var (
+  ErrUnknownLintRuleSet = errors.New("unknown lint rule set")
+)
+
+// ParseLintRuleSet - Converts a case-insensitive string into a LintRuleSet.
+func ParseLintRuleSet(set string)( LintRuleSet, error ){
+  s, ok := lintRuleSetFromString[strings.ToUpper(strings.TrimSpace(set))]
+  if !ok {
+    return "", fmt.Errorf("%w: %q", ErrUnknownLintRuleSet, set)
+  }
+  return s, nil
+}
+
+// LintConfig defines which lint rules run for an Entity. Except removes rules
+// from the selected set, Ignore skips every file under the listed path prefixes.
+type LintConfig struct {
+  RuleSet LintRuleSet `json:"rule_set"`
+  Rules   []string    `json:"rules,omitempty"`
+  Except  []string    `json:"except,omitempty"`
+  Ignore  []string    `json:"ignore,omitempty"`
+}
+
+// DefaultLintConfig is used when an Entity never configured linting.
+var DefaultLintConfig = LintConfig{ RuleSet: LintDefault }
+
+// Validate - Checks the RuleSet is known, and that only LintCustom lists Rules.
+//
+// Potential Errors:
+//    - ErrUnknownLintRuleSet
+func(c LintConfig) Validate() error {
+  if _, ok := lintRuleSetFromString[string(c.RuleSet)]; !ok {
+    return fmt.Errorf("%w: %q", ErrUnknownLintRuleSet, c.RuleSet)
+  }
+  if c.RuleSet != LintCustom && len(c.Rules) != 0 {
+    return fmt.Errorf("%w: rules can only be listed with %s", ErrUnknownLintRuleSet, LintCustom)
+  }
+  return nil
+}
+
+// Ignored - Returns true when 'path' is under one of the Ignore prefixes.
+func(c LintConfig) Ignored(path string) bool {
+  for _, prefix := range c.Ignore {
+    prefix = strings.TrimSuffix(prefix, "/")
+    if path == prefix || strings.HasPrefix(path, prefix+"/") {
+      return true
+    }
+  }
+  return false
+}
+
+// Lintable defines a compiled Schema that can be checked against a LintConfig.
+// Findings are returned as Diagnostics, carrying the Rule that reported them.
+type Lintable interface {
+  Lint(config LintConfig) []Diagnostic
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintConfig(t *testing.T) {
+  set, err := ParseLintRuleSet(" basic ")
+  assert.NoError(t, err)
+  assert.Equal(t, LintBasic, set)
+
+  _, err = ParseLintRuleSet("STRICT")
+  assert.ErrorIs(t, err, ErrUnknownLintRuleSet)
+
+  assert.NoError(t, DefaultLintConfig.Validate())
+  assert.NoError(t, LintConfig{ RuleSet: LintCustom, Rules: []string{ "COMMENT_RPC" } }.Validate())
+  assert.ErrorIs(t, LintConfig{ RuleSet: LintBasic, Rules: []string{ "COMMENT_RPC" } }.Validate(), ErrUnknownLintRuleSet)
+  assert.ErrorIs(t, LintConfig{}.Validate(), ErrUnknownLintRuleSet)
+
+  config := LintConfig{ Ignore: []string{ "vendor/", "legacy.proto" } }
+  assert.True(t, config.Ignored("vendor/google/type.proto"))
+  assert.True(t, config.Ignored("legacy.proto"))
+  assert.False(t, config.Ignored("vendored/a.proto"))
+  assert.False(t, config.Ignored("acme/legacy.proto"))
+}
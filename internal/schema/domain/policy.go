@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// PolicyTarget defines which kind of Schema element a Policy's expression is
+// evaluated against. The expression sees the element under the target's
+// lowercase name, e.g. `message`, along with the `file` declaring it.
+//
+// Possible Values
+//    - PolicyFile    :: Every tenant file.
+//    - PolicyMessage :: Every message, nested messages included.
+//    - PolicyField   :: Every message field.
+//    - PolicyEnum    :: Every enum, nested enums included.
+//    - PolicyService :: Every service.
+//    - PolicyMethod  :: Every RPC method.
+type PolicyTarget string
+const (
+  PolicyFile    PolicyTarget = "FILE"
+  PolicyMessage PolicyTarget = "MESSAGE"
+  PolicyField   PolicyTarget = "FIELD"
+  PolicyEnum    PolicyTarget = "ENUM"
+  PolicyService PolicyTarget = "SERVICE"
+  PolicyMethod  PolicyTarget = "METHOD"
+)
+
+var policyTargetFromString = map[string]PolicyTarget{
+  "FILE"    : PolicyFile,
+  "MESSAGE" : PolicyMessage,
+  "FIELD"   : PolicyField,
+  "ENUM"    : PolicyEnum,
+  "SERVICE" : PolicyService,
+  "METHOD"  : PolicyMethod,
+}
+
+var (
+  ErrUnknownPolicyTarget = errors.New("unknown policy target")
+  ErrInvalidPolicy       = errors.New("invalid policy")
+)
+
+var policyName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ParsePolicyTarget - Converts a case-insensitive string into a PolicyTarget.
+func ParsePolicyTarget(target string)( PolicyTarget, error ){
+  t, ok := policyTargetFromString[strings.ToUpper(strings.TrimSpace(target))]
+  if !ok {
+    return "", fmt.Errorf("%w: %q", ErrUnknownPolicyTarget, target)
+  }
+  return t, nil
+}
+
+// Policy defines an Entity's own governance rule, written as a CEL expression
+// that must evaluate to true for every Target element it applies to. Packages
+// limits the Policy to matching packages, e.g. "billing.*" matches billing and
+// all of its sub-packages; an empty list applies it everywhere.
+//
+// e.g.
+//    Target     : PolicyMessage
+//    Packages   : ["billing.*"]
+//    Expression : message.fields.exists(f, f.name == "tenant_id")
+type Policy struct {
+  Name        string       `json:"name"`
+  Description string       `json:"description,omitempty"`
+  Target      PolicyTarget `json:"target"`
+  Packages    []string     `json:"packages,omitempty"`
+  Expression  string       `json:"expression"`
+  Severity    Severity     `json:"severity"`
+}
+
+// Validate - Checks the Policy is named, targets a known element and has an
+// expression. An empty Severity defaults to SeverityError. The expression
+// itself is only checked once it's compiled by a PolicyEvaluable Schema.
+//
+// Potential Errors:
+//    - ErrInvalidPolicy
+//    - ErrUnknownPolicyTarget
+func(p *Policy) Validate() error {
+  if !policyName.MatchString(p.Name) {
+    return fmt.Errorf("%w: name %q must be alphanumeric, '_', '.' or '-'", ErrInvalidPolicy, p.Name)
+  }
+  if _, ok := policyTargetFromString[string(p.Target)]; !ok {
+    return fmt.Errorf("%w: %q", ErrUnknownPolicyTarget, p.Target)
+  }
+  if strings.TrimSpace(p.Expression) == "" {
+    return fmt.Errorf("%w: %q has no expression", ErrInvalidPolicy, p.Name)
+  }
+  switch p.Severity {
+  case "":
+    p.Severity = SeverityError
+  case SeverityError, SeverityWarning:
+  default:
+    return fmt.Errorf("%w: unknown severity %q", ErrInvalidPolicy, p.Severity)
+  }
+  return nil
+}
+
+// Applies - Returns true when the Policy covers package 'pkg'. A pattern
+// ending in ".*" matches the package and every sub-package, any other
+// pattern is matched segment by segment, '*' matching a single segment.
+func(p Policy) Applies(pkg string) bool {
+  if len(p.Packages) == 0 {
+    return true
+  }
+  for _, pattern := range p.Packages {
+    if pattern == "*" {
+      return true
+    }
+    if prefix, ok := strings.CutSuffix(pattern, ".*"); ok {
+      if pkg == prefix || strings.HasPrefix(pkg, prefix+".") {
+        return true
+      }
+      continue
+    }
+    matched, err := path.Match(
+      strings.ReplaceAll(pattern, ".", "/"),
+      strings.ReplaceAll(pkg, ".", "/"),
+    )
+    if err == nil && matched {
+      return true
+    }
+  }
+  return false
+}
+
+// PolicyEvaluable defines a compiled Schema that can be checked against an
+// Entity's Policies. Violations are returned as Diagnostics, carrying the
+// name of the Policy that reported them as their Rule.
+type PolicyEvaluable interface {
+  EvaluatePolicies(policies []Policy) []Diagnostic
+}
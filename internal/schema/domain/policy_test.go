@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(t *testing.T) {
+  target, err := ParsePolicyTarget(" method ")
+  assert.NoError(t, err)
+  assert.Equal(t, PolicyMethod, target)
+
+  _, err = ParsePolicyTarget("ONEOF")
+  assert.ErrorIs(t, err, ErrUnknownPolicyTarget)
+
+  policy := Policy{ Name: "tenant-id", Target: PolicyMessage, Expression: "true" }
+  assert.NoError(t, policy.Validate())
+  assert.Equal(t, SeverityError, policy.Severity)
+
+  assert.ErrorIs(t, (&Policy{ Name: "bad name", Target: PolicyMessage, Expression: "true" }).Validate(), ErrInvalidPolicy)
+  assert.ErrorIs(t, (&Policy{ Name: "p", Target: "ONEOF", Expression: "true" }).Validate(), ErrUnknownPolicyTarget)
+  assert.ErrorIs(t, (&Policy{ Name: "p", Target: PolicyField, Expression: " " }).Validate(), ErrInvalidPolicy)
+  assert.ErrorIs(t, (&Policy{ Name: "p", Target: PolicyField, Expression: "true", Severity: "fatal" }).Validate(), ErrInvalidPolicy)
+
+  assert.True(t, Policy{}.Applies("anything.v1"))
+
+  scoped := Policy{ Packages: []string{ "billing.*", "*.internal" } }
+  assert.True(t, scoped.Applies("billing"))
+  assert.True(t, scoped.Applies("billing.v1"))
+  assert.True(t, scoped.Applies("billing.invoices.v1"))
+  assert.True(t, scoped.Applies("orders.internal"))
+  assert.False(t, scoped.Applies("billingv2.v1"))
+  assert.False(t, scoped.Applies("orders.v1.internal"))
+}
@@ -1,12 +1,38 @@
 package domain
 
-import "context"
+import (
+	"context"
+
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
+)
 
 // Schema defined a Microservice Messaging Protocol interface.
 // i.e., gRPC, GraphQL, OpenAPI, etc.
 type Schema interface {
   // ParseSchemaFile(ctx context.Context, filename string, runner func(queries []string)) error
   ParseSchemaFiles(ctx context.Context) error
-  // Cyphers Provides an array of compiled cypher queries.
-  Cyphers()( []string, error )
+  // Graph Provides the parsed Schema as a set of graph nodes and relationships.
+  Graph()( *SchemaGraph, error )
+  // Cyphers Provides an array of compiled cypher statements, ordered so that
+  // every statement only depends on the ones before it.
+  Cyphers()( []CypherStatement, error )
+}
+
+// LoadContext defines who a set of Schema files is compiled for. The graph
+// keys of everything EntityID defines itself are namespaced with its
+// GraphNamespace, keeping Entities that share package names apart. Subject
+// scopes the Owner of their nodes, see GraphNode. Version resolves their
+// Packages' versions under the Entity's VersioningScheme.
+type LoadContext struct {
+  EntityID users.EntityID
+  Subject  string
+  Version  VersionContext
 }
+
+// SchemaLoader defines a function that compiles a set of in-memory Schema
+// files, keyed by their relative path, into a ComparableSchema.
+type SchemaLoader func(
+  ctx   context.Context,
+  lc    LoadContext,
+  files map[string][]byte,
+)( ComparableSchema, error )
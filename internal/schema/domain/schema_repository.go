@@ -4,26 +4,70 @@ import (
 	"context"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/TylerAldrich814/Fidicus/internal/shared/role"
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
 )
 
 // SchemaRepository defines our Schema's Storage Logic.
 type SchemaBlobRepository interface {
-  // UploadSchema -- Uploads a new Schema into Blob Database..
-  UploadSchema(ctx context.Context, name, path string) error
-  // DownloadSchema - Attempts to download a stored schema from DB..
-  DownloadSchema(ctx context.Context, name string )([]byte, error)
-  // DeleteSchema - Attempts to delete a Schema from blob DB.
-  DeleteSchema(ctx context.Context, key string) error
-  // ListSchemas -- Using a prefix, search and return an array of Object keys.
-  ListSchemas(ctx context.Context, prefix string)([]string, error)
-  // GeneratePresignedURL -- Generates pre-signed URLs for read/write access.
-  GeneratePresignedURL(ctx context.Context, key string, expiry time.Duration)( string, error)
+  // UploadSchema -- Uploads a local file into an Entity's Subject version.
+  // Stored files are never overwritten.
+  UploadSchema(ctx context.Context, entityID users.EntityID, ref SchemaRef, path string) error
+  // PutSchema -- Uploads an in-memory file into an Entity's Subject version.
+  // Stored files are never overwritten.
+  PutSchema(ctx context.Context, entityID users.EntityID, ref SchemaRef, data []byte) error
+  // DownloadSchema - Attempts to download a file of an Entity's Subject version.
+  DownloadSchema(ctx context.Context, entityID users.EntityID, ref SchemaRef)( []byte, error )
+  // DeleteSchema - Attempts to delete a file of an Entity's Subject version.
+  DeleteSchema(ctx context.Context, entityID users.EntityID, ref SchemaRef) error
+  // ListSchemas -- Returns the path of every file stored under an Entity's
+  // Subject version, relative to the version.
+  ListSchemas(ctx context.Context, entityID users.EntityID, subject string, version int)( []string, error )
+  // PutBlob -- Stores 'data' under the Entity's blob for its BlobDigest,
+  // returning the digest. Uploads are skipped when an identical blob is
+  // already stored.
+  PutBlob(ctx context.Context, entityID users.EntityID, data []byte)( string, error )
+  // GetBlob -- Downloads the Entity's blob stored under 'digest', verifying
+  // its content still matches it.
+  GetBlob(ctx context.Context, entityID users.EntityID, digest string)( []byte, error )
+  // GeneratePresignedURL -- Generates a pre-signed URL granting read access
+  // to the Entity's blob stored under 'digest'.
+  GeneratePresignedURL(ctx context.Context, entityID users.EntityID, digest string, expiry time.Duration)( string, error )
+  // GeneratePresignedPutURL -- Generates a pre-signed URL granting uploads of
+  // a single file of a StagedUpload.
+  GeneratePresignedPutURL(ctx context.Context, entityID users.EntityID, uploadID uuid.UUID, path string, expiry time.Duration)( string, error )
+  // ListStagedFiles -- Returns every file uploaded so far for a StagedUpload, along with its size.
+  ListStagedFiles(ctx context.Context, entityID users.EntityID, uploadID uuid.UUID)( []StagedObject, error )
+  // DownloadStagedFile -- Downloads a single file uploaded for a StagedUpload,
+  // reading at most 'limit'+1 bytes so oversized files can be told apart.
+  DownloadStagedFile(ctx context.Context, entityID users.EntityID, uploadID uuid.UUID, path string, limit int64)( []byte, error )
+  // DeleteStagedUpload -- Deletes every file uploaded for a StagedUpload.
+  DeleteStagedUpload(ctx context.Context, entityID users.EntityID, uploadID uuid.UUID) error
 
   Shutdown()error
 }
 
+// SchemaGraphRepository defines our Schema's Graph Storage Logic.
 type SchemaGraphRepository interface {
+  // WriteSchema - Writes a compiled Schema's graph, in dependency order.
+  WriteSchema(ctx context.Context, schema Schema) error
+  // GetPackage - Returns one of the Entity's stored Packages by its
+  // fully-qualified name, looked up under its GraphNamespace.
+  GetPackage(ctx context.Context, entityID users.EntityID, pkg string)( *PackageNode, error )
+  // GetLatestPackage - Returns the newest version of one of the Entity's
+  // unversioned Package names, ordered by PackageVersion. When 'stableOnly'
+  // is set alpha and beta versions are skipped.
+  GetLatestPackage(ctx context.Context, entityID users.EntityID, name string, stableOnly bool)( *PackageNode, error )
+  // ListMessages - Returns every Message defined in one of the Entity's Packages.
+  ListMessages(ctx context.Context, entityID users.EntityID, pkg string)( []MessageNode, error )
+  // GetServiceMethods - Returns every RPC Method of one of the Entity's Services.
+  GetServiceMethods(ctx context.Context, entityID users.EntityID, pkg, service string)( []MethodNode, error )
+  // AnalyzeImpact - Returns every stored node that transitively depends on
+  // 'target', along with the path reaching it, limited to shared nodes and
+  // those stored under 'namespace'. A 'maxDepth' below 1 walks the whole graph.
+  AnalyzeImpact(ctx context.Context, namespace string, target NodeRef, maxDepth int)( *ImpactReport, error )
 
   Shutdown()error
 }
@@ -32,5 +76,59 @@ type SchemaGraphRepository interface {
 type SchemaSQLRepository interface {
   CreateAccessRole(ctx context.Context, role role.Role) error
 
+  // GetCompatibility - Returns an Entity's default CompatibilityMode, falling back
+  // to DefaultCompatibility when the Entity never configured one.
+  GetCompatibility(ctx context.Context, entityID users.EntityID)( CompatibilityMode, error )
+  // SetCompatibility - Sets an Entity's default CompatibilityMode.
+  SetCompatibility(ctx context.Context, entityID users.EntityID, mode CompatibilityMode) error
+  // GetVersioning - Returns an Entity's VersioningScheme, falling back to
+  // DefaultVersioning when the Entity never configured one.
+  GetVersioning(ctx context.Context, entityID users.EntityID)( VersioningScheme, error )
+  // SetVersioning - Sets an Entity's VersioningScheme.
+  SetVersioning(ctx context.Context, entityID users.EntityID, scheme VersioningScheme) error
+  // GetLintConfig - Returns an Entity's LintConfig, falling back to
+  // DefaultLintConfig when the Entity never configured one.
+  GetLintConfig(ctx context.Context, entityID users.EntityID)( LintConfig, error )
+  // SetLintConfig - Sets an Entity's LintConfig.
+  SetLintConfig(ctx context.Context, entityID users.EntityID, config LintConfig) error
+  // ListPolicies - Returns every Policy of an Entity, ordered by name.
+  ListPolicies(ctx context.Context, entityID users.EntityID)( []Policy, error )
+  // PutPolicy - Creates or replaces an Entity's Policy, by name.
+  PutPolicy(ctx context.Context, entityID users.EntityID, policy Policy) error
+  // DeletePolicy - Removes an Entity's Policy by name.
+  DeletePolicy(ctx context.Context, entityID users.EntityID, name string) error
+
+  // GetSubject - Returns an Entity's Subject by name, or an error matching
+  // ErrSubjectNotFound.
+  GetSubject(ctx context.Context, entityID users.EntityID, name string)( *Subject, error )
+  // CreateSubject - Creates a new Subject, returning the existing one if it was already registered.
+  CreateSubject(ctx context.Context, entityID users.EntityID, name string)( *Subject, error )
+  // SetSubjectCompatibility - Overrides a Subject's CompatibilityMode. An empty mode
+  // clears the override so the Subject inherits its Entity's default again.
+  SetSubjectCompatibility(ctx context.Context, entityID users.EntityID, name string, mode CompatibilityMode) error
+
+  // ListSchemaVersions - Returns every version of a Subject, soft-deleted ones
+  // included, ordered from oldest to newest.
+  ListSchemaVersions(ctx context.Context, subjectID uuid.UUID)( []SchemaVersion, error )
+  // CreateSchemaVersion - Records a newly accepted version of the Subject
+  // 'subject', registering the Subject first when it's new, in a single
+  // transaction. 'publish' runs once the version number and tag are claimed,
+  // before the transaction commits; when it fails nothing is recorded and
+  // its error is returned. Fills in the version's ID, SubjectID and CreatedAt.
+  CreateSchemaVersion(ctx context.Context, subject string, version *SchemaVersion, publish func() error) error
+  // SetSchemaVersionDeleted - Soft-deletes a Subject version, or restores it
+  // when 'deleted' is false.
+  SetSchemaVersionDeleted(ctx context.Context, subjectID uuid.UUID, version int, deleted bool) error
+
+  // CreateStagedUpload - Records a new StagedUpload, filling in its ID and CreatedAt.
+  CreateStagedUpload(ctx context.Context, upload *StagedUpload) error
+  // GetStagedUpload - Returns one of an Entity's StagedUploads, expired or not.
+  GetStagedUpload(ctx context.Context, entityID users.EntityID, id uuid.UUID)( *StagedUpload, error )
+  // DeleteStagedUpload - Removes one of an Entity's StagedUploads.
+  DeleteStagedUpload(ctx context.Context, entityID users.EntityID, id uuid.UUID) error
+  // DeleteExpiredStagedUploads - Removes every StagedUpload, of any Entity,
+  // that expired before 'now', returning them.
+  DeleteExpiredStagedUploads(ctx context.Context, now time.Time)( []StagedUpload, error )
+
   Shutdown()error
 }
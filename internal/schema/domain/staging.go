@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
+)
+
+const (
+  // StagingPrefix -- The Blob Storage prefix files of a StagedUpload are
+  // uploaded under, before they're promoted into a Subject version.
+  StagingPrefix = "staging/"
+  // StagedUploadTTL -- How long a StagedUpload, and the presigned URLs issued
+  // for it, remain usable.
+  StagedUploadTTL = time.Hour
+)
+
+// StagedUpload defines a pending Subject version whose files are uploaded
+// directly into Blob Storage through presigned URLs. Files lists the import
+// path of every expected file; nothing is compiled or published until the
+// upload is finalized.
+type StagedUpload struct {
+  ID        uuid.UUID       `json:"id"`
+  EntityID  users.EntityID  `json:"entity_id"`
+  AccountID users.AccountID `json:"account_id"`
+  Subject   string          `json:"subject"`
+  Tag       string          `json:"tag,omitempty"`
+  Files     []string        `json:"files"`
+  CreatedAt time.Time       `json:"created_at"`
+  ExpiresAt time.Time       `json:"expires_at"`
+}
+
+// IsExpired - Returns true once the upload can no longer be finalized.
+func(u StagedUpload) IsExpired(now time.Time) bool {
+  return !now.Before(u.ExpiresAt)
+}
+
+// StagedObject defines a single file uploaded so far for a StagedUpload.
+type StagedObject struct {
+  Path string
+  Size int64
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
+)
+
+var (
+  // ErrSubjectNotFound -- The Entity never registered the queried Subject.
+  ErrSubjectNotFound  = errors.New("subject doesn't exist")
+  // ErrVersionTagExists -- A Subject version's tag is already claimed by
+  // another of its versions.
+  ErrVersionTagExists = errors.New("subject already has a version with this tag")
+)
+
+// Subject defines a named lineage of Schema versions owned by an Entity.
+// An empty Compatibility means the Subject inherits its Entity's default.
+type Subject struct {
+  ID            uuid.UUID         `json:"id"`
+  EntityID      users.EntityID    `json:"entity_id"`
+  Name          string            `json:"name"`
+  Compatibility CompatibilityMode `json:"compatibility,omitempty"`
+  CreatedAt     time.Time         `json:"created_at"`
+  UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// SchemaVersion defines a single accepted version of a Subject. Published
+// versions are immutable; they can only be soft-deleted, setting DeletedAt,
+// and restored. BlobURL is the Blob Storage prefix of this version. Tag is
+// the optional semver tag supplied with the upload. Versioning is the
+// Entity's VersioningScheme when the version was published, which it's
+// always recompiled under. Manifest lists the blob digest of every file, nil
+// for versions stored before blobs were content-addressed.
+type SchemaVersion struct {
+  ID         uuid.UUID        `json:"id"`
+  SubjectID  uuid.UUID        `json:"subject_id"`
+  EntityID   users.EntityID   `json:"entity_id"`
+  Version    int              `json:"version"`
+  Tag        string           `json:"tag,omitempty"`
+  Versioning VersioningScheme `json:"versioning,omitempty"`
+  BlobURL    string           `json:"blob_url"`
+  Manifest   *VersionManifest `json:"manifest,omitempty"`
+  AuthorID   users.AccountID  `json:"author_id"`
+  CreatedAt  time.Time        `json:"created_at"`
+  DeletedAt  *time.Time       `json:"deleted_at,omitempty"`
+}
+
+// IsDeleted - Returns true while the version is soft-deleted.
+func(v SchemaVersion) IsDeleted() bool {
+  return v.DeletedAt != nil
+}
+
+// ActiveVersions - Returns every version of 'versions' that isn't soft-deleted,
+// keeping their order.
+func ActiveVersions(versions []SchemaVersion) []SchemaVersion {
+  active := make([]SchemaVersion, 0, len(versions))
+  for _, v := range versions {
+    if !v.IsDeleted() {
+      active = append(active, v)
+    }
+  }
+  return active
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActiveVersions(t *testing.T) {
+  deleted := time.Now()
+  versions := []SchemaVersion{
+    { Version: 1 },
+    { Version: 2, DeletedAt: &deleted },
+    { Version: 3 },
+  }
+
+  active := ActiveVersions(versions)
+  assert.Len(t, active, 2)
+  assert.Equal(t, 1, active[0].Version)
+  assert.Equal(t, 3, active[1].Version)
+  assert.True(t, versions[1].IsDeleted())
+  assert.Empty(t, ActiveVersions(nil))
+}
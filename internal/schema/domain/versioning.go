@@ -0,0 +1,251 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VersioningScheme defines how the Packages of an Entity's Schemas are versioned.
+//
+// Possible Values
+//    - VersioningPackageSuffix :: The last package segment is the version; vN, vNalphaM or vNbetaM.
+//    - VersioningRegistry      :: Packages are unversioned, the Subject's registry version is used.
+//    - VersioningSemver        :: Packages are unversioned, a semver tag is supplied with every upload.
+type VersioningScheme string
+const (
+  VersioningPackageSuffix VersioningScheme = "PACKAGE_SUFFIX"
+  VersioningRegistry      VersioningScheme = "REGISTRY"
+  VersioningSemver        VersioningScheme = "SEMVER"
+)
+
+// DefaultVersioning is used when an Entity never configured a VersioningScheme.
+const DefaultVersioning = VersioningPackageSuffix
+
+var versioningFromString = map[string]VersioningScheme{
+  "PACKAGE_SUFFIX" : VersioningPackageSuffix,
+  "REGISTRY"       : VersioningRegistry,
+  "SEMVER"         : VersioningSemver,
+}
+
+var (
+  ErrUnknownVersioningScheme = errors.New("unknown versioning scheme")
+  ErrInvalidVersion          = errors.New("invalid version")
+)
+
+// ParseVersioningScheme - Converts a case-insensitive string into a VersioningScheme.
+func ParseVersioningScheme(scheme string)( VersioningScheme, error ){
+  s, ok := versioningFromString[strings.ToUpper(strings.TrimSpace(scheme))]
+  if !ok {
+    return "", fmt.Errorf("%w: %q", ErrUnknownVersioningScheme, scheme)
+  }
+  return s, nil
+}
+
+// Stability defines the maturity of a PackageVersion, ordered alpha < beta < stable.
+type Stability string
+const (
+  StabilityAlpha  Stability = "alpha"
+  StabilityBeta   Stability = "beta"
+  StabilityStable Stability = "stable"
+)
+
+// Rank - Returns a sortable rank, higher being more stable.
+func(s Stability) Rank() int {
+  switch s {
+  case StabilityAlpha:
+    return 0
+  case StabilityBeta:
+    return 1
+  default:
+    return 2
+  }
+}
+
+// PackageVersion defines a parsed, comparable Package version. Prerelease
+// is the M of vNalphaM/vNbetaM, or the numeric identifier of a semver
+// pre-release such as 1.2.0-beta.3.
+type PackageVersion struct {
+  Raw        string    `json:"raw"`
+  Major      int       `json:"major"`
+  Minor      int       `json:"minor"`
+  Patch      int       `json:"patch"`
+  Stability  Stability `json:"stability"`
+  Prerelease int       `json:"prerelease,omitempty"`
+}
+
+func(v PackageVersion) String() string {
+  return v.Raw
+}
+
+// IsStable - Returns true for versions without an alpha or beta qualifier.
+func(v PackageVersion) IsStable() bool {
+  return v.Stability == StabilityStable
+}
+
+// Compare - Returns -1, 0 or 1 when 'v' is older than, equal to or newer than 'o'.
+// Within the same major.minor.patch, alpha < beta < stable.
+func(v PackageVersion) Compare(o PackageVersion) int {
+  for _, d := range []int{
+    v.Major - o.Major,
+    v.Minor - o.Minor,
+    v.Patch - o.Patch,
+    v.Stability.Rank() - o.Stability.Rank(),
+    v.Prerelease - o.Prerelease,
+  }{
+    switch {
+    case d < 0:
+      return -1
+    case d > 0:
+      return 1
+    }
+  }
+  return 0
+}
+
+var (
+  packageSuffixPattern = regexp.MustCompile(`^[vV](\d+)(?:(alpha|beta)(\d*))?$`)
+  semverPattern        = regexp.MustCompile(
+    `^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`,
+  )
+)
+
+// ParsePackageSuffix - Parses a package version segment, e.g. v1, v2alpha or v3beta2.
+//
+// Potential Errors:
+//    - ErrInvalidVersion
+func ParsePackageSuffix(segment string)( PackageVersion, error ){
+  m := packageSuffixPattern.FindStringSubmatch(segment)
+  if m == nil {
+    return PackageVersion{}, fmt.Errorf(
+      "%w: %q must be vN, vNalphaM or vNbetaM",
+      ErrInvalidVersion,
+      segment,
+    )
+  }
+  v := PackageVersion{
+    Raw       : segment,
+    Stability : StabilityStable,
+  }
+  v.Major, _ = strconv.Atoi(m[1])
+  if m[2] != "" {
+    v.Stability = Stability(m[2])
+  }
+  if m[3] != "" {
+    v.Prerelease, _ = strconv.Atoi(m[3])
+  }
+  return v, nil
+}
+
+// ParseSemver - Parses a semver tag, e.g. 1.4.0, v2.0.0-beta.1. Only alpha and
+// beta pre-releases are understood; build metadata is ignored.
+//
+// Potential Errors:
+//    - ErrInvalidVersion
+func ParseSemver(tag string)( PackageVersion, error ){
+  m := semverPattern.FindStringSubmatch(strings.TrimSpace(tag))
+  if m == nil {
+    return PackageVersion{}, fmt.Errorf("%w: %q is not a semver tag", ErrInvalidVersion, tag)
+  }
+  v := PackageVersion{
+    Raw       : strings.TrimPrefix(strings.TrimSpace(tag), "v"),
+    Stability : StabilityStable,
+  }
+  v.Major, _ = strconv.Atoi(m[1])
+  v.Minor, _ = strconv.Atoi(m[2])
+  v.Patch, _ = strconv.Atoi(m[3])
+
+  if pre := m[4]; pre != "" {
+    label, num, _ := strings.Cut(pre, ".")
+    switch Stability(label) {
+    case StabilityAlpha, StabilityBeta:
+      v.Stability = Stability(label)
+    default:
+      return PackageVersion{}, fmt.Errorf(
+        "%w: pre-release %q must be alpha or beta",
+        ErrInvalidVersion,
+        pre,
+      )
+    }
+    if num != "" {
+      n, err := strconv.Atoi(num)
+      if err != nil {
+        return PackageVersion{}, fmt.Errorf("%w: pre-release %q", ErrInvalidVersion, pre)
+      }
+      v.Prerelease = n
+    }
+  }
+  return v, nil
+}
+
+// VersionContext defines everything needed to resolve a Package's version
+// under an Entity's VersioningScheme.
+type VersionContext struct {
+  Scheme   VersioningScheme
+  Registry int    // Subject version being registered, for VersioningRegistry.
+  Tag      string // Semver tag supplied with the upload, for VersioningSemver.
+}
+
+// Resolve - Splits 'pkg' into its unversioned name and its PackageVersion.
+// Only VersioningPackageSuffix strips the version from the package name.
+//
+// Potential Errors:
+//    - ErrInvalidVersion
+//    - ErrUnknownVersioningScheme
+func(vc VersionContext) Resolve(pkg string)( string, PackageVersion, error ){
+  switch vc.Scheme {
+  case VersioningPackageSuffix, "":
+    idx := strings.LastIndex(pkg, ".")
+    if pkg == "" || idx == -1 {
+      return "", PackageVersion{}, fmt.Errorf(
+        "%w: package %q has no version segment",
+        ErrInvalidVersion,
+        pkg,
+      )
+    }
+    v, err := ParsePackageSuffix(pkg[idx+1:])
+    if err != nil {
+      return "", PackageVersion{}, err
+    }
+    return pkg[:idx], v, nil
+
+  case VersioningRegistry:
+    if vc.Registry < 1 {
+      return "", PackageVersion{}, fmt.Errorf(
+        "%w: registry version %d",
+        ErrInvalidVersion,
+        vc.Registry,
+      )
+    }
+    return pkg, PackageVersion{
+      Raw       : strconv.Itoa(vc.Registry),
+      Major     : vc.Registry,
+      Stability : StabilityStable,
+    }, nil
+
+  case VersioningSemver:
+    v, err := ParseSemver(vc.Tag)
+    if err != nil {
+      return "", PackageVersion{}, err
+    }
+    return pkg, v, nil
+  }
+
+  return "", PackageVersion{}, fmt.Errorf("%w: %q", ErrUnknownVersioningScheme, vc.Scheme)
+}
+
+// KeySuffix - Returns the suffix that keeps graph keys of different versions
+// apart when the version isn't already part of the package name.
+func(vc VersionContext) KeySuffix() string {
+  switch vc.Scheme {
+  case VersioningRegistry:
+    return "@" + strconv.Itoa(vc.Registry)
+  case VersioningSemver:
+    if v, err := ParseSemver(vc.Tag); err == nil {
+      return "@" + v.Raw
+    }
+  }
+  return ""
+}
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionContextResolve(t *testing.T) {
+  tests := []struct{
+    name    string
+    vc      VersionContext
+    pkg     string
+    wantPkg string
+    want    PackageVersion
+    err     error
+  }{
+    {
+      name    : "stable suffix",
+      vc      : VersionContext{},
+      pkg     : "acme.orders.v2",
+      wantPkg : "acme.orders",
+      want    : PackageVersion{ Raw: "v2", Major: 2, Stability: StabilityStable },
+    },
+    {
+      name    : "alpha suffix without number",
+      vc      : VersionContext{ Scheme: VersioningPackageSuffix },
+      pkg     : "user.v1alpha",
+      wantPkg : "user",
+      want    : PackageVersion{ Raw: "v1alpha", Major: 1, Stability: StabilityAlpha },
+    },
+    {
+      name    : "beta suffix",
+      vc      : VersionContext{ Scheme: VersioningPackageSuffix },
+      pkg     : "user.v3beta2",
+      wantPkg : "user",
+      want    : PackageVersion{ Raw: "v3beta2", Major: 3, Stability: StabilityBeta, Prerelease: 2 },
+    },
+    {
+      name : "missing suffix",
+      vc   : VersionContext{ Scheme: VersioningPackageSuffix },
+      pkg  : "acme.orders",
+      err  : ErrInvalidVersion,
+    },
+    {
+      name : "empty package",
+      vc   : VersionContext{ Scheme: VersioningPackageSuffix },
+      pkg  : "",
+      err  : ErrInvalidVersion,
+    },
+    {
+      name    : "registry",
+      vc      : VersionContext{ Scheme: VersioningRegistry, Registry: 7 },
+      pkg     : "acme.orders",
+      wantPkg : "acme.orders",
+      want    : PackageVersion{ Raw: "7", Major: 7, Stability: StabilityStable },
+    },
+    {
+      name : "registry without version",
+      vc   : VersionContext{ Scheme: VersioningRegistry },
+      pkg  : "acme.orders",
+      err  : ErrInvalidVersion,
+    },
+    {
+      name    : "semver",
+      vc      : VersionContext{ Scheme: VersioningSemver, Tag: "v1.4.2-alpha.1+build.9" },
+      pkg     : "acme.orders",
+      wantPkg : "acme.orders",
+      want    : PackageVersion{
+        Raw        : "1.4.2-alpha.1+build.9",
+        Major      : 1,
+        Minor      : 4,
+        Patch      : 2,
+        Stability  : StabilityAlpha,
+        Prerelease : 1,
+      },
+    },
+    {
+      name : "semver with unknown pre-release",
+      vc   : VersionContext{ Scheme: VersioningSemver, Tag: "1.0.0-rc.1" },
+      pkg  : "acme.orders",
+      err  : ErrInvalidVersion,
+    },
+    {
+      name : "unknown scheme",
+      vc   : VersionContext{ Scheme: "CALVER" },
+      pkg  : "acme.orders",
+      err  : ErrUnknownVersioningScheme,
+    },
+  }
+
+  for _, tt := range tests {
+    t.Run(tt.name, func(t *testing.T){
+      pkg, version, err := tt.vc.Resolve(tt.pkg)
+      if tt.err != nil {
+        assert.ErrorIs(t, err, tt.err)
+        return
+      }
+      assert.NoError(t, err)
+      assert.Equal(t, tt.wantPkg, pkg)
+      assert.Equal(t, tt.want, version)
+    })
+  }
+}
+
+func TestPackageVersionOrdering(t *testing.T) {
+  raw := []string{ "v2", "v1beta", "v10", "v2alpha2", "v1", "v2beta1", "v1alpha", "v2alpha1" }
+
+  versions := make([]PackageVersion, 0, len(raw))
+  for _, r := range raw {
+    v, err := ParsePackageSuffix(r)
+    if err != nil {
+      t.Fatal(err)
+    }
+    versions = append(versions, v)
+  }
+  sort.Slice(versions, func(i, j int) bool {
+    return versions[i].Compare(versions[j]) < 0
+  })
+
+  sorted := make([]string, 0, len(versions))
+  for _, v := range versions {
+    sorted = append(sorted, v.String())
+  }
+  assert.Equal(t, []string{
+    "v1alpha", "v1beta", "v1", "v2alpha1", "v2alpha2", "v2beta1", "v2", "v10",
+  }, sorted)
+
+  pre, _ := ParseSemver("2.0.0-beta.1")
+  rel, _ := ParseSemver("2.0.0")
+  assert.Equal(t, -1, pre.Compare(rel))
+  assert.False(t, pre.IsStable())
+  assert.True(t, rel.IsStable())
+}
+
+func TestParseVersioningScheme(t *testing.T) {
+  scheme, err := ParseVersioningScheme(" semver ")
+  assert.NoError(t, err)
+  assert.Equal(t, VersioningSemver, scheme)
+
+  _, err = ParseVersioningScheme("CALVER")
+  assert.ErrorIs(t, err, ErrUnknownVersioningScheme)
+}
@@ -1,12 +1,26 @@
 package http
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"strconv"
 
+	"github.com/TylerAldrich814/Fidicus/internal/shared/jwt"
 	"github.com/TylerAldrich814/Fidicus/internal/shared/role"
+	"github.com/TylerAldrich814/Fidicus/internal/shared/utils"
 	"github.com/TylerAldrich814/Fidicus/internal/shared/middleware"
 	"github.com/TylerAldrich814/Fidicus/internal/schema/application"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	repo "github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/repository"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
 )
 
 // SchemaHTTPHandler defines a structure for handling all HTTP requests
@@ -34,19 +48,53 @@ func(s *SchemaHTTPHandler) RegisterRoutes(r mux.Router) error {
     ),
   ).Methods("PUT")
 
+  schema.HandleFunc(
+    "/versions/{subject}",
+    s.ListVersions,
+  ).Methods("GET")
+
   schema.Handle(
-    "/delete",
+    "/versions/{subject}/{version}",
     middleware.RoleAuthMiddleware(
       http.HandlerFunc(s.DeleteSchema),
-      role.AccessRoleAccount,
+      role.AccessRoleAdmin,
     ),
   ).Methods("DELETE")
 
+  schema.Handle(
+    "/versions/{subject}/{version}/restore",
+    middleware.RoleAuthMiddleware(
+      http.HandlerFunc(s.RestoreSchema),
+      role.AccessRoleAdmin,
+    ),
+  ).Methods("POST")
+
   schema.HandleFunc(
     "/download/{source}/{id}",
     s.GetSchemas,
   ).Methods("GET")
 
+  schema.HandleFunc(
+    "/descriptors/{subject}/{version}",
+    s.GetDescriptors,
+  ).Methods("GET")
+
+  schema.Handle(
+    "/staging",
+    middleware.RoleAuthMiddleware(
+      http.HandlerFunc(s.CreateStagedUpload),
+      role.AccessRoleAccount,
+    ),
+  ).Methods("POST")
+
+  schema.Handle(
+    "/staging/{id}/finalize",
+    middleware.RoleAuthMiddleware(
+      http.HandlerFunc(s.FinalizeStagedUpload),
+      role.AccessRoleAccount,
+    ),
+  ).Methods("POST")
+
   schema.Handle(
     "/sync",
     middleware.RoleAuthMiddleware(
@@ -65,23 +113,871 @@ func(s *SchemaHTTPHandler) RegisterRoutes(r mux.Router) error {
     s.Validate,
   ).Methods("GET")
 
+  schema.HandleFunc(
+    "/impact/{kind}/{name}",
+    s.AnalyzeImpact,
+  ).Methods("GET")
+
+  schema.HandleFunc(
+    "/config",
+    s.GetCompatibility,
+  ).Methods("GET")
+
+  schema.Handle(
+    "/config",
+    middleware.RoleAuthMiddleware(
+      http.HandlerFunc(s.SetCompatibility),
+      role.AccessRoleAdmin,
+    ),
+  ).Methods("PUT")
+
+  schema.HandleFunc(
+    "/config/{subject}",
+    s.GetCompatibility,
+  ).Methods("GET")
+
+  schema.Handle(
+    "/config/{subject}",
+    middleware.RoleAuthMiddleware(
+      http.HandlerFunc(s.SetCompatibility),
+      role.AccessRoleAdmin,
+    ),
+  ).Methods("PUT")
+
+  schema.Handle(
+    "/config/{subject}",
+    middleware.RoleAuthMiddleware(
+      http.HandlerFunc(s.ClearCompatibility),
+      role.AccessRoleAdmin,
+    ),
+  ).Methods("DELETE")
+
+  schema.HandleFunc(
+    "/versioning",
+    s.GetVersioning,
+  ).Methods("GET")
+
+  schema.Handle(
+    "/versioning",
+    middleware.RoleAuthMiddleware(
+      http.HandlerFunc(s.SetVersioning),
+      role.AccessRoleAdmin,
+    ),
+  ).Methods("PUT")
+
+  schema.HandleFunc(
+    "/lint",
+    s.GetLintConfig,
+  ).Methods("GET")
+
+  schema.Handle(
+    "/lint",
+    middleware.RoleAuthMiddleware(
+      http.HandlerFunc(s.SetLintConfig),
+      role.AccessRoleAdmin,
+    ),
+  ).Methods("PUT")
+
+  schema.HandleFunc(
+    "/policies",
+    s.ListPolicies,
+  ).Methods("GET")
+
+  schema.Handle(
+    "/policies/{name}",
+    middleware.RoleAuthMiddleware(
+      http.HandlerFunc(s.PutPolicy),
+      role.AccessRoleAdmin,
+    ),
+  ).Methods("PUT")
+
+  schema.Handle(
+    "/policies/{name}",
+    middleware.RoleAuthMiddleware(
+      http.HandlerFunc(s.DeletePolicy),
+      role.AccessRoleAdmin,
+    ),
+  ).Methods("DELETE")
+
   return nil
 }
 
+// UploadSchemas - [PROTECTED] Registers a new version of a Subject. Files can
+// be sent as
+//    - application/json    :: {"subject", "tag", "files": {path: content}}, or
+//                             a protojson FileDescriptorSet as "descriptor"
+//                             rather than "files".
+//    - multipart/form-data :: "subject", "tag" and "root" fields, plus any number
+//                             of "files" parts, named by their relative path, a
+//                             single "archive" part or a single "descriptor" part,
+//                             a binary or protojson FileDescriptorSet.
+//    - A raw .zip, .tar.gz or .tar body :: with ?subject=, ?tag= and ?root=.
+//    - A raw application/x-protobuf body :: a binary FileDescriptorSet, e.g. from
+//                                          `buf build`, with ?subject= and ?tag=.
+// Archives are read from their 'root' directory. Only .proto sources are
+// accepted; OpenAPI and GraphQL documents can't be uploaded yet, see
+// application.SchemaExtensions. Any path sent twice, whether as "files"
+// parts or archive entries, is rejected with 400. The upload is rejected with
+// 409 and the list of violations when it breaks the Subject's CompatibilityMode,
+// and with 400 and every Diagnostic when it fails to compile. Re-uploading
+// the Subject's latest version returns it with 200 rather than 201.
 func(s *SchemaHTTPHandler) UploadSchemas(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+  r.Body = http.MaxBytesReader(w, r.Body, application.MaxUploadSize)
+
+  req, err := readUploadRequest(r)
+  if err != nil {
+    var maxErr *http.MaxBytesError
+    switch {
+    case errors.As(err, &maxErr),
+         errors.Is(err, application.ErrUploadTooLarge):
+      http.Error(w, application.ErrUploadTooLarge.Error(), http.StatusRequestEntityTooLarge)
+    case errors.Is(err, application.ErrInvalidSchemaPath),
+         errors.Is(err, application.ErrInvalidArchive):
+      http.Error(w, err.Error(), http.StatusBadRequest)
+    default:
+      http.Error(w, "<json error>missing required fields", http.StatusBadRequest)
+    }
+    return
+  }
+  req.EntityID  = claims.EntityID
+  req.AccountID = claims.AccountID
+
+  version, err := s.service.UploadSchema(r.Context(), *req)
+  writeUploadResult(w, version, err)
+}
+
+// writeUploadResult - Writes the outcome of UploadSchema, shared by every
+// endpoint publishing a Subject version.
+func writeUploadResult(
+  w       http.ResponseWriter,
+  version *application.UploadSchemaResult,
+  err     error,
+) {
+  if err != nil {
+    var (
+      compatErr *domain.CompatibilityError
+      diagErr   domain.DiagnosticError
+    )
+    switch {
+    case errors.As(err, &compatErr):
+      utils.WriteJson(w, http.StatusConflict, compatErr)
+    case errors.As(err, &diagErr):
+      utils.WriteJson(w, http.StatusBadRequest, diagnosticsBody{
+        Error       : err.Error(),
+        Diagnostics : diagErr.Diagnostics(),
+      })
+    case errors.Is(err, application.ErrUploadTooLarge):
+      http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+    case errors.Is(err, application.ErrInvalidUploadRequest),
+         errors.Is(err, application.ErrInvalidSchemaPath),
+         errors.Is(err, application.ErrInvalidVersionTag),
+         errors.Is(err, application.ErrSchemaCompileFailed),
+         errors.Is(err, application.ErrStagedFileMissing),
+         errors.Is(err, domain.ErrInvalidBlobKey):
+      http.Error(w, err.Error(), http.StatusBadRequest)
+    case errors.Is(err, repo.ErrDBSchemaVersionExists),
+         errors.Is(err, application.ErrVersionTagExists):
+      http.Error(w, err.Error(), http.StatusConflict)
+    case errors.Is(err, repo.ErrDBStagedUploadNotFound):
+      http.Error(w, err.Error(), http.StatusNotFound)
+    case errors.Is(err, application.ErrStagedUploadExpired):
+      http.Error(w, err.Error(), http.StatusGone)
+    default:
+      http.Error(w, "failed to upload schema", http.StatusInternalServerError)
+    }
+    return
+  }
+
+  status := http.StatusCreated
+  if version.Unchanged {
+    status = http.StatusOK
+  }
+  utils.WriteJson(w, status, version)
+}
+
+// CreateStagedUpload - [PROTECTED] Starts a direct-to-storage upload for large
+// Subject versions. Takes {"subject", "tag", "files": [path, ...]} and
+// returns a presigned PUT URL for every file. Once every file is uploaded,
+// the version is published with FinalizeStagedUpload, which refuses uploads
+// larger than the upload size limit.
+func(s *SchemaHTTPHandler) CreateStagedUpload(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+
+  var req struct {
+    Subject string   `json:"subject"`
+    Tag     string   `json:"tag"`
+    Files   []string `json:"files"`
+  }
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+    http.Error(w, "<json error>missing required fields", http.StatusBadRequest)
+    return
+  }
+
+  upload, err := s.service.CreateStagedUpload(r.Context(), application.StagedUploadRequest{
+    EntityID  : claims.EntityID,
+    AccountID : claims.AccountID,
+    Subject   : req.Subject,
+    Tag       : req.Tag,
+    Files     : req.Files,
+  })
+  if err != nil {
+    switch {
+    case errors.Is(err, application.ErrUploadTooLarge):
+      http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+    case errors.Is(err, application.ErrInvalidUploadRequest),
+         errors.Is(err, application.ErrInvalidSchemaPath),
+         errors.Is(err, application.ErrInvalidVersionTag),
+         errors.Is(err, domain.ErrInvalidBlobKey):
+      http.Error(w, err.Error(), http.StatusBadRequest)
+    default:
+      http.Error(w, "failed to start staged upload", http.StatusInternalServerError)
+    }
+    return
+  }
+
+  utils.WriteJson(w, http.StatusCreated, upload)
+}
+
+// FinalizeStagedUpload - [PROTECTED] Compiles and validates every file staged
+// for upload {id}, publishing them as the Subject's next version. Responds
+// like UploadSchemas, or with 410 once the upload expired.
+func(s *SchemaHTTPHandler) FinalizeStagedUpload(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+  id, err := uuid.Parse(mux.Vars(r)["id"])
+  if err != nil {
+    http.Error(w, "invalid staged upload id", http.StatusBadRequest)
+    return
+  }
+
+  version, err := s.service.FinalizeStagedUpload(r.Context(), claims.EntityID, id)
+  writeUploadResult(w, version, err)
+}
+
+// readUploadRequest - Reads an UploadSchemaRequest from any of the body
+// formats UploadSchemas accepts.
+func readUploadRequest(r *http.Request)( *application.UploadSchemaRequest, error ){
+  contentType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+  switch contentType {
+  case "multipart/form-data":
+    return readMultipartUpload(multipart.NewReader(r.Body, params["boundary"]))
+  case "application/json", "":
+    var req struct {
+      Subject    string            `json:"subject"`
+      Tag        string            `json:"tag"`
+      Files      map[string]string `json:"files"`
+      Descriptor json.RawMessage   `json:"descriptor"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+      return nil, err
+    }
+    files := make(map[string][]byte, len(req.Files))
+    for path, content := range req.Files {
+      files[path] = []byte(content)
+    }
+    // ->> An explicit null is kept verbatim by json.RawMessage, yet means
+    //     the same as leaving the descriptor out.
+    if bytes.Equal(bytes.TrimSpace(req.Descriptor), []byte("null")) {
+      req.Descriptor = nil
+    }
+    return &application.UploadSchemaRequest{
+      Subject    : req.Subject,
+      Tag        : req.Tag,
+      Files      : files,
+      Descriptor : req.Descriptor,
+    }, nil
+  case "application/x-protobuf", "application/vnd.google.protobuf":
+    data, err := io.ReadAll(r.Body)
+    if err != nil {
+      return nil, err
+    }
+    return &application.UploadSchemaRequest{
+      Subject    : r.URL.Query().Get("subject"),
+      Tag        : r.URL.Query().Get("tag"),
+      Descriptor : data,
+    }, nil
+  }
+
+  format, ok := application.DetectArchiveFormat("", contentType)
+  if !ok {
+    return nil, fmt.Errorf("%w: unsupported content type %q", application.ErrInvalidArchive, contentType)
+  }
+  query := r.URL.Query()
+  files, err := application.ExtractArchive(format, r.Body, query.Get("root"))
+  if err != nil {
+    return nil, err
+  }
+  return &application.UploadSchemaRequest{
+    Subject : query.Get("subject"),
+    Tag     : query.Get("tag"),
+    Files   : files,
+  }, nil
+}
+
+// readMultipartUpload - Reads the fields and files of a multipart upload.
+// Parts are read in order, so "root" must be sent before "archive".
+func readMultipartUpload(mr *multipart.Reader)( *application.UploadSchemaRequest, error ){
+  req  := &application.UploadSchemaRequest{ Files: map[string][]byte{} }
+  root := ""
+  for {
+    part, err := mr.NextPart()
+    if err == io.EOF {
+      break
+    }
+    if err != nil {
+      return nil, err
+    }
+
+    switch part.FormName() {
+    case "subject", "tag", "root":
+      value, err := io.ReadAll(io.LimitReader(part, 1024))
+      if err != nil {
+        return nil, err
+      }
+      switch part.FormName() {
+      case "subject":
+        req.Subject = string(value)
+      case "tag":
+        req.Tag = string(value)
+      case "root":
+        root = string(value)
+      }
+    case "archive":
+      format, ok := application.DetectArchiveFormat(
+        partFilename(part),
+        part.Header.Get("Content-Type"),
+      )
+      if !ok {
+        return nil, fmt.Errorf("%w: unsupported archive %q", application.ErrInvalidArchive, partFilename(part))
+      }
+      files, err := application.ExtractArchive(format, part, root)
+      if err != nil {
+        return nil, err
+      }
+      for path, data := range files {
+        if err := application.AddUploadFile(req.Files, path, data); err != nil {
+          return nil, err
+        }
+      }
+    case "files":
+      data, err := io.ReadAll(part)
+      if err != nil {
+        return nil, err
+      }
+      if err := application.AddUploadFile(req.Files, partFilename(part), data); err != nil {
+        return nil, err
+      }
+    case "descriptor":
+      data, err := io.ReadAll(part)
+      if err != nil {
+        return nil, err
+      }
+      req.Descriptor = data
+    }
+    _ = part.Close()
+  }
+  return req, nil
+}
+
+// partFilename - Returns the filename a multipart part was sent with.
+// multipart.Part.FileName strips directories, which are part of an import
+// path, so the header is parsed directly. Paths are validated by the Service.
+func partFilename(part *multipart.Part) string {
+  _, params, err := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+  if err != nil {
+    return ""
+  }
+  return params["filename"]
+}
+
+// GetCompatibility - [PROTECTED] Returns the effective CompatibilityMode of the
+// requested Subject, or the Entity's default when no Subject is provided.
+func(s *SchemaHTTPHandler) GetCompatibility(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+  subject := mux.Vars(r)["subject"]
+
+  mode, err := s.service.GetCompatibility(r.Context(), claims.EntityID, subject)
+  if err != nil {
+    if errors.Is(err, repo.ErrDBSubjectNotFound) {
+      http.Error(w, err.Error(), http.StatusNotFound)
+      return
+    }
+    http.Error(w, "failed to get compatibility", http.StatusInternalServerError)
+    return
+  }
+
+  utils.WriteJson(w, http.StatusOK, compatibilityBody{ mode })
+}
+
+// SetCompatibility - [PROTECTED] Sets the Entity's default CompatibilityMode, or
+// overrides a Subject's mode when a Subject is provided.
+func(s *SchemaHTTPHandler) SetCompatibility(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+  subject := mux.Vars(r)["subject"]
+
+  var req struct {
+    Compatibility string `json:"compatibility"`
+  }
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+    http.Error(w, "<json error>missing required fields", http.StatusBadRequest)
+    return
+  }
+  mode, err := domain.ParseCompatibilityMode(req.Compatibility)
+  if err != nil {
+    http.Error(w, err.Error(), http.StatusBadRequest)
+    return
+  }
+
+  if err := s.service.SetCompatibility(r.Context(), claims.EntityID, subject, mode); err != nil {
+    http.Error(w, "failed to set compatibility", http.StatusInternalServerError)
+    return
+  }
+
+  utils.WriteJson(w, http.StatusOK, compatibilityBody{ mode })
+}
+
+// ClearCompatibility - [PROTECTED] Removes a Subject's CompatibilityMode override.
+func(s *SchemaHTTPHandler) ClearCompatibility(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+
+  if err := s.service.ClearCompatibility(
+    r.Context(),
+    claims.EntityID,
+    mux.Vars(r)["subject"],
+  ); err != nil {
+    if errors.Is(err, repo.ErrDBSubjectNotFound) {
+      http.Error(w, err.Error(), http.StatusNotFound)
+      return
+    }
+    http.Error(w, "failed to clear compatibility", http.StatusInternalServerError)
+    return
+  }
+
+  w.WriteHeader(http.StatusOK)
+}
+
+type compatibilityBody struct {
+  Compatibility domain.CompatibilityMode `json:"compatibility"`
+}
+
+// GetVersioning - [PROTECTED] Returns the Entity's VersioningScheme.
+func(s *SchemaHTTPHandler) GetVersioning(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+
+  scheme, err := s.service.GetVersioning(r.Context(), claims.EntityID)
+  if err != nil {
+    http.Error(w, "failed to get versioning scheme", http.StatusInternalServerError)
+    return
+  }
+
+  utils.WriteJson(w, http.StatusOK, versioningBody{ scheme })
+}
+
+// SetVersioning - [PROTECTED] Sets the Entity's VersioningScheme.
+func(s *SchemaHTTPHandler) SetVersioning(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+
+  var req struct {
+    Versioning string `json:"versioning"`
+  }
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+    http.Error(w, "<json error>missing required fields", http.StatusBadRequest)
+    return
+  }
+  scheme, err := domain.ParseVersioningScheme(req.Versioning)
+  if err != nil {
+    http.Error(w, err.Error(), http.StatusBadRequest)
+    return
+  }
+
+  if err := s.service.SetVersioning(r.Context(), claims.EntityID, scheme); err != nil {
+    http.Error(w, "failed to set versioning scheme", http.StatusInternalServerError)
+    return
+  }
+
+  utils.WriteJson(w, http.StatusOK, versioningBody{ scheme })
+}
+
+type versioningBody struct {
+  Versioning domain.VersioningScheme `json:"versioning"`
+}
+
+// GetLintConfig - [PROTECTED] Returns the Entity's LintConfig.
+func(s *SchemaHTTPHandler) GetLintConfig(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+
+  config, err := s.service.GetLintConfig(r.Context(), claims.EntityID)
+  if err != nil {
+    http.Error(w, "failed to get lint config", http.StatusInternalServerError)
+    return
+  }
+
+  utils.WriteJson(w, http.StatusOK, config)
+}
+
+// SetLintConfig - [PROTECTED] Sets the Entity's LintConfig.
+func(s *SchemaHTTPHandler) SetLintConfig(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+
+  var config domain.LintConfig
+  if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+    http.Error(w, "<json error>missing required fields", http.StatusBadRequest)
+    return
+  }
+  ruleSet, err := domain.ParseLintRuleSet(string(config.RuleSet))
+  if err != nil {
+    http.Error(w, err.Error(), http.StatusBadRequest)
+    return
+  }
+  config.RuleSet = ruleSet
+
+  if err := s.service.SetLintConfig(r.Context(), claims.EntityID, config); err != nil {
+    if errors.Is(err, domain.ErrUnknownLintRuleSet) {
+      http.Error(w, err.Error(), http.StatusBadRequest)
+      return
+    }
+    http.Error(w, "failed to set lint config", http.StatusInternalServerError)
+    return
+  }
+
+  utils.WriteJson(w, http.StatusOK, config)
+}
+
+// ListPolicies - [PROTECTED] Returns every Policy of the Entity.
+func(s *SchemaHTTPHandler) ListPolicies(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+
+  policies, err := s.service.ListPolicies(r.Context(), claims.EntityID)
+  if err != nil {
+    http.Error(w, "failed to list policies", http.StatusInternalServerError)
+    return
+  }
+
+  utils.WriteJson(w, http.StatusOK, policies)
+}
+
+// PutPolicy - [PROTECTED] Creates or replaces the Entity's Policy named by {name}.
+func(s *SchemaHTTPHandler) PutPolicy(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+
+  var policy domain.Policy
+  if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+    http.Error(w, "<json error>missing required fields", http.StatusBadRequest)
+    return
+  }
+  target, err := domain.ParsePolicyTarget(string(policy.Target))
+  if err != nil {
+    http.Error(w, err.Error(), http.StatusBadRequest)
+    return
+  }
+  policy.Name   = mux.Vars(r)["name"]
+  policy.Target = target
+
+  stored, err := s.service.PutPolicy(r.Context(), claims.EntityID, policy)
+  if err != nil {
+    if errors.Is(err, domain.ErrInvalidPolicy) ||
+       errors.Is(err, domain.ErrUnknownPolicyTarget) {
+      http.Error(w, err.Error(), http.StatusBadRequest)
+      return
+    }
+    http.Error(w, "failed to set policy", http.StatusInternalServerError)
+    return
+  }
+
+  utils.WriteJson(w, http.StatusOK, stored)
+}
+
+// DeletePolicy - [PROTECTED] Removes the Entity's Policy named by {name}.
+func(s *SchemaHTTPHandler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+
+  if err := s.service.DeletePolicy(
+    r.Context(),
+    claims.EntityID,
+    mux.Vars(r)["name"],
+  ); err != nil {
+    if errors.Is(err, repo.ErrDBPolicyNotFound) {
+      http.Error(w, err.Error(), http.StatusNotFound)
+      return
+    }
+    http.Error(w, "failed to delete policy", http.StatusInternalServerError)
+    return
+  }
+
+  w.WriteHeader(http.StatusOK)
+}
 
+// ListVersions - [PROTECTED] Returns the version history of the Subject named
+// by {subject}, oldest first. Soft-deleted versions are listed with
+// ?deleted=true.
+func(s *SchemaHTTPHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+  includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("deleted"))
 
-  if err := s.service.UploadSchema(r.Context()); err != nil {
-    http.Error(w, "failed to upload schema", http.StatusBadRequest)
+  versions, err := s.service.ListSchemaVersions(
+    r.Context(),
+    claims.EntityID,
+    mux.Vars(r)["subject"],
+    includeDeleted,
+  )
+  if err != nil {
+    switch {
+    case errors.Is(err, repo.ErrDBSubjectNotFound):
+      http.Error(w, err.Error(), http.StatusNotFound)
+    default:
+      http.Error(w, "failed to list schema versions", http.StatusInternalServerError)
+    }
+    return
   }
+
+  utils.WriteJson(w, http.StatusOK, versions)
 }
 
+// DeleteSchema - [PROTECTED] Soft-deletes {version}, a version number or
+// semver tag, of the Subject named by {subject}. Its files are kept, so it
+// can be restored later.
 func(s *SchemaHTTPHandler) DeleteSchema(w http.ResponseWriter, r *http.Request) {
+  s.setVersionDeleted(w, r, true)
+}
 
+// RestoreSchema - [PROTECTED] Restores a soft-deleted {version} of the
+// Subject named by {subject}.
+func(s *SchemaHTTPHandler) RestoreSchema(w http.ResponseWriter, r *http.Request) {
+  s.setVersionDeleted(w, r, false)
 }
 
+func(s *SchemaHTTPHandler) setVersionDeleted(
+  w       http.ResponseWriter,
+  r       *http.Request,
+  deleted bool,
+) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+
+  update := s.service.RestoreSchemaVersion
+  if deleted {
+    update = s.service.DeleteSchemaVersion
+  }
+  version, err := update(
+    r.Context(),
+    claims.EntityID,
+    mux.Vars(r)["subject"],
+    mux.Vars(r)["version"],
+  )
+  if err != nil {
+    switch {
+    case errors.Is(err, repo.ErrDBSubjectNotFound),
+         errors.Is(err, repo.ErrDBSchemaVersionNotFound),
+         errors.Is(err, application.ErrSchemaVersionNotFound):
+      http.Error(w, err.Error(), http.StatusNotFound)
+    case errors.Is(err, application.ErrVersionTagExists):
+      http.Error(w, err.Error(), http.StatusConflict)
+    default:
+      http.Error(w, "failed to update schema version", http.StatusInternalServerError)
+    }
+    return
+  }
+
+  utils.WriteJson(w, http.StatusOK, version)
+}
+
+// GetSchemas - [PROTECTED] Downloads version {id} ("latest", a version
+// number or a semver tag) of the Subject named by {source}.
+//    - ?file=path            :: Returns that single file as is.
+//    - ?format=zip|tar.gz|tar :: Returns a bundle of the version, or of every
+//                               ?file= given, along with every file they
+//                               transitively import and a .fidicus/manifest.json
+//                               listing each file's path, digest and version.
+// Bundles are zipped when neither is given.
 func(s *SchemaHTTPHandler) GetSchemas(w http.ResponseWriter, r *http.Request){
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
 
+  query  := r.URL.Query()
+  files  := query["file"]
+  format := application.ArchiveZip
+  if f := query.Get("format"); f != "" {
+    detected, ok := application.DetectArchiveFormat("."+f, "")
+    if !ok {
+      http.Error(w, "format must be one of zip, tar.gz or tar", http.StatusBadRequest)
+      return
+    }
+    format = detected
+  }
+
+  bundle, err := s.service.DownloadSchema(
+    r.Context(),
+    claims.EntityID,
+    mux.Vars(r)["source"],
+    mux.Vars(r)["id"],
+    files,
+  )
+  if err != nil {
+    switch {
+    case errors.Is(err, repo.ErrDBSubjectNotFound),
+         errors.Is(err, application.ErrSchemaVersionNotFound),
+         errors.Is(err, application.ErrSchemaFileNotFound):
+      http.Error(w, err.Error(), http.StatusNotFound)
+    case errors.Is(err, application.ErrInvalidSchemaPath),
+         errors.Is(err, domain.ErrInvalidBlobKey):
+      http.Error(w, err.Error(), http.StatusBadRequest)
+    default:
+      http.Error(w, "failed to download schema", http.StatusInternalServerError)
+    }
+    return
+  }
+
+  // ->> A single file is returned as is, unless a bundle was asked for.
+  if len(files) == 1 && query.Get("format") == "" {
+    path, _ := application.CleanSchemaPath(files[0])
+    for _, file := range bundle.Manifest.Files {
+      if file.Path == path {
+        w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+        w.Header().Set("ETag", `"sha256:`+file.SHA256+`"`)
+        w.WriteHeader(http.StatusOK)
+        _, _ = w.Write(bundle.Files[path])
+        return
+      }
+    }
+  }
+
+  contentTypes := map[application.ArchiveFormat]string{
+    application.ArchiveZip   : "application/zip",
+    application.ArchiveTarGz : "application/gzip",
+    application.ArchiveTar   : "application/x-tar",
+  }
+  w.Header().Set("Content-Type", contentTypes[format])
+  w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+    "filename": fmt.Sprintf("%s-v%d.%s", bundle.Manifest.Subject, bundle.Manifest.Version, format),
+  }))
+  w.WriteHeader(http.StatusOK)
+  if err := application.WriteBundle(w, format, bundle); err != nil {
+    log.WithFields(log.Fields{
+      "subject" : bundle.Manifest.Subject,
+      "version" : bundle.Manifest.Version,
+    }).Errorf("failed to write schema bundle: %s", err.Error())
+  }
+}
+
+// GetDescriptors - [PROTECTED] Exports version {version} ("latest", a version
+// number or a semver tag) of the Subject named by {subject} as a binary
+// google.protobuf.FileDescriptorSet.
+//    - ?source_info=true :: Keeps comments and source spans.
+//    - ?imports=true     :: Includes every transitively imported file, well-known
+//                           types included, like protoc's --include_imports.
+func(s *SchemaHTTPHandler) GetDescriptors(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+
+  var opts domain.DescriptorOptions
+  query := r.URL.Query()
+  for name, dst := range map[string]*bool{
+    "source_info" : &opts.SourceInfo,
+    "imports"     : &opts.Imports,
+  }{
+    if v := query.Get(name); v != "" {
+      parsed, err := strconv.ParseBool(v)
+      if err != nil {
+        http.Error(w, name+" must be true or false", http.StatusBadRequest)
+        return
+      }
+      *dst = parsed
+    }
+  }
+
+  set, err := s.service.ExportDescriptors(
+    r.Context(),
+    claims.EntityID,
+    mux.Vars(r)["subject"],
+    mux.Vars(r)["version"],
+    opts,
+  )
+  if err != nil {
+    switch {
+    case errors.Is(err, repo.ErrDBSubjectNotFound),
+         errors.Is(err, application.ErrSchemaVersionNotFound):
+      http.Error(w, err.Error(), http.StatusNotFound)
+    case errors.Is(err, application.ErrDescriptorUnsupported),
+         errors.Is(err, domain.ErrInvalidBlobKey):
+      http.Error(w, err.Error(), http.StatusBadRequest)
+    default:
+      http.Error(w, "failed to export descriptors", http.StatusInternalServerError)
+    }
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/x-protobuf")
+  w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+    "filename": fmt.Sprintf("%s-v%d.binpb", set.Subject, set.Version),
+  }))
+  w.WriteHeader(http.StatusOK)
+  _, _ = w.Write(set.Data)
 }
 
 func(s *SchemaHTTPHandler) SyncSchemas(w http.ResponseWriter, r *http.Request) {
@@ -91,6 +987,95 @@ func(s *SchemaHTTPHandler) ListSchemas(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// Validate - [PROTECTED] Recompiles a stored version of the Subject named by
+// {id}, returning every Diagnostic found. The latest version is validated
+// unless a ?version= is given.
 func(s *SchemaHTTPHandler) Validate(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+
+  version := 0
+  if v := r.URL.Query().Get("version"); v != "" {
+    n, err := strconv.Atoi(v)
+    if err != nil || n < 1 {
+      http.Error(w, "version must be a positive integer", http.StatusBadRequest)
+      return
+    }
+    version = n
+  }
+
+  result, err := s.service.ValidateSchema(
+    r.Context(),
+    claims.EntityID,
+    mux.Vars(r)["id"],
+    version,
+  )
+  if err != nil {
+    switch {
+    case errors.Is(err, repo.ErrDBSubjectNotFound),
+         errors.Is(err, application.ErrSchemaVersionNotFound):
+      http.Error(w, err.Error(), http.StatusNotFound)
+    default:
+      http.Error(w, "failed to validate schema", http.StatusInternalServerError)
+    }
+    return
+  }
+
+  utils.WriteJson(w, http.StatusOK, result)
+}
+
+// AnalyzeImpact - [PROTECTED] Returns every element, Service and Package of
+// the caller's Entity that transitively depends on the {kind} ("message",
+// "enum", "field" or "method") named by its fully-qualified {name}. The walk
+// can be limited with ?depth=.
+func(s *SchemaHTTPHandler) AnalyzeImpact(w http.ResponseWriter, r *http.Request) {
+  claims, ok := r.Context().Value(middleware.ClaimsKey).(*jwt.AuthClaims)
+  if !ok {
+    http.Error(w, "missing claims in context", http.StatusUnauthorized)
+    return
+  }
+
+  label, err := domain.ParseImpactKind(mux.Vars(r)["kind"])
+  if err != nil {
+    http.Error(w, err.Error(), http.StatusBadRequest)
+    return
+  }
+  depth := 0
+  if d := r.URL.Query().Get("depth"); d != "" {
+    n, err := strconv.Atoi(d)
+    if err != nil || n < 1 {
+      http.Error(w, "depth must be a positive integer", http.StatusBadRequest)
+      return
+    }
+    depth = n
+  }
+
+  report, err := s.service.AnalyzeImpact(
+    r.Context(),
+    claims.EntityID,
+    domain.NodeRef{ Label: label, Key: mux.Vars(r)["name"] },
+    depth,
+  )
+  if err != nil {
+    switch {
+    case errors.Is(err, repo.ErrGraphDBNotFound),
+         errors.Is(err, domain.ErrImpactTargetNotFound):
+      http.Error(w, err.Error(), http.StatusNotFound)
+    case errors.Is(err, domain.ErrUnknownImpactKind):
+      http.Error(w, err.Error(), http.StatusBadRequest)
+    default:
+      http.Error(w, "failed to analyze impact", http.StatusInternalServerError)
+    }
+    return
+  }
+
+  utils.WriteJson(w, http.StatusOK, report)
+}
 
+type diagnosticsBody struct {
+  Error       string              `json:"error"`
+  Diagnostics []domain.Diagnostic `json:"diagnostics"`
 }
@@ -14,8 +14,27 @@ var (
   ErrBlobDBDownloadFailed       BlobErr = errors.New("failed to download file from blob storage")
   ErrBlobDBDeleteFailed         BlobErr = errors.New("failed to delete file from blob storage")
   ErrBlobDBIOError              BlobErr = errors.New("failed to read downloaded file from blob storage")
+  ErrBlobDBNotFound             BlobErr = errors.New("queried file doesn't exist in blob storage")
+  ErrBlobDBDigestMismatch       BlobErr = errors.New("stored blob doesn't match its digest")
+  ErrBlobDBObjectExists         BlobErr = errors.New("refusing to overwrite a stored file")
+  ErrBlobDBPresignFailed        BlobErr = errors.New("failed to generate presigned url")
 
   ErrPGSQLConfigFailed            PgSQLErr = errors.New("failed to parse schema metadata DB config")
   ErrDBFailedCreation          PgSQLErr = errors.New("failed to create sql db pool")
   ErrDBFailedPing              PgSQLErr = errors.New("failed to ping newly created sql db")
+  ErrDBFailedToQuery           PgSQLErr = errors.New("failed to query schema metadata")
+  ErrDBFailedToInsert          PgSQLErr = errors.New("failed to insert schema metadata")
+  ErrDBFailedToUpdate          PgSQLErr = errors.New("failed to update schema metadata")
+  ErrDBFailedToBeginTX         PgSQLErr = errors.New("failed to begin schema metadata transaction")
+  ErrDBSubjectNotFound         PgSQLErr = errors.New("queried subject doesn't exist")
+  ErrDBSchemaVersionExists     PgSQLErr = errors.New("schema version already exists for subject")
+  ErrDBSchemaVersionNotFound   PgSQLErr = errors.New("queried schema version doesn't exist")
+  ErrDBStagedUploadNotFound    PgSQLErr = errors.New("queried staged upload doesn't exist")
+  ErrDBPolicyNotFound          PgSQLErr = errors.New("queried policy doesn't exist")
+
+  ErrGraphDBInit               GraphErr = errors.New("failed to initialize neo4j driver")
+  ErrGraphDBConnect            GraphErr = errors.New("failed to verify neo4j connectivity")
+  ErrGraphDBWriteFailed        GraphErr = errors.New("failed to write schema graph")
+  ErrGraphDBReadFailed         GraphErr = errors.New("failed to read schema graph")
+  ErrGraphDBNotFound           GraphErr = errors.New("queried graph node doesn't exist")
 )
@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Record defines a single row returned by a Cypher query, keyed by column name.
+type Record map[string]any
+
+// Tx defines the subset of a Neo4j transaction our repository relies on.
+type Tx interface {
+  // Run - Executes a Cypher query and collects every returned Record.
+  Run(ctx context.Context, query string, params map[string]any)( []Record, error )
+}
+
+// Driver defines the subset of a Neo4j driver our repository relies on. Kept
+// narrow so that tests can swap in an in-process fake.
+type Driver interface {
+  // ExecuteWrite - Runs 'work' inside a single write transaction, committing
+  // when work succeeds and rolling back otherwise.
+  ExecuteWrite(ctx context.Context, work func(tx Tx) error) error
+  // ExecuteRead - Runs 'work' inside a single read transaction.
+  ExecuteRead(ctx context.Context, work func(tx Tx) error) error
+
+  Close(ctx context.Context) error
+}
+
+// neo4jDriver - Adapts neo4j.DriverWithContext to our Driver interface.
+type neo4jDriver struct {
+  driver neo4j.DriverWithContext
+}
+
+// neo4jTx - Adapts neo4j.ExplicitTransaction to our Tx interface.
+type neo4jTx struct {
+  tx neo4j.ExplicitTransaction
+}
+
+func(d *neo4jDriver) ExecuteWrite(
+  ctx  context.Context,
+  work func(tx Tx) error,
+) error {
+  return d.execute(ctx, neo4j.AccessModeWrite, work)
+}
+
+func(d *neo4jDriver) ExecuteRead(
+  ctx  context.Context,
+  work func(tx Tx) error,
+) error {
+  return d.execute(ctx, neo4j.AccessModeRead, work)
+}
+
+// execute - Runs 'work' in an explicit transaction. The driver's own managed
+// transaction retries are bypassed, since Neo4j applies its own retry policy.
+func(d *neo4jDriver) execute(
+  ctx  context.Context,
+  mode neo4j.AccessMode,
+  work func(tx Tx) error,
+) error {
+  session := d.driver.NewSession(ctx, neo4j.SessionConfig{ AccessMode: mode })
+  defer session.Close(ctx)
+
+  tx, err := session.BeginTransaction(ctx)
+  if err != nil {
+    return err
+  }
+  defer tx.Close(ctx)
+
+  if err := work(&neo4jTx{ tx }); err != nil {
+    _ = tx.Rollback(ctx)
+    return err
+  }
+
+  return tx.Commit(ctx)
+}
+
+func(d *neo4jDriver) Close(ctx context.Context) error {
+  return d.driver.Close(ctx)
+}
+
+func(t *neo4jTx) Run(
+  ctx    context.Context,
+  query  string,
+  params map[string]any,
+)( []Record, error ){
+  result, err := t.tx.Run(ctx, query, params)
+  if err != nil {
+    return nil, err
+  }
+
+  records, err := result.Collect(ctx)
+  if err != nil {
+    return nil, err
+  }
+
+  rows := make([]Record, 0, len(records))
+  for _, record := range records {
+    rows = append(rows, Record(record.AsMap()))
+  }
+  return rows, nil
+}
@@ -1,7 +1,488 @@
 package graph
 
-import "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/repository"
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
+	"github.com/TylerAldrich814/Fidicus/internal/shared/utils"
+)
+
+// RetryPolicy defines how many times, and how patiently, a transaction that
+// failed with a transient Neo4j error is retried.
+type RetryPolicy struct {
+  MaxAttempts  int
+  InitialDelay time.Duration
+  MaxDelay     time.Duration
+}
+
+// DefaultRetryPolicy - Used by NewNeo4j.
+var DefaultRetryPolicy = RetryPolicy{
+  MaxAttempts  : 5,
+  InitialDelay : 100 * time.Millisecond,
+  MaxDelay     : 2 * time.Second,
+}
+
+// Neo4j -- A Neo4j wrapper that implements our SchemaGraphRepository.
 type Neo4j struct {
-  neo4j.Session
+  driver Driver
+  retry  RetryPolicy
+}
+
+// NewNeo4j - Creates a new Neo4j driver, verifies it can reach the database,
+// and returns a newly created Neo4j repository instance.
+//
+// Potential Errors:
+//    - GraphErr.ErrGraphDBInit
+//    - GraphErr.ErrGraphDBConnect
+func NewNeo4j(
+  ctx   context.Context,
+  uri   string,
+  user  string,
+  passw string,
+)( *Neo4j, error ){
+  var pushLog = utils.NewLogHandlerFunc(
+    "NewNeo4j",
+    log.Fields{
+      "uri"  : uri,
+      "user" : user,
+    },
+  )
+
+  driver, err := neo4j.NewDriverWithContext(
+    uri,
+    neo4j.BasicAuth(user, passw, ""),
+  )
+  if err != nil {
+    pushLog(utils.LogErro, "failed to create neo4j driver: %s", err.Error())
+    return nil, repository.ErrGraphDBInit
+  }
+
+  if err := driver.VerifyConnectivity(ctx); err != nil {
+    pushLog(utils.LogErro, "failed to verify neo4j connectivity: %s", err.Error())
+    _ = driver.Close(ctx)
+    return nil, repository.ErrGraphDBConnect
+  }
+
+  return NewNeo4jWithDriver(
+    &neo4jDriver{ driver },
+    DefaultRetryPolicy,
+  ), nil
+}
+
+// NewNeo4jWithDriver - Creates a Neo4j repository on top of an existing Driver.
+func NewNeo4jWithDriver(
+  driver Driver,
+  retry  RetryPolicy,
+) *Neo4j {
+  if retry.MaxAttempts < 1 {
+    retry.MaxAttempts = 1
+  }
+  return &Neo4j{
+    driver : driver,
+    retry  : retry,
+  }
+}
+
+// WriteSchema - Writes every compiled statement of 'schema' into Neo4j, in
+// the order the Schema provides them so dependencies are written before their
+// dependents. Every statement runs within a single write transaction, so a
+// failed write leaves nothing behind; transient failures retry the whole
+// transaction.
+//
+// Potential Errors:
+//    - GraphErr.ErrGraphDBWriteFailed
+func(n *Neo4j) WriteSchema(
+  ctx    context.Context,
+  schema domain.Schema,
+) error {
+  var pushLog = utils.NewLogHandlerFunc(
+    "WriteSchema",
+    log.Fields{},
+  )
+
+  statements, err := schema.Cyphers()
+  if err != nil {
+    pushLog(utils.LogErro, "failed to get compiled cyphers: %s", err.Error())
+    return repository.ErrGraphDBWriteFailed
+  }
+
+  if err := n.withRetry(ctx, func() error {
+    return n.driver.ExecuteWrite(ctx, func(tx Tx) error {
+      for i, statement := range statements {
+        if _, err := tx.Run(ctx, statement.Query, statement.Params); err != nil {
+          return fmt.Errorf("statement %d of %d: %w", i+1, len(statements), err)
+        }
+      }
+      return nil
+    })
+  }); err != nil {
+    pushLog(utils.LogErro, "failed to write schema: %s", err.Error())
+    return repository.ErrGraphDBWriteFailed
+  }
+
+  return nil
+}
+
+// GetPackage - Returns one of the Entity's stored Packages by its
+// fully-qualified name, see domain.NamespacedKey.
+//
+// Potential Errors:
+//    - GraphErr.ErrGraphDBReadFailed
+//    - GraphErr.ErrGraphDBNotFound
+func(n *Neo4j) GetPackage(
+  ctx      context.Context,
+  entityID users.EntityID,
+  pkg      string,
+)( *domain.PackageNode, error ){
+  records, err := n.read(
+    ctx,
+    "GetPackage",
+    `MATCH (p:Package {key: $package})
+     RETURN p.name AS name, p.package AS package, p.version AS version,
+            p.stability AS stability, p.syntax AS syntax, p.imports AS imports
+     LIMIT 1`,
+    map[string]any{ "package": domain.NamespacedKey(entityID, pkg) },
+  )
+  if err != nil {
+    return nil, err
+  }
+  if len(records) == 0 {
+    return nil, repository.ErrGraphDBNotFound
+  }
+
+  return packageNode(records[0]), nil
+}
+
+// GetLatestPackage - Returns the newest version of one of the Entity's
+// unversioned Package names, only considering keys under its
+// domain.GraphNamespace. Versions are ordered by major, minor and patch,
+// then by stability, so v2alpha1 sorts after v1 but before v2.
+//
+// Potential Errors:
+//    - GraphErr.ErrGraphDBReadFailed
+//    - GraphErr.ErrGraphDBNotFound
+func(n *Neo4j) GetLatestPackage(
+  ctx        context.Context,
+  entityID   users.EntityID,
+  name       string,
+  stableOnly bool,
+)( *domain.PackageNode, error ){
+  records, err := n.read(
+    ctx,
+    "GetLatestPackage",
+    `MATCH (p:Package {name: $name})
+     WHERE NOT coalesce(p.shared, false)
+       AND CASE $namespace
+             WHEN '' THEN NOT p.key CONTAINS '/'
+             ELSE p.key STARTS WITH $namespace + '/'
+           END
+       AND (NOT $stableOnly OR p.stability = 'stable')
+     RETURN p.name AS name, p.package AS package, p.version AS version,
+            p.stability AS stability, p.syntax AS syntax, p.imports AS imports
+     ORDER BY p.versionMajor DESC, p.versionMinor DESC, p.versionPatch DESC,
+              p.stabilityRank DESC, p.prerelease DESC
+     LIMIT 1`,
+    map[string]any{
+      "name"       : name,
+      "namespace"  : domain.GraphNamespace(entityID),
+      "stableOnly" : stableOnly,
+    },
+  )
+  if err != nil {
+    return nil, err
+  }
+  if len(records) == 0 {
+    return nil, repository.ErrGraphDBNotFound
+  }
+
+  return packageNode(records[0]), nil
+}
+
+func packageNode(r Record) *domain.PackageNode {
+  return &domain.PackageNode{
+    Name      : r.String("name"),
+    Package   : r.String("package"),
+    Version   : r.String("version"),
+    Stability : domain.Stability(r.String("stability")),
+    Syntax    : r.String("syntax"),
+    Imports   : r.Strings("imports"),
+  }
+}
+
+// ListMessages - Returns every Message defined in one of the Entity's
+// Packages, ordered by name.
+//
+// Potential Errors:
+//    - GraphErr.ErrGraphDBReadFailed
+func(n *Neo4j) ListMessages(
+  ctx      context.Context,
+  entityID users.EntityID,
+  pkg      string,
+)( []domain.MessageNode, error ){
+  records, err := n.read(
+    ctx,
+    "ListMessages",
+    `MATCH (m:Message)-[:DEFINED_IN]->(:Package {key: $package})
+     RETURN m.name AS name, m.package AS package, m.version AS version, m.deprecated AS deprecated
+     ORDER BY m.name`,
+    map[string]any{ "package": domain.NamespacedKey(entityID, pkg) },
+  )
+  if err != nil {
+    return nil, err
+  }
+
+  messages := make([]domain.MessageNode, 0, len(records))
+  for _, r := range records {
+    messages = append(messages, domain.MessageNode{
+      Name       : r.String("name"),
+      Package    : r.String("package"),
+      Version    : r.String("version"),
+      Deprecated : r.Bool("deprecated"),
+    })
+  }
+  return messages, nil
+}
+
+// GetServiceMethods - Returns every RPC Method of one of the Entity's
+// Services, ordered by name.
+//
+// Potential Errors:
+//    - GraphErr.ErrGraphDBReadFailed
+func(n *Neo4j) GetServiceMethods(
+  ctx      context.Context,
+  entityID users.EntityID,
+  pkg      string,
+  service  string,
+)( []domain.MethodNode, error ){
+  records, err := n.read(
+    ctx,
+    "GetServiceMethods",
+    `MATCH (s:Service {key: $service})
+     MATCH (m:Method)-[:RPC_METHOD]->(s)
+     OPTIONAL MATCH (in:Message)-[:INPUT]->(m)
+     OPTIONAL MATCH (out:Message)-[:OUTPUT]->(m)
+     RETURN m.name AS name, s.name AS service, in.name AS input, out.name AS output,
+            m.clientStreaming AS clientStreaming, m.serverStreaming AS serverStreaming,
+            m.idempotency AS idempotency, m.deprecated AS deprecated, m.options AS options
+     ORDER BY m.name`,
+    map[string]any{ "service": domain.NamespacedKey(entityID, pkg+"."+service) },
+  )
+  if err != nil {
+    return nil, err
+  }
+
+  methods := make([]domain.MethodNode, 0, len(records))
+  for _, r := range records {
+    methods = append(methods, domain.MethodNode{
+      Name            : r.String("name"),
+      Service         : r.String("service"),
+      Input           : r.String("input"),
+      Output          : r.String("output"),
+      ClientStreaming : r.Bool("clientStreaming"),
+      ServerStreaming : r.Bool("serverStreaming"),
+      Idempotency     : r.String("idempotency"),
+      Deprecated      : r.Bool("deprecated"),
+      Options         : r.Strings("options"),
+    })
+  }
+  return methods, nil
+}
+
+// impactLabels -- Node labels an impact analysis can pass through. Labels are
+// written into the expansion query, so only these are ever queried.
+var impactLabels = map[domain.NodeLabel]bool{
+  domain.LabelPackage   : true,
+  domain.LabelMessage   : true,
+  domain.LabelParameter : true,
+  domain.LabelEnum      : true,
+  domain.LabelService   : true,
+  domain.LabelMethod    : true,
+  domain.LabelExtension : true,
+}
+
+// AnalyzeImpact - Returns every stored node that transitively depends on
+// 'target', limited to shared nodes and those under 'namespace', see
+// domain.AnalyzeImpact. Each level of the walk is read with one query per
+// node label in the frontier.
+//
+// Potential Errors:
+//    - GraphErr.ErrGraphDBReadFailed
+//    - GraphErr.ErrGraphDBNotFound :: Also matches domain.ErrImpactTargetNotFound.
+func(n *Neo4j) AnalyzeImpact(
+  ctx       context.Context,
+  namespace string,
+  target    domain.NodeRef,
+  maxDepth  int,
+)( *domain.ImpactReport, error ){
+  if !impactLabels[target.Label] {
+    return nil, repository.ErrGraphDBNotFound
+  }
+  records, err := n.read(
+    ctx,
+    "AnalyzeImpact",
+    fmt.Sprintf(`MATCH (n:%s {key: $key}) RETURN n.key AS key LIMIT 1`, target.Label),
+    map[string]any{ "key": target.Key },
+  )
+  if err != nil {
+    return nil, err
+  }
+  if len(records) == 0 {
+    return nil, fmt.Errorf("%w: %w", repository.ErrGraphDBNotFound, domain.ErrImpactTargetNotFound)
+  }
+
+  edges := []string{}
+  for _, edge := range domain.ImpactEdges() {
+    edges = append(edges, string(edge))
+  }
+  relationships := strings.Join(edges, "|")
+
+  return domain.AnalyzeImpact(ctx, namespace, target, maxDepth, func(
+    ctx      context.Context,
+    frontier []domain.NodeRef,
+  )( []domain.Adjacent, error ){
+    byLabel := map[domain.NodeLabel][]string{}
+    labels  := []domain.NodeLabel{}
+    for _, node := range frontier {
+      if _, ok := byLabel[node.Label]; !ok {
+        labels = append(labels, node.Label)
+      }
+      byLabel[node.Label] = append(byLabel[node.Label], node.Key)
+    }
+
+    adjacent := []domain.Adjacent{}
+    for _, label := range labels {
+      if !impactLabels[label] {
+        continue
+      }
+      records, err := n.read(
+        ctx,
+        "AnalyzeImpact",
+        fmt.Sprintf(
+          `MATCH (n:%s) WHERE n.key IN $keys
+           MATCH (n)-[r:%s]-(m)
+           RETURN n.key AS from, type(r) AS edge, startNode(r) = n AS outgoing,
+                  labels(m)[0] AS label, m.key AS key`,
+          label,
+          relationships,
+        ),
+        map[string]any{ "keys": byLabel[label] },
+      )
+      if err != nil {
+        return nil, err
+      }
+      for _, r := range records {
+        adjacent = append(adjacent, domain.Adjacent{
+          From     : domain.NodeRef{ Label: label, Key: r.String("from") },
+          Edge     : domain.EdgeType(r.String("edge")),
+          Outgoing : r.Bool("outgoing"),
+          To       : domain.NodeRef{
+            Label : domain.NodeLabel(r.String("label")),
+            Key   : r.String("key"),
+          },
+        })
+      }
+    }
+    return adjacent, nil
+  })
+}
+
+// Shutdown - Closes the underlying Neo4j driver.
+func(n *Neo4j) Shutdown() error {
+  return n.driver.Close(context.Background())
+}
+
+// read - Runs a single read query with retries.
+func(n *Neo4j) read(
+  ctx    context.Context,
+  caller string,
+  query  string,
+  params map[string]any,
+)( []Record, error ){
+  var records []Record
+  if err := n.withRetry(ctx, func() error {
+    return n.driver.ExecuteRead(ctx, func(tx Tx) error {
+      var err error
+      records, err = tx.Run(ctx, query, params)
+      return err
+    })
+  }); err != nil {
+    utils.NewLogHandlerFunc(
+      caller,
+      log.Fields{ "params": params },
+    )(utils.LogErro, "failed to read from neo4j: %s", err.Error())
+    return nil, repository.ErrGraphDBReadFailed
+  }
+  return records, nil
+}
+
+// withRetry - Runs 'op', retrying with an exponential backoff for as long as
+// it fails with an error Neo4j marks as retryable.
+func(n *Neo4j) withRetry(
+  ctx context.Context,
+  op  func() error,
+) error {
+  delay := n.retry.InitialDelay
+  var err error
+  for attempt := 1; attempt <= n.retry.MaxAttempts; attempt++ {
+    if err = op(); err == nil || !neo4j.IsRetryable(err) {
+      return err
+    }
+    if attempt == n.retry.MaxAttempts {
+      break
+    }
+
+    select {
+    case <-ctx.Done():
+      return ctx.Err()
+    case <-time.After(delay):
+    }
+    delay *= 2
+    if n.retry.MaxDelay > 0 && delay > n.retry.MaxDelay {
+      delay = n.retry.MaxDelay
+    }
+  }
+  return err
+}
+
+// String - Returns the string stored under 'key', or "" when missing.
+func(r Record) String(key string) string {
+  s, _ := r[key].(string)
+  return s
+}
+
+// Bool - Returns the bool stored under 'key', or false when missing.
+func(r Record) Bool(key string) bool {
+  b, _ := r[key].(bool)
+  return b
+}
+
+// Strings - Returns the list stored under 'key'. Comma separated strings are
+// split into their parts.
+func(r Record) Strings(key string) []string {
+  values := []string{}
+  switch v := r[key].(type) {
+  case []any:
+    for _, item := range v {
+      if s, ok := item.(string); ok {
+        values = append(values, s)
+      }
+    }
+  case []string:
+    values = append(values, v...)
+  case string:
+    for _, s := range strings.Split(v, ",") {
+      if s = strings.TrimSpace(s); s != "" {
+        values = append(values, s)
+      }
+    }
+  }
+  return values
 }
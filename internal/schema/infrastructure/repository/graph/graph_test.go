@@ -0,0 +1,315 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/repository"
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
+)
+
+// fakeDriver - An in-process Driver. Queries present in 'failures' fail with
+// the queued errors before succeeding; 'results' returns rows for every query
+// containing its key. 'params' holds the parameters of the last query run.
+type fakeDriver struct {
+  committed []string
+  failures  map[string][]error
+  results   map[string][]Record
+  attempts  map[string]int
+  params    map[string]any
+  closed    bool
+}
+
+type fakeTx struct {
+  driver  *fakeDriver
+  pending []string
+}
+
+func newFakeDriver() *fakeDriver {
+  return &fakeDriver{
+    failures : map[string][]error{},
+    results  : map[string][]Record{},
+    attempts : map[string]int{},
+  }
+}
+
+func(d *fakeDriver) ExecuteWrite(ctx context.Context, work func(tx Tx) error) error {
+  tx := &fakeTx{ driver: d }
+  if err := work(tx); err != nil {
+    return err
+  }
+  d.committed = append(d.committed, tx.pending...)
+  return nil
+}
+
+func(d *fakeDriver) ExecuteRead(ctx context.Context, work func(tx Tx) error) error {
+  return work(&fakeTx{ driver: d })
+}
+
+func(d *fakeDriver) Close(ctx context.Context) error {
+  d.closed = true
+  return nil
+}
+
+func(t *fakeTx) Run(ctx context.Context, query string, params map[string]any)( []Record, error ){
+  t.driver.attempts[query]++
+  t.driver.params = params
+  if errs := t.driver.failures[query]; len(errs) != 0 {
+    t.driver.failures[query] = errs[1:]
+    return nil, errs[0]
+  }
+  t.pending = append(t.pending, query)
+  for key, rows := range t.driver.results {
+    if strings.Contains(query, key) {
+      return rows, nil
+    }
+  }
+  return nil, nil
+}
+
+type fakeSchema struct {
+  statements []domain.CypherStatement
+}
+
+func(s *fakeSchema) ParseSchemaFiles(ctx context.Context) error { return nil }
+func(s *fakeSchema) Graph()( *domain.SchemaGraph, error ){ return &domain.SchemaGraph{}, nil }
+func(s *fakeSchema) Cyphers()( []domain.CypherStatement, error ){ return s.statements, nil }
+
+var testRetry = RetryPolicy{
+  MaxAttempts  : 3,
+  InitialDelay : time.Millisecond,
+  MaxDelay     : time.Millisecond,
+}
+
+func transientErr() error {
+  return &neo4j.Neo4jError{ Code: "Neo.TransientError.Transaction.DeadlockDetected" }
+}
+
+func TestWriteSchema(t *testing.T) {
+  ctx := context.Background()
+  schema := &fakeSchema{[]domain.CypherStatement{
+    { Query: "common" },
+    { Query: "user" },
+    { Query: "third" },
+  }}
+
+  t.Run("writes in dependency order, retrying transient errors", func(t *testing.T){
+    driver := newFakeDriver()
+    driver.failures["user"] = []error{ transientErr(), transientErr() }
+
+    repo := NewNeo4jWithDriver(driver, testRetry)
+    assert.NoError(t, repo.WriteSchema(ctx, schema))
+    assert.Equal(t, []string{ "common", "user", "third" }, driver.committed)
+    assert.Equal(t, 3, driver.attempts["user"])
+    // ->> Every retry reruns the whole transaction.
+    assert.Equal(t, 3, driver.attempts["common"])
+  })
+
+  t.Run("commits nothing once retries are exhausted", func(t *testing.T){
+    driver := newFakeDriver()
+    driver.failures["user"] = []error{ transientErr(), transientErr(), transientErr() }
+
+    repo := NewNeo4jWithDriver(driver, testRetry)
+    assert.ErrorIs(t, repo.WriteSchema(ctx, schema), repository.ErrGraphDBWriteFailed)
+    assert.Empty(t, driver.committed)
+  })
+
+  t.Run("does not retry permanent errors", func(t *testing.T){
+    driver := newFakeDriver()
+    driver.failures["common"] = []error{ errors.New("syntax error") }
+
+    repo := NewNeo4jWithDriver(driver, testRetry)
+    assert.ErrorIs(t, repo.WriteSchema(ctx, schema), repository.ErrGraphDBWriteFailed)
+    assert.Equal(t, 1, driver.attempts["common"])
+    assert.Empty(t, driver.committed)
+  })
+}
+
+func TestReadGraph(t *testing.T) {
+  ctx := context.Background()
+  driver := newFakeDriver()
+  repo := NewNeo4jWithDriver(driver, testRetry)
+  entity := users.NewEntityID()
+
+  _, err := repo.GetPackage(ctx, entity, "missing.v1")
+  assert.ErrorIs(t, err, repository.ErrGraphDBNotFound)
+
+  driver.results = map[string][]Record{
+    "MATCH (p:Package": {{
+      "name"    : "user",
+      "package" : "user.v1alpha",
+      "syntax"  : "proto3",
+      "imports" : "common.v1alpha, other.v1alpha",
+    }},
+    "MATCH (m:Message)": {
+      { "name": "Address", "package": "user", "version": "v1alpha", "deprecated": false },
+      { "name": "User",    "package": "user", "version": "v1alpha", "deprecated": true  },
+    },
+    "MATCH (s:Service": {
+      {
+        "name"            : "GetUser",
+        "service"         : "UserService",
+        "input"           : "GetUserRequest",
+        "output"          : "User",
+        "clientStreaming" : false,
+        "serverStreaming" : false,
+        "idempotency"     : "NO_SIDE_EFFECTS",
+        "deprecated"      : false,
+        "options"         : []any{ "acme.auth.scope=users.read" },
+      },
+      {
+        "name"            : "WatchUsers",
+        "service"         : "UserService",
+        "input"           : "WatchUsersRequest",
+        "output"          : "User",
+        "clientStreaming" : false,
+        "serverStreaming" : true,
+        "idempotency"     : "IDEMPOTENCY_UNKNOWN",
+        "deprecated"      : true,
+        "options"         : []any{},
+      },
+    },
+  }
+
+  // ->> Names are only looked up among the Entity's own definitions.
+  pkg, err := repo.GetPackage(ctx, entity, "user.v1alpha")
+  assert.NoError(t, err)
+  assert.Equal(t, domain.NamespacedKey(entity, "user.v1alpha"), driver.params["package"])
+  assert.Equal(t, &domain.PackageNode{
+    Name    : "user",
+    Package : "user.v1alpha",
+    Syntax  : "proto3",
+    Imports : []string{ "common.v1alpha", "other.v1alpha" },
+  }, pkg)
+
+  messages, err := repo.ListMessages(ctx, entity, "user.v1alpha")
+  assert.NoError(t, err)
+  assert.Equal(t, domain.NamespacedKey(entity, "user.v1alpha"), driver.params["package"])
+  assert.Equal(t, []domain.MessageNode{
+    { Name: "Address", Package: "user", Version: "v1alpha" },
+    { Name: "User",    Package: "user", Version: "v1alpha", Deprecated: true },
+  }, messages)
+
+  methods, err := repo.GetServiceMethods(ctx, entity, "user.v1alpha", "UserService")
+  assert.NoError(t, err)
+  assert.Equal(t, domain.NamespacedKey(entity, "user.v1alpha.UserService"), driver.params["service"])
+  assert.Equal(t, []domain.MethodNode{
+    {
+      Name        : "GetUser",
+      Service     : "UserService",
+      Input       : "GetUserRequest",
+      Output      : "User",
+      Idempotency : "NO_SIDE_EFFECTS",
+      Options     : []string{ "acme.auth.scope=users.read" },
+    },
+    {
+      Name            : "WatchUsers",
+      Service         : "UserService",
+      Input           : "WatchUsersRequest",
+      Output          : "User",
+      ServerStreaming : true,
+      Idempotency     : "IDEMPOTENCY_UNKNOWN",
+      Deprecated      : true,
+      Options         : []string{},
+    },
+  }, methods)
+
+  assert.NoError(t, repo.Shutdown())
+  assert.True(t, driver.closed)
+}
+
+func TestGetLatestPackage(t *testing.T) {
+  ctx := context.Background()
+  driver := newFakeDriver()
+  repo := NewNeo4jWithDriver(driver, testRetry)
+  entity := users.NewEntityID()
+
+  _, err := repo.GetLatestPackage(ctx, entity, "user", true)
+  assert.ErrorIs(t, err, repository.ErrGraphDBNotFound)
+
+  driver.results["ORDER BY p.versionMajor DESC"] = []Record{{
+    "name"      : "user",
+    "package"   : "user.v2beta1",
+    "version"   : "v2beta1",
+    "stability" : "beta",
+    "syntax"    : "proto3",
+  }}
+
+  pkg, err := repo.GetLatestPackage(ctx, entity, "user", false)
+  assert.NoError(t, err)
+  assert.Equal(t, domain.GraphNamespace(entity), driver.params["namespace"])
+  assert.Equal(t, &domain.PackageNode{
+    Name      : "user",
+    Package   : "user.v2beta1",
+    Version   : "v2beta1",
+    Stability : domain.StabilityBeta,
+    Syntax    : "proto3",
+    Imports   : []string{},
+  }, pkg)
+}
+
+func TestAnalyzeImpact(t *testing.T) {
+  ctx := context.Background()
+  driver := newFakeDriver()
+  repo := NewNeo4jWithDriver(driver, testRetry)
+
+  money := domain.NodeRef{ Label: domain.LabelMessage, Key: "common.v1.Money" }
+  _, err := repo.AnalyzeImpact(ctx, "", money, 0)
+  assert.ErrorIs(t, err, repository.ErrGraphDBNotFound)
+
+  // ->> The fake ignores $keys, so every level sees every row of a label;
+  //     only rows leaving the current frontier are followed.
+  driver.results = map[string][]Record{
+    "MATCH (n:Message {key: $key})": {{ "key": "common.v1.Money" }},
+    "MATCH (n:Message) WHERE": {
+      { "from": "common.v1.Money", "edge": "USES_MSG_TYPE", "outgoing": false, "label": "Parameter", "key": "orders.v1.Order.total" },
+      { "from": "common.v1.Money", "edge": "USES_MSG_TYPE", "outgoing": true,  "label": "Message",   "key": "common.v1.Amount"      },
+      { "from": "common.v1.Money", "edge": "DEFINED_IN",    "outgoing": true,  "label": "Package",   "key": "common.v1"             },
+      { "from": "orders.v1.Order", "edge": "OUTPUT",        "outgoing": true,  "label": "Method",    "key": "orders.v1.Orders.Get"  },
+    },
+    "MATCH (n:Parameter) WHERE": {
+      { "from": "orders.v1.Order.total", "edge": "HAS_PARAMETER", "outgoing": false, "label": "Message", "key": "orders.v1.Order" },
+    },
+    "MATCH (n:Method) WHERE": {
+      { "from": "orders.v1.Orders.Get", "edge": "RPC_METHOD", "outgoing": true, "label": "Service", "key": "orders.v1.Orders" },
+    },
+  }
+
+  report, err := repo.AnalyzeImpact(ctx, "", money, 0)
+  assert.NoError(t, err)
+  assert.Equal(t, money, report.Target)
+  assert.Equal(t, []domain.ImpactedNode{
+    {
+      NodeRef : domain.NodeRef{ Label: domain.LabelPackage, Key: "common.v1" },
+      Depth   : 1,
+      Path    : []domain.ImpactStep{
+        { Edge: domain.EdgeDefinedIn, Node: domain.NodeRef{ Label: domain.LabelPackage, Key: "common.v1" } },
+      },
+    },
+  }, report.Packages)
+
+  elements := []string{}
+  for _, n := range report.Elements {
+    elements = append(elements, n.Key)
+  }
+  assert.Equal(t, []string{
+    "orders.v1.Order.total",
+    "orders.v1.Order",
+    "orders.v1.Orders.Get",
+  }, elements)
+  assert.Len(t, report.Services, 1)
+  assert.Equal(t, 4, report.Services[0].Depth)
+
+  // ->> Depth limits stop the walk early.
+  report, err = repo.AnalyzeImpact(ctx, "", money, 2)
+  assert.NoError(t, err)
+  assert.Len(t, report.Elements, 2)
+  assert.Empty(t, report.Services)
+}
@@ -2,15 +2,21 @@ package pgsql
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
-	// "github.com/jackc/pgx/v5/pgconn"
-  // "github.com/jackc/pgx/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	log "github.com/sirupsen/logrus"
 
   "github.com/TylerAldrich814/Fidicus/internal/shared/utils"
 	"github.com/TylerAldrich814/Fidicus/internal/shared/role"
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
 	repo "github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/repository"
 )
 
@@ -81,3 +87,922 @@ func(s *SchemaPGSQL) CreateAccessRole(
 
   return nil
 }
+
+// GetCompatibility - Returns an Entity's default CompatibilityMode. Entities that
+// never configured one fall back to domain.DefaultCompatibility.
+//
+// Potential Errors:
+//   - ErrDBFailedToQuery
+func(s *SchemaPGSQL) GetCompatibility(
+  ctx      context.Context,
+  entityID users.EntityID,
+)( domain.CompatibilityMode, error ){
+  var mode string
+  err := s.db.QueryRow(
+    ctx,
+    `SELECT compatibility FROM schema_settings WHERE entity_id = $1`,
+    uuid.UUID(entityID),
+  ).Scan(&mode)
+  if errors.Is(err, pgx.ErrNoRows) {
+    return domain.DefaultCompatibility, nil
+  }
+  if err != nil {
+    utils.NewLogHandlerFunc(
+      "GetCompatibility",
+      log.Fields{ "entity_id": entityID.String() },
+    )(utils.LogErro, "failed to query schema settings: %s", err.Error())
+    return "", repo.ErrDBFailedToQuery
+  }
+
+  return domain.CompatibilityMode(mode), nil
+}
+
+// SetCompatibility - Creates or updates an Entity's default CompatibilityMode.
+//
+// Potential Errors:
+//   - ErrDBFailedToUpdate
+func(s *SchemaPGSQL) SetCompatibility(
+  ctx      context.Context,
+  entityID users.EntityID,
+  mode     domain.CompatibilityMode,
+) error {
+  if _, err := s.db.Exec(
+    ctx,
+    `INSERT INTO schema_settings (entity_id, compatibility)
+     VALUES ($1, $2)
+     ON CONFLICT (entity_id) DO UPDATE
+     SET compatibility = EXCLUDED.compatibility,
+         updated_at    = CURRENT_TIMESTAMP`,
+    uuid.UUID(entityID),
+    string(mode),
+  ); err != nil {
+    utils.NewLogHandlerFunc(
+      "SetCompatibility",
+      log.Fields{
+        "entity_id"     : entityID.String(),
+        "compatibility" : mode,
+      },
+    )(utils.LogErro, "failed to upsert schema settings: %s", err.Error())
+    return repo.ErrDBFailedToUpdate
+  }
+
+  return nil
+}
+
+// GetVersioning - Returns an Entity's VersioningScheme. Entities that never
+// configured one fall back to domain.DefaultVersioning.
+//
+// Potential Errors:
+//   - ErrDBFailedToQuery
+func(s *SchemaPGSQL) GetVersioning(
+  ctx      context.Context,
+  entityID users.EntityID,
+)( domain.VersioningScheme, error ){
+  var scheme string
+  err := s.db.QueryRow(
+    ctx,
+    `SELECT versioning FROM schema_settings WHERE entity_id = $1`,
+    uuid.UUID(entityID),
+  ).Scan(&scheme)
+  if errors.Is(err, pgx.ErrNoRows) {
+    return domain.DefaultVersioning, nil
+  }
+  if err != nil {
+    utils.NewLogHandlerFunc(
+      "GetVersioning",
+      log.Fields{ "entity_id": entityID.String() },
+    )(utils.LogErro, "failed to query schema settings: %s", err.Error())
+    return "", repo.ErrDBFailedToQuery
+  }
+
+  return domain.VersioningScheme(scheme), nil
+}
+
+// SetVersioning - Creates or updates an Entity's VersioningScheme.
+//
+// Potential Errors:
+//   - ErrDBFailedToUpdate
+func(s *SchemaPGSQL) SetVersioning(
+  ctx      context.Context,
+  entityID users.EntityID,
+  scheme   domain.VersioningScheme,
+) error {
+  if _, err := s.db.Exec(
+    ctx,
+    `INSERT INTO schema_settings (entity_id, versioning)
+     VALUES ($1, $2)
+     ON CONFLICT (entity_id) DO UPDATE
+     SET versioning = EXCLUDED.versioning,
+         updated_at = CURRENT_TIMESTAMP`,
+    uuid.UUID(entityID),
+    string(scheme),
+  ); err != nil {
+    utils.NewLogHandlerFunc(
+      "SetVersioning",
+      log.Fields{
+        "entity_id"  : entityID.String(),
+        "versioning" : scheme,
+      },
+    )(utils.LogErro, "failed to upsert schema settings: %s", err.Error())
+    return repo.ErrDBFailedToUpdate
+  }
+
+  return nil
+}
+
+// GetLintConfig - Returns an Entity's LintConfig. Entities that never
+// configured one fall back to domain.DefaultLintConfig.
+//
+// Potential Errors:
+//   - ErrDBFailedToQuery
+func(s *SchemaPGSQL) GetLintConfig(
+  ctx      context.Context,
+  entityID users.EntityID,
+)( domain.LintConfig, error ){
+  var pushLog = utils.NewLogHandlerFunc(
+    "GetLintConfig",
+    log.Fields{ "entity_id": entityID.String() },
+  )
+
+  var raw []byte
+  err := s.db.QueryRow(
+    ctx,
+    `SELECT lint FROM schema_settings WHERE entity_id = $1`,
+    uuid.UUID(entityID),
+  ).Scan(&raw)
+  if errors.Is(err, pgx.ErrNoRows) {
+    return domain.DefaultLintConfig, nil
+  }
+  if err != nil {
+    pushLog(utils.LogErro, "failed to query schema settings: %s", err.Error())
+    return domain.LintConfig{}, repo.ErrDBFailedToQuery
+  }
+
+  var config domain.LintConfig
+  if err := json.Unmarshal(raw, &config); err != nil {
+    pushLog(utils.LogErro, "failed to decode lint config: %s", err.Error())
+    return domain.LintConfig{}, repo.ErrDBFailedToQuery
+  }
+  return config, nil
+}
+
+// SetLintConfig - Creates or updates an Entity's LintConfig.
+//
+// Potential Errors:
+//   - ErrDBFailedToUpdate
+func(s *SchemaPGSQL) SetLintConfig(
+  ctx      context.Context,
+  entityID users.EntityID,
+  config   domain.LintConfig,
+) error {
+  var pushLog = utils.NewLogHandlerFunc(
+    "SetLintConfig",
+    log.Fields{
+      "entity_id" : entityID.String(),
+      "rule_set"  : config.RuleSet,
+    },
+  )
+
+  raw, err := json.Marshal(config)
+  if err != nil {
+    pushLog(utils.LogErro, "failed to encode lint config: %s", err.Error())
+    return repo.ErrDBFailedToUpdate
+  }
+  if _, err := s.db.Exec(
+    ctx,
+    `INSERT INTO schema_settings (entity_id, lint)
+     VALUES ($1, $2)
+     ON CONFLICT (entity_id) DO UPDATE
+     SET lint       = EXCLUDED.lint,
+         updated_at = CURRENT_TIMESTAMP`,
+    uuid.UUID(entityID),
+    raw,
+  ); err != nil {
+    pushLog(utils.LogErro, "failed to upsert schema settings: %s", err.Error())
+    return repo.ErrDBFailedToUpdate
+  }
+
+  return nil
+}
+
+// ListPolicies - Returns every Policy of an Entity, ordered by name.
+//
+// Potential Errors:
+//   - ErrDBFailedToQuery
+func(s *SchemaPGSQL) ListPolicies(
+  ctx      context.Context,
+  entityID users.EntityID,
+)( []domain.Policy, error ){
+  var pushLog = utils.NewLogHandlerFunc(
+    "ListPolicies",
+    log.Fields{ "entity_id": entityID.String() },
+  )
+
+  rows, err := s.db.Query(
+    ctx,
+    `SELECT name, COALESCE(description, ''), target, packages, expression, severity
+     FROM schema_policies
+     WHERE entity_id = $1
+     ORDER BY name ASC`,
+    uuid.UUID(entityID),
+  )
+  if err != nil {
+    pushLog(utils.LogErro, "failed to query policies: %s", err.Error())
+    return nil, repo.ErrDBFailedToQuery
+  }
+  defer rows.Close()
+
+  policies := []domain.Policy{}
+  for rows.Next() {
+    var (
+      p        domain.Policy
+      target   string
+      severity string
+    )
+    if err := rows.Scan(
+      &p.Name,
+      &p.Description,
+      &target,
+      &p.Packages,
+      &p.Expression,
+      &severity,
+    ); err != nil {
+      pushLog(utils.LogErro, "failed to scan policy: %s", err.Error())
+      return nil, repo.ErrDBFailedToQuery
+    }
+    p.Target   = domain.PolicyTarget(target)
+    p.Severity = domain.Severity(severity)
+    policies = append(policies, p)
+  }
+  if err := rows.Err(); err != nil {
+    pushLog(utils.LogErro, "failed to iterate policies: %s", err.Error())
+    return nil, repo.ErrDBFailedToQuery
+  }
+
+  return policies, nil
+}
+
+// PutPolicy - Creates or replaces an Entity's Policy, by name.
+//
+// Potential Errors:
+//   - ErrDBFailedToUpdate
+func(s *SchemaPGSQL) PutPolicy(
+  ctx      context.Context,
+  entityID users.EntityID,
+  policy   domain.Policy,
+) error {
+  packages := policy.Packages
+  if packages == nil {
+    packages = []string{}
+  }
+  if _, err := s.db.Exec(
+    ctx,
+    `INSERT INTO schema_policies (
+       entity_id,
+       name,
+       description,
+       target,
+       packages,
+       expression,
+       severity
+     )
+     VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+     ON CONFLICT (entity_id, name) DO UPDATE
+     SET description = EXCLUDED.description,
+         target      = EXCLUDED.target,
+         packages    = EXCLUDED.packages,
+         expression  = EXCLUDED.expression,
+         severity    = EXCLUDED.severity,
+         updated_at  = CURRENT_TIMESTAMP`,
+    uuid.UUID(entityID),
+    policy.Name,
+    policy.Description,
+    string(policy.Target),
+    packages,
+    policy.Expression,
+    string(policy.Severity),
+  ); err != nil {
+    utils.NewLogHandlerFunc(
+      "PutPolicy",
+      log.Fields{
+        "entity_id" : entityID.String(),
+        "policy"    : policy.Name,
+      },
+    )(utils.LogErro, "failed to upsert policy: %s", err.Error())
+    return repo.ErrDBFailedToUpdate
+  }
+
+  return nil
+}
+
+// DeletePolicy - Removes an Entity's Policy by name.
+//
+// Potential Errors:
+//   - ErrDBPolicyNotFound
+//   - ErrDBFailedToUpdate
+func(s *SchemaPGSQL) DeletePolicy(
+  ctx      context.Context,
+  entityID users.EntityID,
+  name     string,
+) error {
+  tag, err := s.db.Exec(
+    ctx,
+    `DELETE FROM schema_policies WHERE entity_id = $1 AND name = $2`,
+    uuid.UUID(entityID),
+    name,
+  )
+  if err != nil {
+    utils.NewLogHandlerFunc(
+      "DeletePolicy",
+      log.Fields{
+        "entity_id" : entityID.String(),
+        "policy"    : name,
+      },
+    )(utils.LogErro, "failed to delete policy: %s", err.Error())
+    return repo.ErrDBFailedToUpdate
+  }
+  if tag.RowsAffected() == 0 {
+    return repo.ErrDBPolicyNotFound
+  }
+
+  return nil
+}
+
+// GetSubject - Returns an Entity's Subject by name.
+//
+// Potential Errors:
+//   - ErrDBSubjectNotFound :: Also matches domain.ErrSubjectNotFound.
+//   - ErrDBFailedToQuery
+func(s *SchemaPGSQL) GetSubject(
+  ctx      context.Context,
+  entityID users.EntityID,
+  name     string,
+)( *domain.Subject, error ){
+  var (
+    subject = domain.Subject{ EntityID: entityID }
+    mode    *string
+  )
+  err := s.db.QueryRow(
+    ctx,
+    `SELECT id, name, compatibility, created_at, updated_at
+     FROM subjects
+     WHERE entity_id = $1 AND name = $2`,
+    uuid.UUID(entityID),
+    name,
+  ).Scan(
+    &subject.ID,
+    &subject.Name,
+    &mode,
+    &subject.CreatedAt,
+    &subject.UpdatedAt,
+  )
+  if errors.Is(err, pgx.ErrNoRows) {
+    return nil, fmt.Errorf("%w: %w", repo.ErrDBSubjectNotFound, domain.ErrSubjectNotFound)
+  }
+  if err != nil {
+    utils.NewLogHandlerFunc(
+      "GetSubject",
+      log.Fields{
+        "entity_id" : entityID.String(),
+        "subject"   : name,
+      },
+    )(utils.LogErro, "failed to query subject: %s", err.Error())
+    return nil, repo.ErrDBFailedToQuery
+  }
+  if mode != nil {
+    subject.Compatibility = domain.CompatibilityMode(*mode)
+  }
+
+  return &subject, nil
+}
+
+// CreateSubject - Registers a new Subject for an Entity. If the Subject already
+// exists, the stored Subject is returned instead.
+//
+// Potential Errors:
+//   - ErrDBFailedToInsert
+//   - ErrDBFailedToQuery
+func(s *SchemaPGSQL) CreateSubject(
+  ctx      context.Context,
+  entityID users.EntityID,
+  name     string,
+)( *domain.Subject, error ){
+  if _, err := s.db.Exec(
+    ctx,
+    `INSERT INTO subjects (entity_id, name)
+     VALUES ($1, $2)
+     ON CONFLICT (entity_id, name) DO NOTHING`,
+    uuid.UUID(entityID),
+    name,
+  ); err != nil {
+    utils.NewLogHandlerFunc(
+      "CreateSubject",
+      log.Fields{
+        "entity_id" : entityID.String(),
+        "subject"   : name,
+      },
+    )(utils.LogErro, "failed to insert subject: %s", err.Error())
+    return nil, repo.ErrDBFailedToInsert
+  }
+
+  return s.GetSubject(ctx, entityID, name)
+}
+
+// SetSubjectCompatibility - Overrides a Subject's CompatibilityMode. Passing an
+// empty mode removes the override.
+//
+// Potential Errors:
+//   - ErrDBSubjectNotFound
+//   - ErrDBFailedToUpdate
+func(s *SchemaPGSQL) SetSubjectCompatibility(
+  ctx      context.Context,
+  entityID users.EntityID,
+  name     string,
+  mode     domain.CompatibilityMode,
+) error {
+  var override *string
+  if mode != "" {
+    m := string(mode)
+    override = &m
+  }
+
+  tag, err := s.db.Exec(
+    ctx,
+    `UPDATE subjects
+     SET compatibility = $3,
+         updated_at    = CURRENT_TIMESTAMP
+     WHERE entity_id = $1 AND name = $2`,
+    uuid.UUID(entityID),
+    name,
+    override,
+  )
+  if err != nil {
+    utils.NewLogHandlerFunc(
+      "SetSubjectCompatibility",
+      log.Fields{
+        "entity_id"     : entityID.String(),
+        "subject"       : name,
+        "compatibility" : mode,
+      },
+    )(utils.LogErro, "failed to update subject: %s", err.Error())
+    return repo.ErrDBFailedToUpdate
+  }
+  if tag.RowsAffected() == 0 {
+    return repo.ErrDBSubjectNotFound
+  }
+
+  return nil
+}
+
+// ListSchemaVersions - Returns every stored version of a Subject, soft-deleted
+// ones included, ordered from oldest to newest.
+//
+// Potential Errors:
+//   - ErrDBFailedToQuery
+func(s *SchemaPGSQL) ListSchemaVersions(
+  ctx       context.Context,
+  subjectID uuid.UUID,
+)( []domain.SchemaVersion, error ){
+  var pushLog = utils.NewLogHandlerFunc(
+    "ListSchemaVersions",
+    log.Fields{
+      "subject_id": subjectID.String(),
+    },
+  )
+
+  rows, err := s.db.Query(
+    ctx,
+    `SELECT id, entity_id, version, COALESCE(tag, ''), versioning, blob_url, manifest, author_id, created_at, deleted_at
+     FROM schemas
+     WHERE subject_id = $1
+     ORDER BY version ASC`,
+    subjectID,
+  )
+  if err != nil {
+    pushLog(utils.LogErro, "failed to query schema versions: %s", err.Error())
+    return nil, repo.ErrDBFailedToQuery
+  }
+  defer rows.Close()
+
+  versions := []domain.SchemaVersion{}
+  for rows.Next() {
+    var (
+      v          = domain.SchemaVersion{ SubjectID: subjectID }
+      entityID   uuid.UUID
+      versioning string
+      authorID   *uuid.UUID
+      manifest   []byte
+    )
+    if err := rows.Scan(
+      &v.ID,
+      &entityID,
+      &v.Version,
+      &v.Tag,
+      &versioning,
+      &v.BlobURL,
+      &manifest,
+      &authorID,
+      &v.CreatedAt,
+      &v.DeletedAt,
+    ); err != nil {
+      pushLog(utils.LogErro, "failed to scan schema version: %s", err.Error())
+      return nil, repo.ErrDBFailedToQuery
+    }
+    v.EntityID   = users.EntityID(entityID)
+    v.Versioning = domain.VersioningScheme(versioning)
+    if authorID != nil {
+      v.AuthorID = users.AccountID(*authorID)
+    }
+    if manifest != nil {
+      v.Manifest = &domain.VersionManifest{}
+      if err := json.Unmarshal(manifest, v.Manifest); err != nil {
+        pushLog(utils.LogErro, "failed to decode version manifest: %s", err.Error())
+        return nil, repo.ErrDBFailedToQuery
+      }
+    }
+    versions = append(versions, v)
+  }
+  if err := rows.Err(); err != nil {
+    pushLog(utils.LogErro, "failed to iterate schema versions: %s", err.Error())
+    return nil, repo.ErrDBFailedToQuery
+  }
+
+  return versions, nil
+}
+
+// CreateSchemaVersion - Records a newly accepted version of 'subject', along
+// with its manifest and author. The Subject is registered in the same
+// transaction when it's new, so rejected uploads never leave an empty Subject
+// behind. The (subject_id, version) unique constraint guards against
+// concurrent uploads claiming the same version number, and the
+// schemas_subject_tag_key index against them claiming the same tag.
+//
+// 'publish' runs once the version's row is inserted, while the transaction
+// still holds it, so concurrent uploads of the same version wait on it and
+// are refused once it commits. The transaction is rolled back when
+// 'publish' fails.
+//
+// Potential Errors:
+//   - ErrDBSchemaVersionExists
+//   - domain.ErrVersionTagExists
+//   - ErrDBFailedToBeginTX
+//   - ErrDBFailedToInsert
+//   - Any error returned by 'publish'.
+func(s *SchemaPGSQL) CreateSchemaVersion(
+  ctx     context.Context,
+  subject string,
+  version *domain.SchemaVersion,
+  publish func() error,
+) error {
+  var pushLog = utils.NewLogHandlerFunc(
+    "CreateSchemaVersion",
+    log.Fields{
+      "entity_id" : version.EntityID.String(),
+      "subject"   : subject,
+      "version"   : version.Version,
+    },
+  )
+
+  var manifest []byte
+  if version.Manifest != nil {
+    raw, err := json.Marshal(version.Manifest)
+    if err != nil {
+      pushLog(utils.LogErro, "failed to encode version manifest: %s", err.Error())
+      return repo.ErrDBFailedToInsert
+    }
+    manifest = raw
+  }
+  var authorID *uuid.UUID
+  if version.AuthorID != users.NilAccount() {
+    id := uuid.UUID(version.AuthorID)
+    authorID = &id
+  }
+
+  tx, err := s.db.Begin(ctx)
+  if err != nil {
+    pushLog(utils.LogErro, "failed to begin transaction: %s", err.Error())
+    return repo.ErrDBFailedToBeginTX
+  }
+  defer tx.Rollback(ctx)
+
+  // ->> The no-op update makes the upsert return the stored Subject's ID.
+  var subjectID uuid.UUID
+  if err := tx.QueryRow(
+    ctx,
+    `INSERT INTO subjects (entity_id, name)
+     VALUES ($1, $2)
+     ON CONFLICT (entity_id, name) DO UPDATE SET name = EXCLUDED.name
+     RETURNING id`,
+    uuid.UUID(version.EntityID),
+    subject,
+  ).Scan(&subjectID); err != nil {
+    pushLog(utils.LogErro, "failed to register subject: %s", err.Error())
+    return repo.ErrDBFailedToInsert
+  }
+
+  var (
+    id        uuid.UUID
+    createdAt time.Time
+  )
+  err = tx.QueryRow(
+    ctx,
+    `INSERT INTO schemas (
+       entity_id,
+       subject_id,
+       name,
+       version,
+       blob_url,
+       graph_url,
+       tag,
+       versioning,
+       manifest,
+       author_id
+     )
+     SELECT entity_id, id, name, $2, $3, '', NULLIF($4, ''), $5, $6, $7
+     FROM subjects
+     WHERE id = $1
+     RETURNING id, created_at`,
+    subjectID,
+    version.Version,
+    version.BlobURL,
+    version.Tag,
+    string(version.Versioning),
+    manifest,
+    authorID,
+  ).Scan(
+    &id,
+    &createdAt,
+  )
+  if err != nil {
+    if isTagConflict(err) {
+      pushLog(utils.LogWarn, "schema version tag already exists: %s", err.Error())
+      return domain.ErrVersionTagExists
+    }
+    var pgErr *pgconn.PgError
+    if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+      pushLog(utils.LogErro, "schema version already exists: %s", err.Error())
+      return repo.ErrDBSchemaVersionExists
+    }
+    pushLog(utils.LogErro, "failed to insert schema version: %s", err.Error())
+    return repo.ErrDBFailedToInsert
+  }
+
+  if err := publish(); err != nil {
+    return err
+  }
+  if err := tx.Commit(ctx); err != nil {
+    pushLog(utils.LogErro, "failed to commit schema version: %s", err.Error())
+    return repo.ErrDBFailedToInsert
+  }
+
+  version.ID        = id
+  version.SubjectID = subjectID
+  version.CreatedAt = createdAt
+  return nil
+}
+
+// SetSchemaVersionDeleted - Soft-deletes a Subject version, or restores it
+// when 'deleted' is false. Nothing else about a published version can change.
+//
+// Potential Errors:
+//   - ErrDBSchemaVersionNotFound
+//   - domain.ErrVersionTagExists :: A restored version's tag is taken.
+//   - ErrDBFailedToUpdate
+func(s *SchemaPGSQL) SetSchemaVersionDeleted(
+  ctx       context.Context,
+  subjectID uuid.UUID,
+  version   int,
+  deleted   bool,
+) error {
+  tag, err := s.db.Exec(
+    ctx,
+    `UPDATE schemas
+     SET deleted_at = CASE WHEN $3 THEN COALESCE(deleted_at, CURRENT_TIMESTAMP) END,
+         updated_at = CURRENT_TIMESTAMP
+     WHERE subject_id = $1 AND version = $2`,
+    subjectID,
+    version,
+    deleted,
+  )
+  if isTagConflict(err) {
+    return domain.ErrVersionTagExists
+  }
+  if err != nil {
+    utils.NewLogHandlerFunc(
+      "SetSchemaVersionDeleted",
+      log.Fields{
+        "subject_id" : subjectID.String(),
+        "version"    : version,
+        "deleted"    : deleted,
+      },
+    )(utils.LogErro, "failed to update schema version: %s", err.Error())
+    return repo.ErrDBFailedToUpdate
+  }
+  if tag.RowsAffected() == 0 {
+    return repo.ErrDBSchemaVersionNotFound
+  }
+
+  return nil
+}
+
+// isTagConflict - Reports whether 'err' violates the schemas_subject_tag_key
+// index, i.e. a Subject already has a live version with the same tag.
+func isTagConflict(err error) bool {
+  var pgErr *pgconn.PgError
+  return errors.As(err, &pgErr) &&
+    pgErr.Code == "23505" &&
+    pgErr.ConstraintName == "schemas_subject_tag_key"
+}
+
+// CreateStagedUpload - Records a new StagedUpload, filling in its ID and
+// CreatedAt.
+//
+// Potential Errors:
+//   - ErrDBFailedToInsert
+func(s *SchemaPGSQL) CreateStagedUpload(
+  ctx    context.Context,
+  upload *domain.StagedUpload,
+) error {
+  var authorID *uuid.UUID
+  if upload.AccountID != users.NilAccount() {
+    id := uuid.UUID(upload.AccountID)
+    authorID = &id
+  }
+
+  if err := s.db.QueryRow(
+    ctx,
+    `INSERT INTO schema_staged_uploads (
+       entity_id,
+       account_id,
+       subject,
+       tag,
+       files,
+       expires_at
+     )
+     VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+     RETURNING id, created_at`,
+    uuid.UUID(upload.EntityID),
+    authorID,
+    upload.Subject,
+    upload.Tag,
+    upload.Files,
+    upload.ExpiresAt,
+  ).Scan(
+    &upload.ID,
+    &upload.CreatedAt,
+  ); err != nil {
+    utils.NewLogHandlerFunc(
+      "CreateStagedUpload",
+      log.Fields{
+        "entity_id" : upload.EntityID.String(),
+        "subject"   : upload.Subject,
+      },
+    )(utils.LogErro, "failed to insert staged upload: %s", err.Error())
+    return repo.ErrDBFailedToInsert
+  }
+
+  return nil
+}
+
+// GetStagedUpload - Returns one of an Entity's StagedUploads, expired or not.
+//
+// Potential Errors:
+//   - ErrDBStagedUploadNotFound
+//   - ErrDBFailedToQuery
+func(s *SchemaPGSQL) GetStagedUpload(
+  ctx      context.Context,
+  entityID users.EntityID,
+  id       uuid.UUID,
+)( *domain.StagedUpload, error ){
+  var (
+    upload    = domain.StagedUpload{ ID: id, EntityID: entityID }
+    accountID *uuid.UUID
+  )
+  err := s.db.QueryRow(
+    ctx,
+    `SELECT account_id, subject, COALESCE(tag, ''), files, created_at, expires_at
+     FROM schema_staged_uploads
+     WHERE entity_id = $1 AND id = $2`,
+    uuid.UUID(entityID),
+    id,
+  ).Scan(
+    &accountID,
+    &upload.Subject,
+    &upload.Tag,
+    &upload.Files,
+    &upload.CreatedAt,
+    &upload.ExpiresAt,
+  )
+  if errors.Is(err, pgx.ErrNoRows) {
+    return nil, repo.ErrDBStagedUploadNotFound
+  }
+  if err != nil {
+    utils.NewLogHandlerFunc(
+      "GetStagedUpload",
+      log.Fields{
+        "entity_id" : entityID.String(),
+        "upload_id" : id.String(),
+      },
+    )(utils.LogErro, "failed to query staged upload: %s", err.Error())
+    return nil, repo.ErrDBFailedToQuery
+  }
+  if accountID != nil {
+    upload.AccountID = users.AccountID(*accountID)
+  }
+
+  return &upload, nil
+}
+
+// DeleteStagedUpload - Removes one of an Entity's StagedUploads.
+//
+// Potential Errors:
+//   - ErrDBStagedUploadNotFound
+//   - ErrDBFailedToUpdate
+func(s *SchemaPGSQL) DeleteStagedUpload(
+  ctx      context.Context,
+  entityID users.EntityID,
+  id       uuid.UUID,
+) error {
+  tag, err := s.db.Exec(
+    ctx,
+    `DELETE FROM schema_staged_uploads WHERE entity_id = $1 AND id = $2`,
+    uuid.UUID(entityID),
+    id,
+  )
+  if err != nil {
+    utils.NewLogHandlerFunc(
+      "DeleteStagedUpload",
+      log.Fields{
+        "entity_id" : entityID.String(),
+        "upload_id" : id.String(),
+      },
+    )(utils.LogErro, "failed to delete staged upload: %s", err.Error())
+    return repo.ErrDBFailedToUpdate
+  }
+  if tag.RowsAffected() == 0 {
+    return repo.ErrDBStagedUploadNotFound
+  }
+
+  return nil
+}
+
+// DeleteExpiredStagedUploads - Removes every StagedUpload that expired before
+// 'now', of any Entity, returning them.
+//
+// Potential Errors:
+//   - ErrDBFailedToUpdate
+func(s *SchemaPGSQL) DeleteExpiredStagedUploads(
+  ctx context.Context,
+  now time.Time,
+)( []domain.StagedUpload, error ){
+  var pushLog = utils.NewLogHandlerFunc(
+    "DeleteExpiredStagedUploads",
+    log.Fields{ "now": now },
+  )
+
+  rows, err := s.db.Query(
+    ctx,
+    `DELETE FROM schema_staged_uploads
+     WHERE expires_at <= $1
+     RETURNING id, entity_id, subject, files, created_at, expires_at`,
+    now,
+  )
+  if err != nil {
+    pushLog(utils.LogErro, "failed to delete expired staged uploads: %s", err.Error())
+    return nil, repo.ErrDBFailedToUpdate
+  }
+  defer rows.Close()
+
+  expired := []domain.StagedUpload{}
+  for rows.Next() {
+    var (
+      upload   domain.StagedUpload
+      entityID uuid.UUID
+    )
+    if err := rows.Scan(
+      &upload.ID,
+      &entityID,
+      &upload.Subject,
+      &upload.Files,
+      &upload.CreatedAt,
+      &upload.ExpiresAt,
+    ); err != nil {
+      pushLog(utils.LogErro, "failed to scan staged upload: %s", err.Error())
+      return nil, repo.ErrDBFailedToUpdate
+    }
+    upload.EntityID = users.EntityID(entityID)
+    expired = append(expired, upload)
+  }
+  if err := rows.Err(); err != nil {
+    pushLog(utils.LogErro, "failed to iterate staged uploads: %s", err.Error())
+    return nil, repo.ErrDBFailedToUpdate
+  }
+
+  return expired, nil
+}
+
+// Shutdown - Closes the underlying Postgres connection pool.
+func(s *SchemaPGSQL) Shutdown() error {
+  s.db.Close()
+  return nil
+}
@@ -1,20 +1,29 @@
 package s3
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"net/url"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
 	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/repository"
+	"github.com/TylerAldrich814/Fidicus/internal/shared/users"
 	"github.com/TylerAldrich814/Fidicus/internal/shared/utils"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
 
 	log "github.com/sirupsen/logrus"
 )
 
-// S3Storage - defines our Fidicus
+// S3Storage - defines our Fidicus Blob Storage. Keys are never accepted raw;
+// every method builds its keys from an EntityID and the parts of the domain
+// key layout, see domain.SchemaRef, so one Entity can't reach another's files.
 type S3Storage struct {
   client *minio.Client
   bucket string
@@ -76,22 +85,56 @@ func NewS3Storage(
     }
   }
 
+  // ->> Keep every object version, so even a deleted file can be recovered.
+  //     Single drive MinIO deployments don't support versioning, which
+  //     shouldn't stop local development.
+  if err := client.EnableVersioning(ctx, bucket); err != nil {
+    pushLog(utils.LogWarn, "failed to enable bucket versioning: %s", err.Error())
+  }
+
+  // ->> Abandoned staged uploads are cleaned up by the bucket itself, every
+  //     version of them included. Expiration is counted in whole days.
+  if err := client.SetBucketLifecycle(ctx, bucket, &lifecycle.Configuration{
+    Rules: []lifecycle.Rule{{
+      ID         : "expire-staged-uploads",
+      Status     : "Enabled",
+      RuleFilter : lifecycle.Filter{ Prefix: domain.StagingPrefix },
+      Expiration : lifecycle.Expiration{ Days: 1 },
+      NoncurrentVersionExpiration: lifecycle.NoncurrentVersionExpiration{
+        NoncurrentDays: 1,
+      },
+    }},
+  }); err != nil {
+    pushLog(utils.LogWarn, "failed to set staged upload lifecycle: %s", err.Error())
+  }
+
   return &S3Storage{
     client, 
     bucket,
   }, nil
 }
  
-// UploadSchema -- Uploads a new Schema into our S3 Bucket.
+// UploadSchema -- Uploads a local file into a Subject version in our S3
+// Bucket. Stored Schemas are immutable, so existing keys are never overwritten.
+//
+// Potential Errors:
+//    - domain.ErrInvalidBlobKey
+//    - BlobErr.ErrBlobDBObjectExists
+//    - BlobErr.ErrBlobDBUploadFailed
 func(s *S3Storage) UploadSchema(
-  ctx context.Context, 
-  name string, 
-  path string,
+  ctx      context.Context, 
+  entityID users.EntityID,
+  ref      domain.SchemaRef,
+  path     string,
 ) error {
+  key, err := ref.Key(entityID)
+  if err != nil {
+    return err
+  }
   var pushLog = utils.NewLogHandlerFunc(
     "UploadSchema",
     log.Fields{
-      "name" : name,
+      "key"  : key,
       "path" : path,
     },
   )
@@ -99,33 +142,363 @@ func(s *S3Storage) UploadSchema(
   if _, err := s.client.FPutObject(
     ctx,
     s.bucket,
-    name,
+    key,
     path,
-    minio.PutObjectOptions{},
+    immutablePut(),
   ); err != nil {
+    if isPreconditionFailed(err) {
+      return repository.ErrBlobDBObjectExists
+    }
     pushLog(utils.LogErro, "failed to put new object into storage: %s", err.Error())
     return repository.ErrBlobDBUploadFailed
   }
 
   return nil
 }
+
+// PutSchema -- Uploads an in-memory file into a Subject version in our S3
+// Bucket, refusing to overwrite an existing key.
+//
+// Potential Errors:
+//    - domain.ErrInvalidBlobKey
+//    - BlobErr.ErrBlobDBObjectExists
+//    - BlobErr.ErrBlobDBUploadFailed
+func(s *S3Storage) PutSchema(
+  ctx      context.Context,
+  entityID users.EntityID,
+  ref      domain.SchemaRef,
+  data     []byte,
+) error {
+  key, err := ref.Key(entityID)
+  if err != nil {
+    return err
+  }
+  return s.put(ctx, key, data, immutablePut())
+}
  
-// DownloadSchema - Attempts to download a stored schema from s3.
+// DownloadSchema - Attempts to download a file of a Subject version from s3.
+//
+// Potential Errors:
+//    - domain.ErrInvalidBlobKey
+//    - BlobErr.ErrBlobDBNotFound
+//    - BlobErr.ErrBlobDBDownloadFailed
+//    - BlobErr.ErrBlobDBIOError
 func(s *S3Storage) DownloadSchema(
-  ctx context.Context,
-  name string,
+  ctx      context.Context,
+  entityID users.EntityID,
+  ref      domain.SchemaRef,
+)( []byte, error ){
+  key, err := ref.Key(entityID)
+  if err != nil {
+    return nil, err
+  }
+  return s.get(ctx, key, -1)
+}
+
+// PutBlob -- Stores 'data' under the Entity's blob for its digest. Blobs are
+// immutable, so nothing is uploaded when the digest is already stored.
+//
+// Potential Errors:
+//    - domain.ErrInvalidBlobKey
+//    - BlobErr.ErrBlobDBInternal
+//    - BlobErr.ErrBlobDBUploadFailed
+func(s *S3Storage) PutBlob(
+  ctx      context.Context,
+  entityID users.EntityID,
+  data     []byte,
+)( string, error ){
+  digest   := domain.BlobDigest(data)
+  key, err := domain.BlobKey(entityID, digest)
+  if err != nil {
+    return "", err
+  }
+
+  _, err = s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+  switch {
+  case err == nil:
+    return digest, nil
+  case !isNotFound(err):
+    utils.NewLogHandlerFunc(
+      "PutBlob",
+      log.Fields{ "key": key },
+    )(utils.LogErro, "failed to check for stored blob: %s", err.Error())
+    return "", repository.ErrBlobDBInternal
+  }
+
+  opts := immutablePut()
+  opts.UserMetadata = map[string]string{ "sha256": digest }
+  // ->> A concurrent upload storing the same blob first is just as good.
+  if err := s.put(ctx, key, data, opts); err != nil && err != repository.ErrBlobDBObjectExists {
+    return "", err
+  }
+  return digest, nil
+}
+
+// GetBlob -- Downloads the Entity's blob stored under 'digest', rejecting it
+// when its content no longer hashes to 'digest'.
+//
+// Potential Errors:
+//    - domain.ErrInvalidBlobKey
+//    - BlobErr.ErrBlobDBNotFound
+//    - BlobErr.ErrBlobDBDownloadFailed
+//    - BlobErr.ErrBlobDBIOError
+//    - BlobErr.ErrBlobDBDigestMismatch
+func(s *S3Storage) GetBlob(
+  ctx      context.Context,
+  entityID users.EntityID,
+  digest   string,
+)( []byte, error ){
+  key, err := domain.BlobKey(entityID, digest)
+  if err != nil {
+    return nil, err
+  }
+  data, err := s.get(ctx, key, -1)
+  if err != nil {
+    return nil, err
+  }
+  if domain.BlobDigest(data) != digest {
+    utils.NewLogHandlerFunc(
+      "GetBlob",
+      log.Fields{ "key": key },
+    )(utils.LogErro, "stored blob doesn't match its digest")
+    return nil, repository.ErrBlobDBDigestMismatch
+  }
+  return data, nil
+}
+
+// DeleteSchema - Attempts to delete a file of a Subject version from S3.
+//
+// Potential Errors:
+//    - domain.ErrInvalidBlobKey
+//    - BlobErr.ErrBlobDBDeleteFailed
+func(s *S3Storage) DeleteSchema(
+  ctx      context.Context,
+  entityID users.EntityID,
+  ref      domain.SchemaRef,
+) error {
+  key, err := ref.Key(entityID)
+  if err != nil {
+    return err
+  }
+  return s.remove(ctx, key)
+}
+
+// GeneratePresignedURL -- Generates a pre-signed URL granting read access to
+// the Entity's blob stored under 'digest'.
+//
+// Potential Errors:
+//    - domain.ErrInvalidBlobKey
+//    - BlobErr.ErrBlobDBPresignFailed
+func(s *S3Storage) GeneratePresignedURL(
+  ctx      context.Context, 
+  entityID users.EntityID,
+  digest   string, 
+  expiry   time.Duration,
+)( string, error) {
+  key, err := domain.BlobKey(entityID, digest)
+  if err != nil {
+    return "", err
+  }
+
+  reqParams := make(url.Values)
+  presignedURL, err := s.client.PresignedGetObject(
+    ctx,
+    s.bucket,
+    key,
+    expiry,
+    reqParams,
+  )
+  if err != nil {
+    utils.NewLogHandlerFunc(
+      "GeneratePresignedURL",
+      log.Fields{ "key": key },
+    )(utils.LogErro, "failed to presign get url: %s", err.Error())
+    return "", repository.ErrBlobDBPresignFailed
+  }
+
+  return presignedURL.String(), nil
+}
+
+// GeneratePresignedPutURL -- Generates a pre-signed URL clients can upload a
+// single file of a StagedUpload with, directly into our S3 Bucket. PUT URLs
+// can't bound the file's size, so it's enforced once the upload is finalized.
+//
+// Potential Errors:
+//    - domain.ErrInvalidBlobKey
+//    - BlobErr.ErrBlobDBPresignFailed
+func(s *S3Storage) GeneratePresignedPutURL(
+  ctx      context.Context,
+  entityID users.EntityID,
+  uploadID uuid.UUID,
+  file     string,
+  expiry   time.Duration,
+)( string, error ){
+  key, err := domain.StagedFileKey(entityID, uploadID, file)
+  if err != nil {
+    return "", err
+  }
+
+  presignedURL, err := s.client.PresignedPutObject(
+    ctx,
+    s.bucket,
+    key,
+    expiry,
+  )
+  if err != nil {
+    utils.NewLogHandlerFunc(
+      "GeneratePresignedPutURL",
+      log.Fields{ "key": key },
+    )(utils.LogErro, "failed to presign put url: %s", err.Error())
+    return "", repository.ErrBlobDBPresignFailed
+  }
+
+  return presignedURL.String(), nil
+}
+ 
+// ListSchemas -- Returns the path of every file stored under a Subject
+// version, relative to the version.
+//
+// Potential Errors:
+//    - domain.ErrInvalidBlobKey
+//    - BlobErr.ErrBlobDBInternal
+func(s *S3Storage) ListSchemas(
+  ctx      context.Context,
+  entityID users.EntityID,
+  subject  string,
+  version  int,
+)( []string, error ){
+  prefix, err := domain.VersionPrefix(entityID, subject, version)
+  if err != nil {
+    return nil, err
+  }
+  return s.list(ctx, prefix)
+}
+
+// ListStagedFiles -- Returns every file uploaded so far for a StagedUpload,
+// along with its size.
+//
+// Potential Errors:
+//    - domain.ErrInvalidBlobKey
+//    - BlobErr.ErrBlobDBInternal
+func(s *S3Storage) ListStagedFiles(
+  ctx      context.Context,
+  entityID users.EntityID,
+  uploadID uuid.UUID,
+)( []domain.StagedObject, error ){
+  prefix, err := domain.StagedUploadPrefix(entityID, uploadID)
+  if err != nil {
+    return nil, err
+  }
+  objects, err := s.stat(ctx, prefix)
+  if err != nil {
+    return nil, err
+  }
+  staged := make([]domain.StagedObject, 0, len(objects))
+  for _, object := range objects {
+    staged = append(staged, domain.StagedObject{
+      Path : strings.TrimPrefix(object.Key, prefix),
+      Size : object.Size,
+    })
+  }
+  return staged, nil
+}
+
+// DownloadStagedFile -- Downloads a single file uploaded for a StagedUpload,
+// reading at most 'limit'+1 bytes.
+//
+// Potential Errors:
+//    - domain.ErrInvalidBlobKey
+//    - BlobErr.ErrBlobDBNotFound
+//    - BlobErr.ErrBlobDBDownloadFailed
+//    - BlobErr.ErrBlobDBIOError
+func(s *S3Storage) DownloadStagedFile(
+  ctx      context.Context,
+  entityID users.EntityID,
+  uploadID uuid.UUID,
+  file     string,
+  limit    int64,
+)( []byte, error ){
+  key, err := domain.StagedFileKey(entityID, uploadID, file)
+  if err != nil {
+    return nil, err
+  }
+  return s.get(ctx, key, limit)
+}
+
+// DeleteStagedUpload -- Deletes every file uploaded for a StagedUpload.
+//
+// Potential Errors:
+//    - domain.ErrInvalidBlobKey
+//    - BlobErr.ErrBlobDBInternal
+//    - BlobErr.ErrBlobDBDeleteFailed
+func(s *S3Storage) DeleteStagedUpload(
+  ctx      context.Context,
+  entityID users.EntityID,
+  uploadID uuid.UUID,
+) error {
+  prefix, err := domain.StagedUploadPrefix(entityID, uploadID)
+  if err != nil {
+    return err
+  }
+  files, err := s.list(ctx, prefix)
+  if err != nil {
+    return err
+  }
+  for _, file := range files {
+    if err := s.remove(ctx, prefix+file); err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+// put - Uploads 'data' under 'key'.
+func(s *S3Storage) put(
+  ctx  context.Context,
+  key  string,
+  data []byte,
+  opts minio.PutObjectOptions,
+) error {
+  if _, err := s.client.PutObject(
+    ctx,
+    s.bucket,
+    key,
+    bytes.NewReader(data),
+    int64(len(data)),
+    opts,
+  ); err != nil {
+    if isPreconditionFailed(err) {
+      return repository.ErrBlobDBObjectExists
+    }
+    utils.NewLogHandlerFunc(
+      "PutSchema",
+      log.Fields{ "key": key },
+    )(utils.LogErro, "failed to put new object into storage: %s", err.Error())
+    return repository.ErrBlobDBUploadFailed
+  }
+
+  return nil
+}
+
+// get - Downloads the object stored under 'key'. Unless 'limit' is negative,
+// at most 'limit'+1 bytes are read, so callers can tell oversized objects
+// apart without reading them whole.
+func(s *S3Storage) get(
+  ctx   context.Context,
+  key   string,
+  limit int64,
 )( []byte, error ){
   var pushLog = utils.NewLogHandlerFunc(
     "DownloadSchema",
     log.Fields{
-      "name": name,
+      "key": key,
     },
   )
 
   obj, err := s.client.GetObject(
     ctx,
     s.bucket,
-    name,
+    key,
     minio.GetObjectOptions{},
   )
   if err != nil {
@@ -138,8 +511,15 @@ func(s *S3Storage) DownloadSchema(
   }
   defer obj.Close()
 
-  schema, err := io.ReadAll(obj)
+  var r io.Reader = obj
+  if limit >= 0 {
+    r = io.LimitReader(obj, limit+1)
+  }
+  schema, err := io.ReadAll(r)
   if err != nil {
+    if isNotFound(err) {
+      return nil, repository.ErrBlobDBNotFound
+    }
     pushLog(
       utils.LogErro,
       "failed to read received schema from s3: %s",
@@ -151,8 +531,9 @@ func(s *S3Storage) DownloadSchema(
   return schema, nil
 }
 
-// DeleteSchema - Attempts to delete a schema from S3.
-func(s *S3Storage) DeleteSchema(
+// remove - Deletes the object stored under 'key'. With bucket versioning
+// enabled, earlier versions of the object are kept.
+func(s *S3Storage) remove(
   ctx context.Context,
   key string,
 ) error {
@@ -172,35 +553,29 @@ func(s *S3Storage) DeleteSchema(
   return nil
 }
 
-  // GeneratePresignedURL -- Generates pre-signed URLs for read/write access.
-func(s *S3Storage) GeneratePresignedURL(
-  ctx context.Context, 
-  key string, 
-  expiry time.Duration,
-)( string, error) {
-  reqParams := make(url.Values)
-  presignedURL, err := s.client.PresignedGetObject(
-    ctx,
-    s.bucket,
-    key,
-    expiry,
-    reqParams,
-  )
+// list - Returns the key of every object under 'prefix', relative to 'prefix'.
+func(s *S3Storage) list(
+  ctx    context.Context,
+  prefix string,
+)( []string, error ){
+  objects, err := s.stat(ctx, prefix)
   if err != nil {
-    return "", nil
+    return nil, err
   }
-
-  return presignedURL.String(), nil
+  var schemas []string
+  for _, object := range objects {
+    schemas = append(schemas, strings.TrimPrefix(object.Key, prefix))
+  }
+  return schemas, nil
 }
- 
-// ListSchemas -- Using a prefix, search and return an array of 
-// Object keys.
-func(s *S3Storage) ListSchemas(
+
+// stat - Returns the info of every object under 'prefix'.
+func(s *S3Storage) stat(
   ctx    context.Context,
   prefix string,
-)( []string, error ){
+)( []minio.ObjectInfo, error ){
   var pushLog = utils.NewLogHandlerFunc(
-    "DownloadSchema",
+    "ListSchemas",
     log.Fields{
       "prefix": prefix,
     },
@@ -215,7 +590,7 @@ func(s *S3Storage) ListSchemas(
     },
   )
 
-  var schemas []string
+  var objects []minio.ObjectInfo
   for object := range objectCh {
     if object.Err != nil {
       pushLog(
@@ -225,22 +600,34 @@ func(s *S3Storage) ListSchemas(
       )
       return nil, repository.ErrBlobDBInternal
     }
-    schemas = append(schemas, object.Key)
+    objects = append(objects, object)
   }
 
-  return schemas, nil
+  return objects, nil
+}
+
+// immutablePut - Returns PutObjectOptions that only create new keys, the
+// upload failing when the key is already stored.
+func immutablePut() minio.PutObjectOptions {
+  opts := minio.PutObjectOptions{}
+  opts.SetMatchETagExcept("*")
+  return opts
+}
+
+// isPreconditionFailed - Returns true when 'err' reports a conditional
+// upload was refused, i.e. the key was already stored.
+func isPreconditionFailed(err error) bool {
+  return minio.ToErrorResponse(err).Code == "PreconditionFailed"
+}
+
+// isNotFound - Returns true when 'err' reports a missing object.
+func isNotFound(err error) bool {
+  code := minio.ToErrorResponse(err).Code
+  return code == "NoSuchKey" || code == "NotFound"
+}
+
+// Shutdown -- The minio client holds no persistent connections, so there is
+// nothing to release.
+func(s *S3Storage) Shutdown() error {
+  return nil
 }
- 
- 
- 
- 
- 
- 
- 
- 
- 
- 
- 
- 
- 
- 
@@ -0,0 +1,192 @@
+package cypher
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+)
+
+// DefaultBatchSize - The maximum number of rows UNWOUND by a single statement.
+const DefaultBatchSize = 500
+
+// OwnedLabel - The label every node with an Owner is written with, so stale
+// nodes are found through the owner index rather than a full node scan.
+const OwnedLabel = "Owned"
+
+var (
+  ErrInvalidIdentifier = errors.New("invalid graph label or relationship type")
+  ErrMissingKey        = errors.New("graph node or relationship is missing a key")
+)
+
+// identifier -- Labels and relationship types can't be passed as parameters, so
+// they're interpolated into queries and must be plain identifiers.
+var identifier = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// Writer turns a domain.SchemaGraph into parameterized, batched Cypher
+// statements. Nodes are MERGEd on their Key, so writing the same graph twice
+// is idempotent. Every write replaces what the Owners of its nodes held
+// before, so removed fields, methods or imports don't linger in the graph.
+// Owned nodes carry the OwnedLabel, which their Owner is indexed under.
+type Writer struct {
+  batchSize int
+}
+
+// NewWriter - Creates a new Writer. A batchSize <= 0 uses DefaultBatchSize.
+func NewWriter(batchSize int) *Writer {
+  if batchSize <= 0 {
+    batchSize = DefaultBatchSize
+  }
+  return &Writer{ batchSize }
+}
+
+type nodeGroup struct {
+  label domain.NodeLabel
+  owned bool
+}
+
+type edgeGroup struct {
+  edge domain.EdgeType
+  from domain.NodeLabel
+  to   domain.NodeLabel
+}
+
+// Statements - Compiles 'graph' into Cypher statements. Stale nodes and
+// relationships of every Owner are removed first, then every node statement
+// is emitted before any relationship statement, so relationships always find
+// both of their endpoints.
+//
+// Potential Errors:
+//    - ErrInvalidIdentifier
+//    - ErrMissingKey
+func(w *Writer) Statements(
+  graph *domain.SchemaGraph,
+)( []domain.CypherStatement, error ){
+  statements := []domain.CypherStatement{}
+
+  // ->> Drop whatever each Owner no longer holds, and every relationship
+  //     leaving what it still holds; both are merged back below.
+  owners, held := graph.Owners()
+  for _, owner := range owners {
+    refs := make([]string, 0, len(held[owner]))
+    for _, ref := range held[owner] {
+      refs = append(refs, string(ref.Label)+":"+ref.Key)
+    }
+    statements = append(statements,
+      domain.CypherStatement{
+        Query  : "MATCH (n:"+OwnedLabel+" {owner: $owner})\n"+
+                 "WHERE NONE(l IN labels(n) WHERE l + ':' + n.key IN $refs)\n"+
+                 "DETACH DELETE n",
+        Params : map[string]any{ "owner": owner, "refs": refs },
+      },
+      domain.CypherStatement{
+        Query  : "MATCH (n:"+OwnedLabel+" {owner: $owner})-[r]->()\n"+
+                 "DELETE r",
+        Params : map[string]any{ "owner": owner },
+      },
+    )
+  }
+
+  // ->> Group nodes by label and whether they're owned, keeping the order
+  //     groups first appear in.
+  nodeGroups := []nodeGroup{}
+  nodeRows   := map[nodeGroup][]any{}
+  for _, node := range graph.Nodes {
+    if !identifier.MatchString(string(node.Label)) {
+      return nil, fmt.Errorf("%w: %q", ErrInvalidIdentifier, node.Label)
+    }
+    if node.Key == "" {
+      return nil, fmt.Errorf("%w: %s node", ErrMissingKey, node.Label)
+    }
+    group := nodeGroup{ node.Label, node.Owner != "" }
+    if _, ok := nodeRows[group]; !ok {
+      nodeGroups = append(nodeGroups, group)
+    }
+    row := props(node.Props)
+    if node.Owner != "" {
+      row = make(map[string]any, len(node.Props)+1)
+      for k, v := range node.Props {
+        row[k] = v
+      }
+      row["owner"] = node.Owner
+    }
+    nodeRows[group] = append(nodeRows[group], map[string]any{
+      "key"   : node.Key,
+      "props" : row,
+    })
+  }
+  for _, group := range nodeGroups {
+    query := fmt.Sprintf(
+      "UNWIND $rows AS row\n"+
+      "MERGE (n:`%s` {key: row.key})\n"+
+      "SET n = row.props, n.key = row.key",
+      group.label,
+    )
+    if group.owned {
+      query += ", n:" + OwnedLabel
+    }
+    statements = append(statements, w.batch(query, nodeRows[group])...)
+  }
+
+  // ->> Group relationships by type and endpoint labels.
+  groups   := []edgeGroup{}
+  edgeRows := map[edgeGroup][]any{}
+  for _, edge := range graph.Edges {
+    for _, id := range []string{ string(edge.Type), string(edge.FromLabel), string(edge.ToLabel) } {
+      if !identifier.MatchString(id) {
+        return nil, fmt.Errorf("%w: %q", ErrInvalidIdentifier, id)
+      }
+    }
+    if edge.FromKey == "" || edge.ToKey == "" {
+      return nil, fmt.Errorf("%w: %s relationship", ErrMissingKey, edge.Type)
+    }
+    group := edgeGroup{ edge.Type, edge.FromLabel, edge.ToLabel }
+    if _, ok := edgeRows[group]; !ok {
+      groups = append(groups, group)
+    }
+    edgeRows[group] = append(edgeRows[group], map[string]any{
+      "from"  : edge.FromKey,
+      "to"    : edge.ToKey,
+      "props" : props(edge.Props),
+    })
+  }
+  for _, group := range groups {
+    query := fmt.Sprintf(
+      "UNWIND $rows AS row\n"+
+      "MATCH (a:`%s` {key: row.from})\n"+
+      "MATCH (b:`%s` {key: row.to})\n"+
+      "MERGE (a)-[r:`%s`]->(b)\n"+
+      "SET r = row.props",
+      group.from,
+      group.to,
+      group.edge,
+    )
+    statements = append(statements, w.batch(query, edgeRows[group])...)
+  }
+
+  return statements, nil
+}
+
+// batch - Splits 'rows' into statements of at most w.batchSize rows each.
+func(w *Writer) batch(
+  query string,
+  rows  []any,
+) []domain.CypherStatement {
+  statements := []domain.CypherStatement{}
+  for start := 0; start < len(rows); start += w.batchSize {
+    end := min(start+w.batchSize, len(rows))
+    statements = append(statements, domain.CypherStatement{
+      Query  : query,
+      Params : map[string]any{ "rows": rows[start:end] },
+    })
+  }
+  return statements
+}
+
+func props(p map[string]any) map[string]any {
+  if p == nil {
+    return map[string]any{}
+  }
+  return p
+}
@@ -0,0 +1,250 @@
+package graphql
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+)
+
+// builtinDirectives - Directives defined by the GraphQL specification itself.
+var builtinDirectives = map[string]bool{
+  "skip"        : true,
+  "include"     : true,
+  "deprecated"  : true,
+  "specifiedBy" : true,
+  "defer"       : true,
+  "oneOf"       : true,
+}
+
+// graphQLGraphCompiler -- Compiles a validated GraphQL schema into a
+// domain.SchemaGraph. Types are keyed by name, fields by "Type.field",
+// arguments by "Type.field.arg", enum values by "Enum.VALUE" and
+// directives by "@name".
+type graphQLGraphCompiler struct {
+  schema *ast.Schema
+  graph  *domain.SchemaGraph
+  roots  map[string]string
+}
+
+func compileGraph(schema *ast.Schema) *domain.SchemaGraph {
+  c := &graphQLGraphCompiler{
+    schema : schema,
+    graph  : &domain.SchemaGraph{},
+    roots  : map[string]string{},
+  }
+  if schema.Query != nil {
+    c.roots[schema.Query.Name] = "query"
+  }
+  if schema.Mutation != nil {
+    c.roots[schema.Mutation.Name] = "mutation"
+  }
+  if schema.Subscription != nil {
+    c.roots[schema.Subscription.Name] = "subscription"
+  }
+
+  c.compileDirectives()
+  for _, name := range sortedNames(schema.Types) {
+    def := schema.Types[name]
+    if strings.HasPrefix(name, "__") {
+      continue
+    }
+    c.compileType(def)
+  }
+  return c.graph
+}
+
+func(c *graphQLGraphCompiler) compileDirectives() {
+  for _, name := range sortedNames(c.schema.Directives) {
+    if builtinDirectives[name] {
+      continue
+    }
+    dir := c.schema.Directives[name]
+    key := "@" + name
+
+    locations := make([]string, 0, len(dir.Locations))
+    for _, l := range dir.Locations {
+      locations = append(locations, string(l))
+    }
+    c.graph.AddNode(domain.LabelGraphQLDirective, key, map[string]any{
+      "name"        : name,
+      "description" : dir.Description,
+      "repeatable"  : dir.IsRepeatable,
+      "locations"   : locations,
+      "file"        : fileOf(dir.Position),
+    })
+    c.compileArguments(domain.LabelGraphQLDirective, key, dir.Arguments)
+  }
+}
+
+func(c *graphQLGraphCompiler) compileType(def *ast.Definition) {
+  c.graph.AddNode(domain.LabelGraphQLType, def.Name, map[string]any{
+    "name"        : def.Name,
+    "kind"        : string(def.Kind),
+    "description" : def.Description,
+    "builtIn"     : def.BuiltIn,
+    "root"        : c.roots[def.Name],
+    "file"        : fileOf(def.Position),
+  })
+  c.linkDirectives(domain.LabelGraphQLType, def.Name, def.Directives)
+
+  for _, iface := range def.Interfaces {
+    c.graph.AddEdge(
+      domain.EdgeImplements,
+      domain.LabelGraphQLType, def.Name,
+      domain.LabelGraphQLType, iface,
+    )
+  }
+  for _, member := range def.Types {
+    c.graph.AddEdge(
+      domain.EdgeUnionMember,
+      domain.LabelGraphQLType, def.Name,
+      domain.LabelGraphQLType, member,
+    )
+  }
+  for _, value := range def.EnumValues {
+    key := def.Name + "." + value.Name
+    c.graph.AddNode(domain.LabelGraphQLEnumValue, key, map[string]any{
+      "name"        : value.Name,
+      "description" : value.Description,
+      "deprecated"  : value.Directives.ForName("deprecated") != nil,
+    })
+    c.graph.AddEdge(
+      domain.EdgeHasValue,
+      domain.LabelGraphQLType, def.Name,
+      domain.LabelGraphQLEnumValue, key,
+    )
+    c.linkDirectives(domain.LabelGraphQLEnumValue, key, value.Directives)
+  }
+
+  for _, field := range def.Fields {
+    if strings.HasPrefix(field.Name, "__") {
+      continue
+    }
+    c.compileField(def, field)
+  }
+}
+
+// compileField - Root type fields become GraphQLOperation nodes, the GraphQL
+// equivalent of an RPC Method. Every other field becomes a GraphQLField.
+func(c *graphQLGraphCompiler) compileField(
+  def   *ast.Definition,
+  field *ast.FieldDefinition,
+) {
+  var (
+    key      = def.Name + "." + field.Name
+    label    = domain.LabelGraphQLField
+    typeEdge = domain.EdgeReturns
+    props    = map[string]any{
+      "name"        : field.Name,
+      "type"        : field.Type.String(),
+      "parent"      : def.Name,
+      "description" : field.Description,
+      "deprecated"  : field.Directives.ForName("deprecated") != nil,
+      "file"        : fileOf(field.Position),
+    }
+  )
+  if op, ok := c.roots[def.Name]; ok {
+    label = domain.LabelGraphQLOperation
+    props["operation"] = op
+  }
+  if def.Kind == ast.InputObject {
+    typeEdge = domain.EdgeArgType
+  }
+
+  c.graph.AddNode(label, key, props)
+  if label == domain.LabelGraphQLOperation {
+    c.graph.AddEdge(
+      domain.EdgeRootField,
+      label, key,
+      domain.LabelGraphQLType, def.Name,
+    )
+  } else {
+    c.graph.AddEdge(
+      domain.EdgeHasField,
+      domain.LabelGraphQLType, def.Name,
+      label, key,
+    )
+  }
+  c.graph.AddEdgeWithProps(
+    typeEdge,
+    label, key,
+    domain.LabelGraphQLType, field.Type.Name(),
+    typeProps(field.Type),
+  )
+  c.compileArguments(label, key, field.Arguments)
+  c.linkDirectives(label, key, field.Directives)
+}
+
+func(c *graphQLGraphCompiler) compileArguments(
+  ownerLbl domain.NodeLabel,
+  ownerKey string,
+  args     ast.ArgumentDefinitionList,
+) {
+  for _, arg := range args {
+    key := ownerKey + "." + arg.Name
+    c.graph.AddNode(domain.LabelGraphQLArgument, key, map[string]any{
+      "name"        : arg.Name,
+      "type"        : arg.Type.String(),
+      "description" : arg.Description,
+      "hasDefault"  : arg.DefaultValue != nil,
+    })
+    c.graph.AddEdge(
+      domain.EdgeHasArgument,
+      ownerLbl, ownerKey,
+      domain.LabelGraphQLArgument, key,
+    )
+    c.graph.AddEdgeWithProps(
+      domain.EdgeArgType,
+      domain.LabelGraphQLArgument, key,
+      domain.LabelGraphQLType, arg.Type.Name(),
+      typeProps(arg.Type),
+    )
+    c.linkDirectives(domain.LabelGraphQLArgument, key, arg.Directives)
+  }
+}
+
+// linkDirectives - Links a node to every custom directive applied to it.
+func(c *graphQLGraphCompiler) linkDirectives(
+  fromLbl    domain.NodeLabel,
+  fromKey    string,
+  directives ast.DirectiveList,
+) {
+  for _, dir := range directives {
+    if builtinDirectives[dir.Name] {
+      continue
+    }
+    c.graph.AddEdge(
+      domain.EdgeUsesDirective,
+      fromLbl, fromKey,
+      domain.LabelGraphQLDirective, "@"+dir.Name,
+    )
+  }
+}
+
+// typeProps - Describes the list/non-null wrapping of a type reference.
+func typeProps(t *ast.Type) map[string]any {
+  return map[string]any{
+    "type"    : t.String(),
+    "list"    : t.Elem != nil,
+    "nonNull" : t.NonNull,
+  }
+}
+
+func fileOf(pos *ast.Position) string {
+  if pos == nil || pos.Src == nil {
+    return ""
+  }
+  return pos.Src.Name
+}
+
+func sortedNames[T any](m map[string]T) []string {
+  names := make([]string, 0, len(m))
+  for name := range m {
+    names = append(names, name)
+  }
+  sort.Strings(names)
+  return names
+}
@@ -0,0 +1,9 @@
+package graphql
+
+import "errors"
+
+var (
+  ErrDocumentLoadFailed = errors.New("failed to load graphql document")
+  ErrSchemaInvalid      = errors.New("invalid graphql schema")
+  ErrNotParsed          = errors.New("files have not been parsed yet")
+)
@@ -0,0 +1,141 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/cypher"
+)
+
+// Accessor defines a function that opens a document by its path.
+type Accessor func(path string)( io.ReadCloser, error )
+
+// GraphQLFiles -- A set of GraphQL SDL documents implementing domain.Schema.
+// All documents are merged into a single schema, so types may be declared in
+// one file and extended in another.
+type GraphQLFiles struct {
+  filepaths []string
+  accessor  Accessor
+  schema    *ast.Schema
+  graph     *domain.SchemaGraph
+}
+
+// NewLocalFiles --- For Testing on local GraphQL files.
+func NewLocalFiles(
+  ctx       context.Context,
+  src       string,
+  filepaths []string,
+)( *GraphQLFiles, error ){
+  return &GraphQLFiles{
+    filepaths : filepaths,
+    accessor  : func(p string)( io.ReadCloser, error ){
+      return os.Open(filepath.Join(src, filepath.FromSlash(p)))
+    },
+  }, nil
+}
+
+// NewBlobFiles - Creates a GraphQLFiles instance whose documents are loaded
+// through 'accessor'.
+func NewBlobFiles(
+  ctx       context.Context,
+  filepaths []string,
+  accessor  Accessor,
+)( *GraphQLFiles, error ){
+  return &GraphQLFiles{
+    filepaths : filepaths,
+    accessor  : accessor,
+  }, nil
+}
+
+// NewSourceFiles - Creates a GraphQLFiles instance from in-memory documents,
+// keyed by their path.
+func NewSourceFiles(
+  ctx     context.Context,
+  sources map[string][]byte,
+)( *GraphQLFiles, error ){
+  filepaths := make([]string, 0, len(sources))
+  for p := range sources {
+    filepaths = append(filepaths, p)
+  }
+  sort.Strings(filepaths)
+
+  return &GraphQLFiles{
+    filepaths : filepaths,
+    accessor  : func(p string)( io.ReadCloser, error ){
+      data, ok := sources[p]
+      if !ok {
+        return nil, os.ErrNotExist
+      }
+      return io.NopCloser(bytes.NewReader(data)), nil
+    },
+  }, nil
+}
+
+// ParseSchemaFiles - Loads, merges and validates every document, then
+// compiles the resulting schema into a graph.
+//
+// Potential Errors:
+//    - ErrDocumentLoadFailed
+//    - ErrSchemaInvalid
+func(gf *GraphQLFiles) ParseSchemaFiles(
+  ctx context.Context,
+) error {
+  sources := make([]*ast.Source, 0, len(gf.filepaths))
+  for _, p := range gf.filepaths {
+    if err := ctx.Err(); err != nil {
+      return err
+    }
+    data, err := gf.read(p)
+    if err != nil {
+      return fmt.Errorf("%w: %s: %s", ErrDocumentLoadFailed, p, err.Error())
+    }
+    sources = append(sources, &ast.Source{
+      Name  : p,
+      Input : string(data),
+    })
+  }
+
+  schema, err := gqlparser.LoadSchema(sources...)
+  if err != nil {
+    return fmt.Errorf("%w: %s", ErrSchemaInvalid, err.Error())
+  }
+
+  gf.schema = schema
+  gf.graph  = compileGraph(schema)
+  return nil
+}
+
+// Graph -- Returns the compiled graph.
+func(gf *GraphQLFiles) Graph()( *domain.SchemaGraph, error ){
+  if gf.graph == nil {
+    return nil, ErrNotParsed
+  }
+  return gf.graph, nil
+}
+
+// Cyphers -- Compiles the graph into parameterized, batched Cypher statements.
+func(gf *GraphQLFiles) Cyphers()( []domain.CypherStatement, error ){
+  graph, err := gf.Graph()
+  if err != nil {
+    return nil, err
+  }
+  return cypher.NewWriter(cypher.DefaultBatchSize).Statements(graph)
+}
+
+func(gf *GraphQLFiles) read(p string)( []byte, error ){
+  rc, err := gf.accessor(p)
+  if err != nil {
+    return nil, err
+  }
+  defer rc.Close()
+  return io.ReadAll(rc)
+}
@@ -0,0 +1,409 @@
+package openapi
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+)
+
+// httpMethods - Every operation an OpenAPI Path Item may declare, in output order.
+var httpMethods = []string{ "get", "put", "post", "delete", "options", "head", "patch", "trace" }
+
+// schemaValueKeys - Schema keywords holding literal values rather than
+// sub-schemas. Never searched for $refs.
+var schemaValueKeys = map[string]bool{
+  "example"  : true,
+  "examples" : true,
+  "default"  : true,
+  "enum"     : true,
+  "const"    : true,
+}
+
+// openAPIGraphCompiler -- Compiles loaded OpenAPI documents into a domain.SchemaGraph.
+// Every node is keyed by the document and JSON Pointer it was defined at.
+type openAPIGraphCompiler struct {
+  res     *resolver
+  graph   *domain.SchemaGraph
+  schemas map[string]bool
+}
+
+func newOpenAPIGraphCompiler(res *resolver) *openAPIGraphCompiler {
+  return &openAPIGraphCompiler{
+    res     : res,
+    graph   : &domain.SchemaGraph{},
+    schemas : map[string]bool{},
+  }
+}
+
+// compileDocument - Compiles a root OpenAPI document: its paths, operations
+// and every component schema they reach.
+func(c *openAPIGraphCompiler) compileDocument(file string) error {
+  raw, err := c.res.load(file)
+  if err != nil {
+    return err
+  }
+  doc, _ := raw.(map[string]any)
+
+  version, _ := doc["openapi"].(string)
+  if !strings.HasPrefix(version, "3.0") && !strings.HasPrefix(version, "3.1") {
+    return ErrUnsupportedVersion
+  }
+
+  info, _ := doc["info"].(map[string]any)
+  root    := location{ file: file }
+  c.graph.AddNode(domain.LabelAPI, root.key(), map[string]any{
+    "file"    : file,
+    "openapi" : version,
+    "title"   : stringOf(info, "title"),
+    "version" : stringOf(info, "version"),
+  })
+
+  // ->> Component schemas are compiled even when no operation uses them.
+  components, _ := doc["components"].(map[string]any)
+  schemas, _    := components["schemas"].(map[string]any)
+  for _, name := range sortedKeys(schemas) {
+    loc := root.child("components", "schemas", name)
+    if err := c.compileSchema(loc, schemas[name]); err != nil {
+      return err
+    }
+  }
+
+  paths, _ := doc["paths"].(map[string]any)
+  for _, p := range sortedKeys(paths) {
+    if err := c.compilePath(root, p, paths[p]); err != nil {
+      return err
+    }
+  }
+
+  return nil
+}
+
+func(c *openAPIGraphCompiler) compilePath(
+  root  location,
+  p     string,
+  value any,
+) error {
+  pathLoc := root.child("paths", p)
+  itemLoc, item, err := c.deref(pathLoc, value)
+  if err != nil {
+    return err
+  }
+
+  c.graph.AddNode(domain.LabelAPIPath, pathLoc.key(), map[string]any{
+    "path" : p,
+  })
+  c.graph.AddEdge(
+    domain.EdgeHasPath,
+    domain.LabelAPI, root.key(),
+    domain.LabelAPIPath, pathLoc.key(),
+  )
+
+  for _, method := range httpMethods {
+    op, ok := item[method].(map[string]any)
+    if !ok {
+      continue
+    }
+    opLoc := pathLoc.child(method)
+    if err := c.compileOperation(
+      pathLoc,
+      opLoc,
+      itemLoc.child(method),
+      p,
+      method,
+      op,
+      item["parameters"],
+      itemLoc.child("parameters"),
+    ); err != nil {
+      return err
+    }
+  }
+
+  return nil
+}
+
+func(c *openAPIGraphCompiler) compileOperation(
+  pathLoc     location,
+  opLoc       location,
+  defLoc      location,
+  p           string,
+  method      string,
+  op          map[string]any,
+  pathParams  any,
+  pathParmLoc location,
+) error {
+  deprecated, _ := op["deprecated"].(bool)
+  c.graph.AddNode(domain.LabelOperation, opLoc.key(), map[string]any{
+    "method"      : strings.ToUpper(method),
+    "path"        : p,
+    "operationId" : stringOf(op, "operationId"),
+    "summary"     : stringOf(op, "summary"),
+    "deprecated"  : deprecated,
+    "tags"        : stringsOf(op["tags"]),
+  })
+  c.graph.AddEdge(
+    domain.EdgeHasOperation,
+    domain.LabelAPIPath, pathLoc.key(),
+    domain.LabelOperation, opLoc.key(),
+  )
+
+  // ->> Operation parameters override Path Item parameters sharing a name and location.
+  params := map[string]bool{}
+  list, _ := op["parameters"].([]any)
+  for i, raw := range list {
+    name, err := c.compileParameter(opLoc, defLoc.child("parameters", itoa(i)), raw)
+    if err != nil {
+      return err
+    }
+    params[name] = true
+  }
+  shared, _ := pathParams.([]any)
+  for i, raw := range shared {
+    paramLoc, param, err := c.deref(pathParmLoc.child(itoa(i)), raw)
+    if err != nil {
+      return err
+    }
+    if params[stringOf(param, "in")+"/"+stringOf(param, "name")] {
+      continue
+    }
+    if _, err := c.compileParameter(opLoc, paramLoc, param); err != nil {
+      return err
+    }
+  }
+
+  if body, ok := op["requestBody"]; ok {
+    bodyLoc, resolved, err := c.deref(defLoc.child("requestBody"), body)
+    if err != nil {
+      return err
+    }
+    key := opLoc.child("requestBody").key()
+    required, _ := resolved["required"].(bool)
+    content, _  := resolved["content"].(map[string]any)
+
+    c.graph.AddNode(domain.LabelRequestBody, key, map[string]any{
+      "required"     : required,
+      "contentTypes" : sortedKeys(content),
+    })
+    c.graph.AddEdge(
+      domain.EdgeHasRequestBody,
+      domain.LabelOperation, opLoc.key(),
+      domain.LabelRequestBody, key,
+    )
+    if err := c.linkContent(domain.LabelRequestBody, key, bodyLoc, content); err != nil {
+      return err
+    }
+  }
+
+  responses, _ := op["responses"].(map[string]any)
+  for _, status := range sortedKeys(responses) {
+    respLoc, resolved, err := c.deref(defLoc.child("responses", status), responses[status])
+    if err != nil {
+      return err
+    }
+    key := opLoc.child("responses", status).key()
+    content, _ := resolved["content"].(map[string]any)
+
+    c.graph.AddNode(domain.LabelResponse, key, map[string]any{
+      "status"       : status,
+      "description"  : stringOf(resolved, "description"),
+      "contentTypes" : sortedKeys(content),
+    })
+    c.graph.AddEdgeWithProps(
+      domain.EdgeHasResponse,
+      domain.LabelOperation, opLoc.key(),
+      domain.LabelResponse, key,
+      map[string]any{ "status": status },
+    )
+    if err := c.linkContent(domain.LabelResponse, key, respLoc, content); err != nil {
+      return err
+    }
+  }
+
+  return nil
+}
+
+// compileParameter - Compiles a single Parameter, returning its "in/name" identity.
+func(c *openAPIGraphCompiler) compileParameter(
+  opLoc location,
+  loc   location,
+  raw   any,
+)( string, error ){
+  paramLoc, param, err := c.deref(loc, raw)
+  if err != nil {
+    return "", err
+  }
+  in, name := stringOf(param, "in"), stringOf(param, "name")
+  key := opLoc.child("parameters", in, name).key()
+
+  required, _   := param["required"].(bool)
+  deprecated, _ := param["deprecated"].(bool)
+  c.graph.AddNode(domain.LabelAPIParameter, key, map[string]any{
+    "name"       : name,
+    "in"         : in,
+    "required"   : required,
+    "deprecated" : deprecated,
+  })
+  c.graph.AddEdge(
+    domain.EdgeHasParameter,
+    domain.LabelOperation, opLoc.key(),
+    domain.LabelAPIParameter, key,
+  )
+
+  if schema, ok := param["schema"]; ok {
+    if err := c.linkSchemas(domain.LabelAPIParameter, key, paramLoc.child("schema"), schema, nil); err != nil {
+      return "", err
+    }
+  }
+  content, _ := param["content"].(map[string]any)
+  if err := c.linkContent(domain.LabelAPIParameter, key, paramLoc, content); err != nil {
+    return "", err
+  }
+
+  return in + "/" + name, nil
+}
+
+// linkContent - Links 'fromKey' to every schema referenced by a Media Type map.
+func(c *openAPIGraphCompiler) linkContent(
+  fromLbl domain.NodeLabel,
+  fromKey string,
+  loc     location,
+  content map[string]any,
+) error {
+  for _, mediaType := range sortedKeys(content) {
+    media, _ := content[mediaType].(map[string]any)
+    schema, ok := media["schema"]
+    if !ok {
+      continue
+    }
+    if err := c.linkSchemas(
+      fromLbl,
+      fromKey,
+      loc.child("content", mediaType, "schema"),
+      schema,
+      map[string]any{ "mediaType": mediaType },
+    ); err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+// compileSchema - Adds a ComponentSchema node for the schema at 'loc', and
+// links it to every schema it references. Each schema is compiled once, so
+// recursive schemas terminate.
+func(c *openAPIGraphCompiler) compileSchema(
+  loc    location,
+  schema any,
+) error {
+  key := loc.key()
+  if c.schemas[key] {
+    return nil
+  }
+  c.schemas[key] = true
+
+  name := path.Base(loc.pointer)
+  if loc.pointer == "" {
+    name = strings.TrimSuffix(path.Base(loc.file), path.Ext(loc.file))
+  }
+  m, _ := schema.(map[string]any)
+  c.graph.AddNode(domain.LabelComponentSchema, key, map[string]any{
+    "name" : unescapePointer(name),
+    "file" : loc.file,
+    "type" : stringOf(m, "type"),
+  })
+
+  return c.linkSchemas(domain.LabelComponentSchema, key, loc, schema, nil)
+}
+
+// linkSchemas - Walks an inline schema and links 'fromKey' to every schema it
+// references through $ref, compiling each referenced schema along the way.
+func(c *openAPIGraphCompiler) linkSchemas(
+  fromLbl domain.NodeLabel,
+  fromKey string,
+  loc     location,
+  schema  any,
+  props   map[string]any,
+) error {
+  switch v := schema.(type) {
+  case map[string]any:
+    if ref, ok := v["$ref"].(string); ok {
+      target, value, err := c.res.resolve(loc.file, ref)
+      if err != nil {
+        return err
+      }
+      if err := c.compileSchema(target, value); err != nil {
+        return err
+      }
+      c.graph.AddEdgeWithProps(
+        domain.EdgeUsesSchema,
+        fromLbl, fromKey,
+        domain.LabelComponentSchema, target.key(),
+        props,
+      )
+    }
+    for _, k := range sortedKeys(v) {
+      if k == "$ref" || schemaValueKeys[k] {
+        continue
+      }
+      if err := c.linkSchemas(fromLbl, fromKey, loc.child(k), v[k], props); err != nil {
+        return err
+      }
+    }
+  case []any:
+    for i, item := range v {
+      if err := c.linkSchemas(fromLbl, fromKey, loc.child(itoa(i)), item, props); err != nil {
+        return err
+      }
+    }
+  }
+  return nil
+}
+
+// deref - Resolves 'value' when it's a $ref, returning where the definition
+// actually lives along with its contents.
+func(c *openAPIGraphCompiler) deref(
+  loc   location,
+  value any,
+)( location, map[string]any, error ){
+  if ref, ok := refOf(value); ok {
+    target, resolved, err := c.res.resolve(loc.file, ref)
+    if err != nil {
+      return location{}, nil, err
+    }
+    m, _ := resolved.(map[string]any)
+    return target, m, nil
+  }
+  m, _ := value.(map[string]any)
+  return loc, m, nil
+}
+
+func stringOf(m map[string]any, key string) string {
+  s, _ := m[key].(string)
+  return s
+}
+
+func stringsOf(value any) []string {
+  list, _ := value.([]any)
+  out := make([]string, 0, len(list))
+  for _, item := range list {
+    if s, ok := item.(string); ok {
+      out = append(out, s)
+    }
+  }
+  return out
+}
+
+func sortedKeys(m map[string]any) []string {
+  keys := make([]string, 0, len(m))
+  for k := range m {
+    keys = append(keys, k)
+  }
+  sort.Strings(keys)
+  return keys
+}
+
+func itoa(i int) string {
+  return strconv.Itoa(i)
+}
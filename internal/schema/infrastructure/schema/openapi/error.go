@@ -0,0 +1,13 @@
+package openapi
+
+import "errors"
+
+var (
+  ErrDocumentLoadFailed      = errors.New("failed to load openapi document")
+  ErrDocumentParseFailed     = errors.New("failed to parse openapi document")
+  ErrUnsupportedVersion      = errors.New("only openapi 3.0 and 3.1 documents are supported")
+  ErrUnresolvedRef           = errors.New("failed to resolve $ref")
+  ErrRemoteRefUnsupported    = errors.New("remote $ref targets are not supported")
+  ErrCircularRef             = errors.New("circular $ref chain")
+  ErrNotParsed               = errors.New("files have not been parsed yet")
+)
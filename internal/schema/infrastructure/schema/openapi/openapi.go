@@ -0,0 +1,125 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/cypher"
+)
+
+// OpenAPIFiles -- A set of OpenAPI 3.0/3.1 documents implementing domain.Schema.
+// Each of 'filepaths' is a root document; documents they $ref are loaded
+// through the same Accessor on demand.
+type OpenAPIFiles struct {
+  filepaths []string
+  resolver  *resolver
+  graph     *domain.SchemaGraph
+}
+
+// NewLocalFiles --- For Testing on local OpenAPI files.
+func NewLocalFiles(
+  ctx       context.Context,
+  src       string,
+  filepaths []string,
+)( *OpenAPIFiles, error ){
+  return newOpenAPIFiles(
+    filepaths,
+    func(p string)( io.ReadCloser, error ){
+      return os.Open(filepath.Join(src, filepath.FromSlash(p)))
+    },
+  ), nil
+}
+
+// NewBlobFiles - Creates an OpenAPIFiles instance whose documents are loaded
+// through 'accessor'.
+func NewBlobFiles(
+  ctx       context.Context,
+  filepaths []string,
+  accessor  Accessor,
+)( *OpenAPIFiles, error ){
+  return newOpenAPIFiles(filepaths, accessor), nil
+}
+
+// NewSourceFiles - Creates an OpenAPIFiles instance from in-memory documents,
+// keyed by their path. Every provided document declaring an 'openapi' version
+// is treated as a root document.
+func NewSourceFiles(
+  ctx     context.Context,
+  sources map[string][]byte,
+)( *OpenAPIFiles, error ){
+  files := &OpenAPIFiles{
+    resolver: newResolver(func(p string)( io.ReadCloser, error ){
+      data, ok := sources[p]
+      if !ok {
+        return nil, os.ErrNotExist
+      }
+      return io.NopCloser(bytes.NewReader(data)), nil
+    }),
+  }
+
+  for p := range sources {
+    doc, err := files.resolver.load(p)
+    if err != nil {
+      return nil, err
+    }
+    if m, ok := doc.(map[string]any); ok && m["openapi"] != nil {
+      files.filepaths = append(files.filepaths, p)
+    }
+  }
+  sort.Strings(files.filepaths)
+
+  return files, nil
+}
+
+func newOpenAPIFiles(
+  filepaths []string,
+  accessor  Accessor,
+) *OpenAPIFiles {
+  return &OpenAPIFiles{
+    filepaths : filepaths,
+    resolver  : newResolver(accessor),
+  }
+}
+
+// ParseSchemaFiles - Loads every root document, resolves their $refs and
+// compiles the resulting graph.
+func(of *OpenAPIFiles) ParseSchemaFiles(
+  ctx context.Context,
+) error {
+  compiler := newOpenAPIGraphCompiler(of.resolver)
+
+  for _, file := range of.filepaths {
+    if err := ctx.Err(); err != nil {
+      return err
+    }
+    if err := compiler.compileDocument(file); err != nil {
+      return fmt.Errorf("failed to compile \"%s\": %w", file, err)
+    }
+  }
+
+  of.graph = compiler.graph
+  return nil
+}
+
+// Graph -- Returns the compiled graph.
+func(of *OpenAPIFiles) Graph()( *domain.SchemaGraph, error ){
+  if of.graph == nil {
+    return nil, ErrNotParsed
+  }
+  return of.graph, nil
+}
+
+// Cyphers -- Compiles the graph into parameterized, batched Cypher statements.
+func(of *OpenAPIFiles) Cyphers()( []domain.CypherStatement, error ){
+  graph, err := of.Graph()
+  if err != nil {
+    return nil, err
+  }
+  return cypher.NewWriter(cypher.DefaultBatchSize).Statements(graph)
+}
@@ -0,0 +1,211 @@
+package openapi
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Accessor defines a function that opens a document by its path.
+type Accessor func(path string)( io.ReadCloser, error )
+
+// maxRefDepth - The longest chain of $refs pointing at $refs we'll follow.
+const maxRefDepth = 32
+
+// location -- Identifies a value within a loaded document via JSON Pointer.
+type location struct {
+  file    string
+  pointer string
+}
+
+// key - Stable identifier used as graph node key.
+func(l location) key() string {
+  return l.file + "#" + l.pointer
+}
+
+// child - Returns the location of 'tokens' below l.
+func(l location) child(tokens ...string) location {
+  p := l.pointer
+  for _, t := range tokens {
+    p += "/" + escapePointer(t)
+  }
+  return location{ l.file, p }
+}
+
+// resolver -- Loads documents on demand and resolves $refs between them.
+type resolver struct {
+  accessor Accessor
+  docs     map[string]any
+}
+
+func newResolver(accessor Accessor) *resolver {
+  return &resolver{
+    accessor : accessor,
+    docs     : map[string]any{},
+  }
+}
+
+// load - Returns the parsed document at 'file', loading it on first use.
+// Both JSON and YAML are accepted, since JSON is valid YAML.
+func(r *resolver) load(file string)( any, error ){
+  if doc, ok := r.docs[file]; ok {
+    return doc, nil
+  }
+
+  rc, err := r.accessor(file)
+  if err != nil {
+    return nil, fmt.Errorf("%w: %s: %s", ErrDocumentLoadFailed, file, err.Error())
+  }
+  defer rc.Close()
+
+  data, err := io.ReadAll(rc)
+  if err != nil {
+    return nil, fmt.Errorf("%w: %s: %s", ErrDocumentLoadFailed, file, err.Error())
+  }
+
+  var raw any
+  if err := yaml.Unmarshal(data, &raw); err != nil {
+    return nil, fmt.Errorf("%w: %s: %s", ErrDocumentParseFailed, file, err.Error())
+  }
+
+  doc := normalize(raw)
+  r.docs[file] = doc
+  return doc, nil
+}
+
+// resolve - Resolves 'ref', found in 'from', following chained $refs. Returns
+// the final location and the value stored there.
+//
+// Potential Errors:
+//    - ErrUnresolvedRef
+//    - ErrRemoteRefUnsupported
+//    - ErrCircularRef
+func(r *resolver) resolve(
+  from string,
+  ref  string,
+)( location, any, error ){
+  for depth := 0; depth < maxRefDepth; depth++ {
+    loc, err := refLocation(from, ref)
+    if err != nil {
+      return location{}, nil, err
+    }
+    value, err := r.lookup(loc)
+    if err != nil {
+      return location{}, nil, err
+    }
+
+    next, ok := refOf(value)
+    if !ok {
+      return loc, value, nil
+    }
+    from, ref = loc.file, next
+  }
+
+  return location{}, nil, fmt.Errorf("%w: %s", ErrCircularRef, ref)
+}
+
+// lookup - Returns the value stored at 'loc'.
+func(r *resolver) lookup(loc location)( any, error ){
+  value, err := r.load(loc.file)
+  if err != nil {
+    return nil, err
+  }
+  if loc.pointer == "" {
+    return value, nil
+  }
+
+  for _, token := range strings.Split(strings.TrimPrefix(loc.pointer, "/"), "/") {
+    token = unescapePointer(token)
+    switch v := value.(type) {
+    case map[string]any:
+      next, ok := v[token]
+      if !ok {
+        return nil, fmt.Errorf("%w: %s", ErrUnresolvedRef, loc.key())
+      }
+      value = next
+    case []any:
+      idx, err := strconv.Atoi(token)
+      if err != nil || idx < 0 || idx >= len(v) {
+        return nil, fmt.Errorf("%w: %s", ErrUnresolvedRef, loc.key())
+      }
+      value = v[idx]
+    default:
+      return nil, fmt.Errorf("%w: %s", ErrUnresolvedRef, loc.key())
+    }
+  }
+  return value, nil
+}
+
+// refLocation - Converts a $ref, relative to the document it was found in,
+// into a location.
+func refLocation(
+  from string,
+  ref  string,
+)( location, error ){
+  u, err := url.Parse(ref)
+  if err != nil {
+    return location{}, fmt.Errorf("%w: %s: %s", ErrUnresolvedRef, ref, err.Error())
+  }
+  if u.Scheme != "" || u.Host != "" {
+    return location{}, fmt.Errorf("%w: %s", ErrRemoteRefUnsupported, ref)
+  }
+
+  file := from
+  if u.Path != "" {
+    file = path.Join(path.Dir(from), u.Path)
+  }
+  pointer := u.Fragment
+  if pointer != "" && !strings.HasPrefix(pointer, "/") {
+    return location{}, fmt.Errorf("%w: %s", ErrUnresolvedRef, ref)
+  }
+
+  return location{ file, pointer }, nil
+}
+
+// refOf - Returns the $ref held by 'value', if any.
+func refOf(value any)( string, bool ){
+  m, ok := value.(map[string]any)
+  if !ok {
+    return "", false
+  }
+  ref, ok := m["$ref"].(string)
+  return ref, ok
+}
+
+// normalize - yaml.v3 decodes mappings with non-string keys, like response
+// status codes, into map[any]any. Converts every mapping into map[string]any.
+func normalize(value any) any {
+  switch v := value.(type) {
+  case map[string]any:
+    for k, item := range v {
+      v[k] = normalize(item)
+    }
+    return v
+  case map[any]any:
+    m := make(map[string]any, len(v))
+    for k, item := range v {
+      m[fmt.Sprint(k)] = normalize(item)
+    }
+    return m
+  case []any:
+    for i, item := range v {
+      v[i] = normalize(item)
+    }
+    return v
+  default:
+    return v
+  }
+}
+
+func escapePointer(token string) string {
+  return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func unescapePointer(token string) string {
+  return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
@@ -0,0 +1,547 @@
+package proto
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bufbuild/protocompile/linker"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+)
+
+// BreakingRule defines a single check performed between two compiled
+// versions of a proto schema set.
+type BreakingRule struct {
+  ID       string
+  Category domain.BreakingCategory
+  Severity domain.Severity
+}
+
+var (
+  RulePackageNoDelete           = BreakingRule{ "PACKAGE_NO_DELETE",             domain.BreakingWire,   domain.SeverityError   }
+  RuleFileSamePackage           = BreakingRule{ "FILE_SAME_PACKAGE",             domain.BreakingWire,   domain.SeverityError   }
+  RuleMessageNoDelete           = BreakingRule{ "MESSAGE_NO_DELETE",             domain.BreakingSource, domain.SeverityError   }
+  RuleFieldNoDelete             = BreakingRule{ "FIELD_NO_DELETE",               domain.BreakingWire,   domain.SeverityError   }
+  RuleFieldNoDeleteReserved     = BreakingRule{ "FIELD_NO_DELETE_RESERVED",      domain.BreakingSource, domain.SeverityWarning }
+  RuleFieldSameNumber           = BreakingRule{ "FIELD_SAME_NUMBER",             domain.BreakingWire,   domain.SeverityError   }
+  RuleFieldSameName             = BreakingRule{ "FIELD_SAME_NAME",               domain.BreakingSource, domain.SeverityWarning }
+  RuleFieldSameType             = BreakingRule{ "FIELD_SAME_TYPE",               domain.BreakingWire,   domain.SeverityError   }
+  RuleFieldSameCardinality      = BreakingRule{ "FIELD_SAME_CARDINALITY",        domain.BreakingWire,   domain.SeverityError   }
+  RuleFieldSameOneof            = BreakingRule{ "FIELD_SAME_ONEOF",              domain.BreakingWire,   domain.SeverityError   }
+  RuleFieldNumberReused         = BreakingRule{ "FIELD_NUMBER_REUSED",           domain.BreakingWire,   domain.SeverityError   }
+  RuleFieldSameDefault          = BreakingRule{ "FIELD_SAME_DEFAULT",            domain.BreakingWire,   domain.SeverityError   }
+  RuleFieldSameEncoding         = BreakingRule{ "FIELD_SAME_ENCODING",           domain.BreakingWire,   domain.SeverityError   }
+  RuleFieldSamePresence         = BreakingRule{ "FIELD_SAME_PRESENCE",           domain.BreakingSource, domain.SeverityWarning }
+  RuleExtensionNoDelete         = BreakingRule{ "EXTENSION_NO_DELETE",           domain.BreakingSource, domain.SeverityError   }
+  RuleEnumNoDelete              = BreakingRule{ "ENUM_NO_DELETE",                domain.BreakingSource, domain.SeverityError   }
+  RuleEnumValueNoDelete         = BreakingRule{ "ENUM_VALUE_NO_DELETE",          domain.BreakingWire,   domain.SeverityError   }
+  RuleEnumValueNoDeleteReserved = BreakingRule{ "ENUM_VALUE_NO_DELETE_RESERVED", domain.BreakingSource, domain.SeverityWarning }
+  RuleEnumValueSameName         = BreakingRule{ "ENUM_VALUE_SAME_NAME",          domain.BreakingSource, domain.SeverityWarning }
+  RuleEnumValueNumberReused     = BreakingRule{ "ENUM_VALUE_NUMBER_REUSED",      domain.BreakingWire,   domain.SeverityError   }
+  RuleEnumSameType              = BreakingRule{ "ENUM_SAME_TYPE",                domain.BreakingWire,   domain.SeverityError   }
+  RuleServiceNoDelete           = BreakingRule{ "SERVICE_NO_DELETE",             domain.BreakingWire,   domain.SeverityError   }
+  RuleRPCNoDelete               = BreakingRule{ "RPC_NO_DELETE",                 domain.BreakingWire,   domain.SeverityError   }
+  RuleRPCSameInputType          = BreakingRule{ "RPC_SAME_INPUT_TYPE",           domain.BreakingWire,   domain.SeverityError   }
+  RuleRPCSameOutputType         = BreakingRule{ "RPC_SAME_OUTPUT_TYPE",          domain.BreakingWire,   domain.SeverityError   }
+  RuleRPCSameClientStreaming    = BreakingRule{ "RPC_SAME_CLIENT_STREAMING",     domain.BreakingWire,   domain.SeverityError   }
+  RuleRPCSameServerStreaming    = BreakingRule{ "RPC_SAME_SERVER_STREAMING",     domain.BreakingWire,   domain.SeverityError   }
+)
+
+// descriptorIndex -- A flattened, name keyed view over a set of compiled files.
+type descriptorIndex struct {
+  packages map[string]bool
+  files    map[string]protoreflect.FileDescriptor
+  messages map[protoreflect.FullName]protoreflect.MessageDescriptor
+  enums    map[protoreflect.FullName]protoreflect.EnumDescriptor
+  services map[protoreflect.FullName]protoreflect.ServiceDescriptor
+  exts     map[protoreflect.FullName]protoreflect.ExtensionDescriptor
+}
+
+func newDescriptorIndex(files linker.Files) *descriptorIndex {
+  idx := &descriptorIndex{
+    packages : map[string]bool{},
+    files    : map[string]protoreflect.FileDescriptor{},
+    messages : map[protoreflect.FullName]protoreflect.MessageDescriptor{},
+    enums    : map[protoreflect.FullName]protoreflect.EnumDescriptor{},
+    services : map[protoreflect.FullName]protoreflect.ServiceDescriptor{},
+    exts     : map[protoreflect.FullName]protoreflect.ExtensionDescriptor{},
+  }
+
+  var walkMessages func(msgs protoreflect.MessageDescriptors)
+  walkMessages = func(msgs protoreflect.MessageDescriptors){
+    for i := 0; i < msgs.Len(); i++ {
+      msg := msgs.Get(i)
+      if msg.IsMapEntry() {
+        continue
+      }
+      idx.messages[msg.FullName()] = msg
+      for j := 0; j < msg.Enums().Len(); j++ {
+        idx.enums[msg.Enums().Get(j).FullName()] = msg.Enums().Get(j)
+      }
+      for j := 0; j < msg.Extensions().Len(); j++ {
+        idx.exts[msg.Extensions().Get(j).FullName()] = msg.Extensions().Get(j)
+      }
+      walkMessages(msg.Messages())
+    }
+  }
+
+  for _, file := range files {
+    idx.packages[string(file.Package())] = true
+    idx.files[file.Path()] = file
+    walkMessages(file.Messages())
+    for i := 0; i < file.Enums().Len(); i++ {
+      idx.enums[file.Enums().Get(i).FullName()] = file.Enums().Get(i)
+    }
+    for i := 0; i < file.Services().Len(); i++ {
+      idx.services[file.Services().Get(i).FullName()] = file.Services().Get(i)
+    }
+    for i := 0; i < file.Extensions().Len(); i++ {
+      idx.exts[file.Extensions().Get(i).FullName()] = file.Extensions().Get(i)
+    }
+  }
+
+  return idx
+}
+
+// breakingReport -- Collects BreakingChanges while comparing two descriptorIndexes.
+type breakingReport struct {
+  changes []domain.BreakingChange
+}
+
+func(r *breakingReport) add(
+  rule BreakingRule,
+  desc protoreflect.Descriptor,
+  f    string,
+  args ...any,
+) {
+  change := domain.BreakingChange{
+    RuleID   : rule.ID,
+    Category : rule.Category,
+    Severity : rule.Severity,
+    Path     : string(desc.FullName()),
+    Message  : fmt.Sprintf(f, args...),
+  }
+  if file := desc.ParentFile(); file != nil {
+    change.File = file.Path()
+    loc := file.SourceLocations().ByDescriptor(desc)
+    if loc.Path != nil {
+      change.Line   = loc.StartLine + 1
+      change.Column = loc.StartColumn + 1
+    }
+  }
+  r.changes = append(r.changes, change)
+}
+
+// DetectBreakingChanges - Compares two compiled versions of the same proto
+// schema set and returns every wire and source breaking change found when
+// moving from 'previous' to 'next'. Results are sorted by file, line and rule.
+func DetectBreakingChanges(
+  previous linker.Files,
+  next     linker.Files,
+) []domain.BreakingChange {
+  prev := newDescriptorIndex(previous)
+  curr := newDescriptorIndex(next)
+  report := &breakingReport{}
+
+  compareFiles(report, prev, curr)
+  compareMessages(report, prev, curr)
+  compareEnums(report, prev, curr)
+  compareServices(report, prev, curr)
+  compareExtensions(report, prev, curr)
+
+  sort.SliceStable(report.changes, func(i, j int) bool {
+    a, b := report.changes[i], report.changes[j]
+    if a.File != b.File {
+      return a.File < b.File
+    }
+    if a.Line != b.Line {
+      return a.Line < b.Line
+    }
+    if a.RuleID != b.RuleID {
+      return a.RuleID < b.RuleID
+    }
+    return a.Path < b.Path
+  })
+  return report.changes
+}
+
+// compareFiles -- Detects removed packages and files that changed package.
+func compareFiles(r *breakingReport, prev, curr *descriptorIndex) {
+  renamed := map[string]bool{}
+  for path, oldFile := range prev.files {
+    newFile, ok := curr.files[path]
+    if !ok || oldFile.Package() == newFile.Package() {
+      continue
+    }
+    renamed[string(oldFile.Package())] = true
+    r.add(RuleFileSamePackage, newFile,
+      "file %q changed package from %q to %q",
+      path,
+      oldFile.Package(),
+      newFile.Package(),
+    )
+  }
+
+  for pkg := range prev.packages {
+    if curr.packages[pkg] || renamed[pkg] {
+      continue
+    }
+    var first protoreflect.FileDescriptor
+    for path, file := range prev.files {
+      if string(file.Package()) == pkg && (first == nil || path < first.Path()) {
+        first = file
+      }
+    }
+    r.add(RulePackageNoDelete, first,
+      "package %q was deleted",
+      pkg,
+    )
+  }
+}
+
+func compareMessages(r *breakingReport, prev, curr *descriptorIndex) {
+  for name, oldMsg := range prev.messages {
+    newMsg, ok := curr.messages[name]
+    if !ok {
+      // ->> A deleted package is already reported once.
+      if curr.packages[string(oldMsg.ParentFile().Package())] {
+        r.add(RuleMessageNoDelete, oldMsg,
+          "message %q was deleted",
+          name,
+        )
+      }
+      continue
+    }
+    compareFields(r, oldMsg, newMsg)
+  }
+}
+
+func compareFields(r *breakingReport, oldMsg, newMsg protoreflect.MessageDescriptor) {
+  oldFields := oldMsg.Fields()
+  newFields := newMsg.Fields()
+
+  for i := 0; i < oldFields.Len(); i++ {
+    oldField := oldFields.Get(i)
+    newField := newFields.ByNumber(oldField.Number())
+
+    if newField == nil {
+      if renumbered := newFields.ByName(oldField.Name()); renumbered != nil {
+        r.add(RuleFieldSameNumber, renumbered,
+          "field %q changed number from %d to %d",
+          oldField.Name(),
+          oldField.Number(),
+          renumbered.Number(),
+        )
+        continue
+      }
+      if newMsg.ReservedRanges().Has(oldField.Number()) {
+        r.add(RuleFieldNoDeleteReserved, newMsg,
+          "field %d %q was deleted; its number is reserved",
+          oldField.Number(),
+          oldField.Name(),
+        )
+        continue
+      }
+      r.add(RuleFieldNoDelete, newMsg,
+        "field %d %q was deleted without reserving its number",
+        oldField.Number(),
+        oldField.Name(),
+      )
+      continue
+    }
+
+    if oldField.Name() != newField.Name() {
+      r.add(RuleFieldSameName, newField,
+        "field %d changed name from %q to %q",
+        oldField.Number(),
+        oldField.Name(),
+        newField.Name(),
+      )
+    }
+    compareField(r, oldField, newField)
+  }
+
+  // ->> Numbers reserved by the previous version must stay unused.
+  for i := 0; i < newFields.Len(); i++ {
+    newField := newFields.Get(i)
+    if oldFields.ByNumber(newField.Number()) != nil {
+      continue
+    }
+    if oldMsg.ReservedRanges().Has(newField.Number()) {
+      r.add(RuleFieldNumberReused, newField,
+        "field %q reuses reserved number %d",
+        newField.Name(),
+        newField.Number(),
+      )
+    }
+  }
+}
+
+// compareField -- Compares the wire and source shape of a single field, or
+// extension, present in both versions. Presence, defaults and delimited
+// encoding only vary between proto2, editions and proto3 'optional' fields.
+func compareField(r *breakingReport, oldField, newField protoreflect.FieldDescriptor) {
+  if oldType, newType := fieldTypeName(oldField), fieldTypeName(newField); oldType != newType {
+    r.add(RuleFieldSameType, newField,
+      "field %d %q changed type from %q to %q",
+      newField.Number(),
+      newField.Name(),
+      oldType,
+      newType,
+    )
+  } else if oldEnc, newEnc := fieldEncoding(oldField), fieldEncoding(newField); oldEnc != newEnc {
+    r.add(RuleFieldSameEncoding, newField,
+      "field %d %q changed message encoding from %q to %q",
+      newField.Number(),
+      newField.Name(),
+      oldEnc,
+      newEnc,
+    )
+  }
+  if oldCard, newCard := fieldCardinality(oldField), fieldCardinality(newField); oldCard != newCard {
+    r.add(RuleFieldSameCardinality, newField,
+      "field %d %q changed cardinality from %q to %q",
+      newField.Number(),
+      newField.Name(),
+      oldCard,
+      newCard,
+    )
+  } else if oldPres, newPres := fieldPresence(oldField), fieldPresence(newField); oldPres != newPres {
+    r.add(RuleFieldSamePresence, newField,
+      "field %d %q changed presence from %q to %q",
+      newField.Number(),
+      newField.Name(),
+      oldPres,
+      newPres,
+    )
+  }
+  if oldDef, newDef := fieldDefault(oldField), fieldDefault(newField); oldDef != newDef {
+    r.add(RuleFieldSameDefault, newField,
+      "field %d %q changed default from %q to %q",
+      newField.Number(),
+      newField.Name(),
+      oldDef,
+      newDef,
+    )
+  }
+  if oldOneof, newOneof := oneofName(oldField), oneofName(newField); oldOneof != newOneof {
+    r.add(RuleFieldSameOneof, newField,
+      "field %d %q moved from oneof %q to oneof %q",
+      newField.Number(),
+      newField.Name(),
+      oldOneof,
+      newOneof,
+    )
+  }
+}
+
+func compareExtensions(r *breakingReport, prev, curr *descriptorIndex) {
+  for name, oldExt := range prev.exts {
+    newExt, ok := curr.exts[name]
+    if !ok {
+      if curr.packages[string(oldExt.ParentFile().Package())] {
+        r.add(RuleExtensionNoDelete, oldExt,
+          "extension %q of %q was deleted",
+          name,
+          oldExt.ContainingMessage().FullName(),
+        )
+      }
+      continue
+    }
+    if oldExt.Number() != newExt.Number() {
+      r.add(RuleFieldSameNumber, newExt,
+        "extension %q changed number from %d to %d",
+        name,
+        oldExt.Number(),
+        newExt.Number(),
+      )
+    }
+    compareField(r, oldExt, newExt)
+  }
+}
+
+func compareEnums(r *breakingReport, prev, curr *descriptorIndex) {
+  for name, oldEnum := range prev.enums {
+    newEnum, ok := curr.enums[name]
+    if !ok {
+      if curr.packages[string(oldEnum.ParentFile().Package())] {
+        r.add(RuleEnumNoDelete, oldEnum,
+          "enum %q was deleted",
+          name,
+        )
+      }
+      continue
+    }
+
+    if oldEnum.IsClosed() != newEnum.IsClosed() {
+      r.add(RuleEnumSameType, newEnum,
+        "enum %q changed from %s to %s",
+        name,
+        enumType(oldEnum),
+        enumType(newEnum),
+      )
+    }
+
+    oldValues := oldEnum.Values()
+    newValues := newEnum.Values()
+    for i := 0; i < oldValues.Len(); i++ {
+      oldValue := oldValues.Get(i)
+      newValue := newValues.ByNumber(oldValue.Number())
+      if newValue == nil {
+        if newEnum.ReservedRanges().Has(oldValue.Number()) {
+          r.add(RuleEnumValueNoDeleteReserved, newEnum,
+            "enum value %d %q was deleted; its number is reserved",
+            oldValue.Number(),
+            oldValue.Name(),
+          )
+          continue
+        }
+        r.add(RuleEnumValueNoDelete, newEnum,
+          "enum value %d %q was deleted without reserving its number",
+          oldValue.Number(),
+          oldValue.Name(),
+        )
+        continue
+      }
+      if newValues.ByName(oldValue.Name()) == nil {
+        r.add(RuleEnumValueSameName, newValue,
+          "enum value %d changed name from %q to %q",
+          oldValue.Number(),
+          oldValue.Name(),
+          newValue.Name(),
+        )
+      }
+    }
+
+    for i := 0; i < newValues.Len(); i++ {
+      newValue := newValues.Get(i)
+      if oldValues.ByNumber(newValue.Number()) != nil {
+        continue
+      }
+      if oldEnum.ReservedRanges().Has(newValue.Number()) {
+        r.add(RuleEnumValueNumberReused, newValue,
+          "enum value %q reuses reserved number %d",
+          newValue.Name(),
+          newValue.Number(),
+        )
+      }
+    }
+  }
+}
+
+func compareServices(r *breakingReport, prev, curr *descriptorIndex) {
+  for name, oldSvc := range prev.services {
+    newSvc, ok := curr.services[name]
+    if !ok {
+      if curr.packages[string(oldSvc.ParentFile().Package())] {
+        r.add(RuleServiceNoDelete, oldSvc,
+          "service %q was deleted",
+          name,
+        )
+      }
+      continue
+    }
+
+    for i := 0; i < oldSvc.Methods().Len(); i++ {
+      oldMethod := oldSvc.Methods().Get(i)
+      newMethod := newSvc.Methods().ByName(oldMethod.Name())
+      if newMethod == nil {
+        r.add(RuleRPCNoDelete, newSvc,
+          "rpc %q was deleted from service %q",
+          oldMethod.Name(),
+          name,
+        )
+        continue
+      }
+      if oldMethod.Input().FullName() != newMethod.Input().FullName() {
+        r.add(RuleRPCSameInputType, newMethod,
+          "rpc %q changed input type from %q to %q",
+          newMethod.Name(),
+          oldMethod.Input().FullName(),
+          newMethod.Input().FullName(),
+        )
+      }
+      if oldMethod.Output().FullName() != newMethod.Output().FullName() {
+        r.add(RuleRPCSameOutputType, newMethod,
+          "rpc %q changed output type from %q to %q",
+          newMethod.Name(),
+          oldMethod.Output().FullName(),
+          newMethod.Output().FullName(),
+        )
+      }
+      if oldMethod.IsStreamingClient() != newMethod.IsStreamingClient() {
+        r.add(RuleRPCSameClientStreaming, newMethod,
+          "rpc %q changed client streaming from %t to %t",
+          newMethod.Name(),
+          oldMethod.IsStreamingClient(),
+          newMethod.IsStreamingClient(),
+        )
+      }
+      if oldMethod.IsStreamingServer() != newMethod.IsStreamingServer() {
+        r.add(RuleRPCSameServerStreaming, newMethod,
+          "rpc %q changed server streaming from %t to %t",
+          newMethod.Name(),
+          oldMethod.IsStreamingServer(),
+          newMethod.IsStreamingServer(),
+        )
+      }
+    }
+  }
+}
+
+// fieldTypeName -- Returns a comparable type name for a field. Message and
+// Enum fields resolve to their fully-qualified type names.
+func fieldTypeName(field protoreflect.FieldDescriptor) string {
+  if field.IsMap() {
+    return fmt.Sprintf(
+      "map<%s, %s>",
+      fieldTypeName(field.MapKey()),
+      fieldTypeName(field.MapValue()),
+    )
+  }
+  switch field.Kind() {
+  case protoreflect.MessageKind, protoreflect.GroupKind:
+    return string(field.Message().FullName())
+  case protoreflect.EnumKind:
+    return string(field.Enum().FullName())
+  default:
+    return field.Kind().String()
+  }
+}
+
+func fieldCardinality(field protoreflect.FieldDescriptor) string {
+  if field.IsMap() {
+    return "map"
+  }
+  return field.Cardinality().String()
+}
+
+func fieldEncoding(field protoreflect.FieldDescriptor) string {
+  if field.Kind() == protoreflect.GroupKind {
+    return "delimited"
+  }
+  return "length_prefixed"
+}
+
+func fieldDefault(field protoreflect.FieldDescriptor) string {
+  if !field.HasDefault() {
+    return ""
+  }
+  return formatScalar(field, field.Default())
+}
+
+func enumType(enum protoreflect.EnumDescriptor) string {
+  if enum.IsClosed() {
+    return "closed"
+  }
+  return "open"
+}
+
+func oneofName(field protoreflect.FieldDescriptor) string {
+  oneof := field.ContainingOneof()
+  if oneof == nil || oneof.IsSynthetic() {
+    return ""
+  }
+  return string(oneof.Name())
+}
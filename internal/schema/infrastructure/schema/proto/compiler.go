@@ -1,546 +1,820 @@
 package proto
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/bufbuild/protocompile/linker"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
-)
 
-type ProtoPortion string
-const (
-  ProtoMeta      ProtoPortion = "metadata"
-  ProtoEnums     ProtoPortion = "enums"
-  ProtoMsgDefs   ProtoPortion = "msgDefs"
-  ProtoMsgParams ProtoPortion = "msgParams"
-  ProtoSvcDefs   ProtoPortion = "svcDefs"
-  ProtoSvcParams ProtoPortion = "svcParams"
-  ProtoRelations ProtoPortion = "relations"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
 )
 
-type ProtoCypherCompiler struct { 
-  builders map[ProtoPortion]*strings.Builder
-  metadata ProtoMetadata
-  wg       sync.WaitGroup
+// ProtoGraphCompiler compiles a single proto file into a domain.SchemaGraph.
+// Every node is keyed by its fully-qualified proto name, so keys stay stable
+// across uploads and identical between the files that reference them. When
+// compiled WithNamespace, tenant owned keys are prefixed with the namespace.
+type ProtoGraphCompiler struct {
+  graph *domain.SchemaGraph
+  opts  compileOptions
 }
 
-func NewProtoCypherCompiler() *ProtoCypherCompiler {
-  builders := map[ProtoPortion]*strings.Builder{
-    ProtoMeta      : new(strings.Builder),
-    ProtoEnums     : new(strings.Builder),
-    ProtoMsgDefs   : new(strings.Builder),
-    ProtoMsgParams : new(strings.Builder),
-    ProtoSvcDefs   : new(strings.Builder),
-    ProtoSvcParams : new(strings.Builder),
-    ProtoRelations : new(strings.Builder),
-  }
+func NewProtoGraphCompiler(opts ...CompileOption) *ProtoGraphCompiler {
+  return newProtoGraphCompiler(newCompileOptions(opts))
+}
 
-  return &ProtoCypherCompiler{
-    builders : builders,
-    wg       : sync.WaitGroup{},
+func newProtoGraphCompiler(opts compileOptions) *ProtoGraphCompiler {
+  return &ProtoGraphCompiler{
+    graph : &domain.SchemaGraph{},
+    opts  : opts,
   }
 }
 
-func(c *ProtoCypherCompiler) Run(
+// Graph - Returns the compiled graph.
+func(c *ProtoGraphCompiler) Graph() *domain.SchemaGraph {
+  return c.graph
+}
+
+func(c *ProtoGraphCompiler) Run(
   dep *ProtoMetadata,
 ) error {
   file    := dep.File
   pkgName := dep.PkgName
-  // imports := dep.Imports
-  ver, pkg, err := extractVersion(pkgName)
+  pkg, version, err := c.opts.versioning.Resolve(pkgName)
   if err != nil {
-    return err
+    // ->> Shared packages, e.g. google.protobuf, aren't versioned.
+    if !dep.Shared {
+      return fmt.Errorf("package %q in %q: %w", pkgName, dep.Path, err)
+    }
+    pkg, version = pkgName, domain.PackageVersion{}
   }
-  enums    := []string{}
-  messages := []string{}
-  services := []string{}
+  ver   := version.String()
+  first := len(c.graph.Nodes)
 
-  c.compileMetadata(file, ver, pkg, dep.Imports)
-  c.compileEnums(file, ver, pkg, &enums)
-  c.compileMessageDefinitions(file, ver, pkg, &messages)
-  c.compileMessageParams(file, ver, pkg, )
-  c.compileServiceDefinitions(file, ver, pkg, &services)
+  messages, enums, extensions := walkDescriptors(file)
+
+  c.compileMetadata(file, version, pkg, dep.Imports)
+  c.compileEnums(file, enums, ver, pkg)
+  c.compileMessageDefinitions(file, messages, ver, pkg)
+  c.compileMessageParams(file, messages, ver, pkg)
+  c.compileExtensions(file, extensions, ver, pkg)
+  c.compileServiceDefinitions(file, ver, pkg)
   c.compileServiceParams(file, ver, pkg)
 
-  c.wg.Wait()
-  for _, enum := range enums {
-    appendQuery(c.builders[ProtoRelations],
-      `(%s)-[:DEFINED_IN]->(%s),`,
-      enum,
-      pkgName,
-    )
-  }
-  for _, msg := range messages {
-    appendQuery(c.builders[ProtoRelations],
-      `(%s)-[:DEFINED_IN]->(%s),`,
-      msg,
-      pkgName,
-    )
-  }
-  for _, svc := range services {
-    appendQuery(c.builders[ProtoRelations],
-      `(%s)-[:DEFINED_IN]->(%s),`,
-      svc,
-      pkgName,
-    )
-  }
-  for _, imp := range dep.Imports {
-    appendQuery(c.builders[ProtoRelations],
-    `(%s)-[:IMPORTS]->(%s);`,
-      pkgName,
-      imp,
-    )
+  // ->> Everything a tenant file defines is rewritten along with its
+  //     Package. Shared files are referenced by every tenant, so they're
+  //     only ever merged.
+  if !c.opts.isShared(file.Path()) {
+    owner := c.owner(file)
+    for i := first; i < len(c.graph.Nodes); i++ {
+      c.graph.Nodes[i].Owner = owner
+    }
   }
-
   return nil
 }
 
-
-func(c *ProtoCypherCompiler) compileMetadata(
-  file linker.File,
-  ver  string,
-  pkg  string,
-  imps []string,
+func(c *ProtoGraphCompiler) compileMetadata(
+  file    linker.File,
+  version domain.PackageVersion,
+  pkg     string,
+  imps    []string,
 ) {
-  c.wg.Add(1)
-  go func(){
-    defer c.wg.Done()
-    // pkgKey  := versionedKey(ver, pkg)
-    pkgName := string(file.Package())
-    syntax := file.Syntax().String()
-    imports := ""
-    if len(imps) != 0 {
-      imports = strings.Join(imps, ", ")
+  pkgName := string(file.Package())
+  pkgKey  := c.packageKey(file)
+  imports := append([]string{}, imps...)
+
+  c.graph.AddNode(domain.LabelPackage, pkgKey, withLocation(file, packageLocation(file), map[string]any{
+    "name"    : pkg,
+    "package" : pkgName,
+    "version"       : version.String(),
+    "versionMajor"  : version.Major,
+    "versionMinor"  : version.Minor,
+    "versionPatch"  : version.Patch,
+    "stability"     : string(version.Stability),
+    "stabilityRank" : version.Stability.Rank(),
+    "prerelease"    : version.Prerelease,
+    "syntax"        : file.Syntax().String(),
+    "edition"       : fileEdition(file),
+    "imports"       : imports,
+    "shared"        : c.opts.isShared(file.Path()),
+  }))
+
+  linked := map[string]bool{}
+  for i := 0; i < file.Imports().Len(); i++ {
+    impKey := c.packageKey(file.Imports().Get(i).FileDescriptor)
+    if impKey == pkgKey || linked[impKey] {
+      continue
     }
-
-    appendQuery(c.builders[ProtoMeta],
-`(%s:Package {
-  name: "%s",
-  package: "%s",
-  syntax: "%s",
-  imports: "%s"
-}),`,
-      pkgName,
-      pkg,
-      pkgName,
-      syntax,
-      imports,
+    linked[impKey] = true
+    c.graph.AddEdge(
+      domain.EdgeImports,
+      domain.LabelPackage, pkgKey,
+      domain.LabelPackage, impKey,
     )
-  }()
+  }
 }
 
-// compileEnums takes all parsed proto eneum data and compiles cypher queries
-// for both enum nodes and relations.
-func(c *ProtoCypherCompiler) compileEnums(
-  file     linker.File,
-  ver      string,
-  pkg      string,
-  enumKeys *[]string,
+// compileEnums takes all parsed proto enum data and compiles graph nodes
+// for both enums, their values and alias relationships.
+func(c *ProtoGraphCompiler) compileEnums(
+  file  linker.File,
+  enums []protoreflect.EnumDescriptor,
+  ver   string,
+  pkg   string,
 ){
-  c.wg.Add(1)
-  go func(){
-    defer c.wg.Done()
-    var (
-      enum       protoreflect.EnumDescriptor
-      enumValue  protoreflect.EnumValueDescriptor
-      opts       protoreflect.ProtoMessage
-      descriptor protoreflect.MessageDescriptor
-      usedIdxs   map[int32][]string
-      enumName   string
-      enumKey    string
-      valueName   string
-      valueNum   int32
-      valueKey   string
-      allowAlias bool
-      deprecated bool
-    )
-    enums := file.Enums()
+  pkgKey := c.packageKey(file)
 
-    for i := 0; i < enums.Len(); i++ {
+  for _, enum := range enums {
+    var (
+      enumKey    = c.key(enum)
       usedIdxs   = make(map[int32][]string)
-      enum       = enums.Get(i)
-      enumName   = string(enum.Name())
-      enumKey    = versionedKey(ver, enumName)
-      opts       = enum.Options()
+      order      = []int32{}
       allowAlias = false
       deprecated = false
-      *enumKeys  = append(*enumKeys, enumKey)
-
-      // Enum options: allow_alias or deprecated
-      if opts != nil {
-        descriptor = opts.ProtoReflect().Descriptor()
-        if field := descriptor.Fields().ByName("allow_alias"); field != nil {
-          allowAlias = opts.ProtoReflect().Get(field).Bool()
-        }
-        if field := descriptor.Fields().ByName("deprecated"); field != nil {
-          deprecated = opts.ProtoReflect().Get(field).Bool()
-        }
+    )
+
+    // Enum options: allow_alias or deprecated
+    if opts := enum.Options(); opts != nil {
+      descriptor := opts.ProtoReflect().Descriptor()
+      if field := descriptor.Fields().ByName("allow_alias"); field != nil {
+        allowAlias = opts.ProtoReflect().Get(field).Bool()
       }
-      appendQuery(c.builders[ProtoEnums],
-`(%s:Enum {
-  package: "%s",
-  name: "%s",
-  version: "%s",
-  allowAlias: %t,
-  deprecated: %t
-}),`,
-        enumKey,
-        pkg,
-        enumName,
-        ver,
-        allowAlias,
-        deprecated,
+      if field := descriptor.Fields().ByName("deprecated"); field != nil {
+        deprecated = opts.ProtoReflect().Get(field).Bool()
+      }
+    }
+    c.graph.AddNode(domain.LabelEnum, enumKey, withSource(enum, map[string]any{
+      "package"    : pkg,
+      "name"       : string(enum.Name()),
+      "version"    : ver,
+      "allowAlias" : allowAlias,
+      "deprecated" : deprecated,
+      "closed"     : enum.IsClosed(),
+    }))
+    c.graph.AddEdge(
+      domain.EdgeDefinedIn,
+      domain.LabelEnum, enumKey,
+      domain.LabelPackage, pkgKey,
+    )
+    if parent, ok := enum.Parent().(protoreflect.MessageDescriptor); ok {
+      c.graph.AddEdge(
+        domain.EdgeNestedIn,
+        domain.LabelEnum, enumKey,
+        domain.LabelMessage, c.key(parent),
       )
+    }
 
-      for j := 0; j < enum.Values().Len(); j++ {
-        enumValue   = enum.Values().Get(j)
-        valueName   = string(enumValue.Name())
-        valueNum    = int32(enumValue.Number())
-        valueKey    = versionedKey(ver, enumName, valueName)
-
-        if _, ok := usedIdxs[valueNum]; !ok {
-          usedIdxs[valueNum] = []string{valueKey}
-        } else {
-          usedIdxs[valueNum] = append(usedIdxs[valueNum], valueKey)
-        }
-
-        appendQuery(c.builders[ProtoEnums],
-`(%s:EnumValue {
-  name: "%s",
-  number: %d
-}),`,
-        valueKey,
-        valueName,
-        valueNum,
-        )
+    for j := 0; j < enum.Values().Len(); j++ {
+      var (
+        value    = enum.Values().Get(j)
+        valueNum = int32(value.Number())
+        valueKey = c.enumValueKey(value)
+      )
+      if _, ok := usedIdxs[valueNum]; !ok {
+        order = append(order, valueNum)
       }
-      // Compile Aliased Enum Value Relationships
-      for _, v := range usedIdxs {
-        if len(v) == 1 {
-          continue
-        }
-        first := v[0]
-        for _, next := range v[1:] {
-          appendQuery(c.builders[ProtoEnums],
-            `(%s)-[:ALIAS]->(%s),`,
-            next,
-            first,
-          )
-        }
+      usedIdxs[valueNum] = append(usedIdxs[valueNum], valueKey)
+
+      c.graph.AddNode(domain.LabelEnumValue, valueKey, withSource(value, map[string]any{
+        "name"   : string(value.Name()),
+        "number" : valueNum,
+      }))
+      c.graph.AddEdge(
+        domain.EdgeHasValue,
+        domain.LabelEnum, enumKey,
+        domain.LabelEnumValue, valueKey,
+      )
+    }
+
+    // Compile Aliased Enum Value Relationships
+    for _, num := range order {
+      v := usedIdxs[num]
+      for _, next := range v[1:] {
+        c.graph.AddEdge(
+          domain.EdgeAlias,
+          domain.LabelEnumValue, next,
+          domain.LabelEnumValue, v[0],
+        )
       }
     }
-  }()
+  }
 }
 
-func(c *ProtoCypherCompiler) compileMessageDefinitions(
-  file linker.File,
-  ver  string,
-  pkg  string,
-  msgs *[]string,
+func(c *ProtoGraphCompiler) compileMessageDefinitions(
+  file     linker.File,
+  messages []protoreflect.MessageDescriptor,
+  ver      string,
+  pkg      string,
 ){
-  c.wg.Add(1)
-  go func(){
-    defer c.wg.Done()
-    var (
-      descriptor  protoreflect.MessageDescriptor
-      msg         protoreflect.MessageDescriptor
-      opts        protoreflect.ProtoMessage
-      msgName     string
-      msgKey      string
-      deprecated  bool
-    )
+  pkgKey := c.packageKey(file)
 
-    messages := file.Messages()
-    for i := 0; i < messages.Len(); i++ {
-      msg        = messages.Get(i)
-      msgName    = string(msg.Name())
-      msgKey     = versionedKey(ver, msgName)
-      *msgs      = append(*msgs, msgKey)
-      opts       = msg.Options()
+  for _, msg := range messages {
+    var (
+      msgKey     = c.key(msg)
       deprecated = false
+    )
 
-      if opts != nil {
-        descriptor = opts.ProtoReflect().Descriptor()
-        if field := descriptor.Fields().ByName("deprecated"); field != nil {
-          deprecated = opts.ProtoReflect().Get(field).Bool()
-        }
+    if opts := msg.Options(); opts != nil {
+      descriptor := opts.ProtoReflect().Descriptor()
+      if field := descriptor.Fields().ByName("deprecated"); field != nil {
+        deprecated = opts.ProtoReflect().Get(field).Bool()
       }
-      appendQuery(c.builders[ProtoMsgDefs],
-`(%s:Message {
-  package: "%s",
-  version: "%s",
-  name: "%s",
-  deprecated: %t
-}),`,
-        msgKey,
-        pkg,
-        ver,
-        msgName,
-        deprecated,
+    }
+    c.graph.AddNode(domain.LabelMessage, msgKey, withSource(msg, map[string]any{
+      "package"    : pkg,
+      "version"    : ver,
+      "name"            : string(msg.Name()),
+      "deprecated"      : deprecated,
+      "extensionRanges" : extensionRanges(msg),
+    }))
+    c.graph.AddEdge(
+      domain.EdgeDefinedIn,
+      domain.LabelMessage, msgKey,
+      domain.LabelPackage, pkgKey,
+    )
+    if parent, ok := msg.Parent().(protoreflect.MessageDescriptor); ok {
+      c.graph.AddEdge(
+        domain.EdgeNestedIn,
+        domain.LabelMessage, msgKey,
+        domain.LabelMessage, c.key(parent),
       )
     }
-  }()
+  }
 }
 
-// compileMessageParams -- Compilse Cypher Queries that define Message Parameter
-// Nodes and Relationships.
-func(c *ProtoCypherCompiler) compileMessageParams(
-  file linker.File,
-  ver  string,
-  pkg  string,
+// compileMessageParams -- Compiles Message Parameter Nodes and their
+// Relationships to the Messages and Enums they use. Oneof groups become
+// Oneof Nodes linked to each of their member Parameters.
+func(c *ProtoGraphCompiler) compileMessageParams(
+  file     linker.File,
+  messages []protoreflect.MessageDescriptor,
+  ver      string,
+  pkg      string,
 ){
-  c.wg.Add(1)
-  go func(){
-    defer c.wg.Done()
-    var (
-      msg           protoreflect.MessageDescriptor
-      field         protoreflect.FieldDescriptor
-      paramKey      string
-      msgName       string
-      msgKey        string
-      fieldKind     string
-      fieldPackage  string
-      fieldName     string
-      tKey          string
-      tVal          string
-      fieldNum      int32
-      isRepeated    bool
-      isOptional    bool
-      isMap         bool
-    )
-    messages := file.Messages()
-
-    for i := 0; i < messages.Len(); i++ {
-      msg     = messages.Get(i)
-      msgName = string(msg.Name())
-      msgKey  = versionedKey(ver, msgName)
-      for j := 0; j < msg.Fields().Len(); j++ {
-        field         = msg.Fields().Get(j)
-        fieldKind     = field.Kind().String()
-        fieldName     = string(field.Name())
-        fieldNum      = int32(field.Number())
-        paramKey      = versionedKey(ver, msgName, fieldName)
-        isRepeated    = false
-        isOptional    = false
-
-        switch field.Cardinality() {
-        case protoreflect.Repeated:
-          isRepeated = true
-        case protoreflect.Optional:
-          isOptional = true
-        }
-
-        isMap = field.IsMap()
-        tKey  = ""
-        tVal  = ""
-        if isMap {
-          tKey = field.MapKey().Kind().String()
-          tVal = field.MapValue().Kind().String()
-          fieldKind = "map"
-        }
-
-        appendQuery(c.builders[ProtoMsgParams],
-`(%s:Parameter {
-  package: "%s",
-  message: "%s",
-  repeated: %t,
-  optional: %t,
-  field: "%s",
-  type: "%s",
-  number: %d,
-  tKey: "%s",
-  tVal: "%s"
-}),`,
-          paramKey,
-          pkg,
-          msg.Name(),
-          isRepeated,
-          isOptional,
-          fieldName,
-          fieldKind,
-          fieldNum,
-          tKey,
-          tVal,
+  pkgKey := c.packageKey(file)
+
+  for _, msg := range messages {
+    msgKey := c.key(msg)
+    c.compileOneofs(msg, ver, pkg)
+
+    for j := 0; j < msg.Fields().Len(); j++ {
+      field    := msg.Fields().Get(j)
+      paramKey := c.key(field)
+
+      props := fieldProps(field, ver, pkg)
+      props["message"] = string(msg.Name())
+      props["oneof"]   = oneofName(field)
+
+      c.graph.AddNode(domain.LabelParameter, paramKey, withSource(field, props))
+      c.graph.AddEdge(
+        domain.EdgeHasParameter,
+        domain.LabelMessage, msgKey,
+        domain.LabelParameter, paramKey,
+      )
+      if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+        c.graph.AddEdge(
+          domain.EdgeOneofField,
+          domain.LabelOneof, c.key(oneof),
+          domain.LabelParameter, paramKey,
         )
       }
-      appendQuery(c.builders[ProtoMsgParams],
-        `(%s)-[:HAS_PARAMTER]->(%s),`,
-        msgKey,
-        paramKey,
+      c.linkFieldType(domain.LabelParameter, paramKey, field, pkgKey)
+    }
+  }
+}
+
+// compileExtensions -- Compiles an Extension Node for every proto2/editions
+// extension declared in 'file', linked to the Message it extends.
+func(c *ProtoGraphCompiler) compileExtensions(
+  file       linker.File,
+  extensions []protoreflect.ExtensionDescriptor,
+  ver        string,
+  pkg        string,
+){
+  pkgKey := c.packageKey(file)
+
+  for _, ext := range extensions {
+    extKey := c.key(ext)
+
+    props := fieldProps(ext, ver, pkg)
+    props["extendee"] = string(ext.ContainingMessage().FullName())
+
+    c.graph.AddNode(domain.LabelExtension, extKey, withSource(ext, props))
+    c.graph.AddEdge(
+      domain.EdgeDefinedIn,
+      domain.LabelExtension, extKey,
+      domain.LabelPackage, pkgKey,
+    )
+    c.graph.AddEdge(
+      domain.EdgeExtends,
+      domain.LabelExtension, extKey,
+      domain.LabelMessage, c.key(ext.ContainingMessage()),
+    )
+    if parent, ok := ext.Parent().(protoreflect.MessageDescriptor); ok {
+      c.graph.AddEdge(
+        domain.EdgeNestedIn,
+        domain.LabelExtension, extKey,
+        domain.LabelMessage, c.key(parent),
       )
-      if field.Kind() == protoreflect.MessageKind && !isMap {
-        fieldType := field.Message().Name()
-        appendQuery(c.builders[ProtoMsgParams],
-          `(%s)-[:USES_MSG_TYPE]->(%s),`,
-          paramKey,
-          versionedKey(ver, string(fieldType)),
-        )
+    }
+    c.linkFieldType(domain.LabelExtension, extKey, ext, pkgKey)
+  }
+}
 
-        // If field is of type message FROM a different package.
-        // Create relationship between :Paramter and :Package
-        fieldPackage = strings.Join(
-          strings.Split(
-            string(field.Message().FullName()), 
-            ".",
-          )[:2],
-          ".",
-        )
-        if fieldPackage != pkg+"."+ver {
-          appendQuery(c.builders[ProtoMsgParams],
-            `(%s)-[:FROM_PACKAGE]->(%s),`,
-            paramKey,
-            fieldPackage+"."+ver,
-          )
-        }
-
-      } else if field.Kind() == protoreflect.EnumKind {
-        enumName := string(field.Enum().Name())
-        appendQuery(c.builders[ProtoMsgParams],
-        `(%s)-[:USES_ENUM_TYPE]->(%s),`,
-          paramKey,
-          versionedKey(ver, enumName),
-        )
-      }
+// linkFieldType -- Links a field, or extension, to the Message or Enum it's
+// typed as. Map fields link to their value type.
+func(c *ProtoGraphCompiler) linkFieldType(
+  label  domain.NodeLabel,
+  key    string,
+  field  protoreflect.FieldDescriptor,
+  pkgKey string,
+){
+  typed := field
+  if field.IsMap() {
+    typed = field.MapValue()
+  }
+
+  switch typed.Kind() {
+  case protoreflect.MessageKind, protoreflect.GroupKind:
+    c.graph.AddEdge(
+      domain.EdgeUsesMsgType,
+      label, key,
+      domain.LabelMessage, c.key(typed.Message()),
+    )
+    // If field is of type message FROM a different package.
+    // Create relationship between :Parameter and :Package
+    if fieldPackage := c.packageKey(typed.Message().ParentFile()); fieldPackage != pkgKey {
+      c.graph.AddEdge(
+        domain.EdgeFromPackage,
+        label, key,
+        domain.LabelPackage, fieldPackage,
+      )
+    }
+  case protoreflect.EnumKind:
+    c.graph.AddEdge(
+      domain.EdgeUsesEnumType,
+      label, key,
+      domain.LabelEnum, c.key(typed.Enum()),
+    )
+  }
+}
+
+// fieldProps -- Returns the properties shared by Parameter and Extension
+// Nodes. Presence, defaults and encoding differ between proto2, proto3 and
+// editions, so they're recorded as resolved by the descriptor rather than
+// inferred from the file's syntax.
+func fieldProps(
+  field protoreflect.FieldDescriptor,
+  ver   string,
+  pkg   string,
+) map[string]any {
+  var (
+    fieldKind = field.Kind().String()
+    tKey      = ""
+    tVal      = ""
+    encoding  = ""
+    dflt      = ""
+  )
+  if field.IsMap() {
+    tKey      = field.MapKey().Kind().String()
+    tVal      = field.MapValue().Kind().String()
+    fieldKind = "map"
+  }
+  switch field.Kind() {
+  case protoreflect.GroupKind:
+    encoding = "delimited"
+  case protoreflect.MessageKind:
+    encoding = "length_prefixed"
+  }
+  if field.HasDefault() {
+    dflt = formatScalar(field, field.Default())
+  }
+
+  return map[string]any{
+    "package"    : pkg,
+    "version"    : ver,
+    "repeated"   : field.Cardinality() == protoreflect.Repeated,
+    "optional"   : field.Cardinality() == protoreflect.Optional,
+    "required"   : field.Cardinality() == protoreflect.Required,
+    "presence"   : fieldPresence(field),
+    "packed"     : field.IsPacked(),
+    "encoding"   : encoding,
+    "hasDefault" : field.HasDefault(),
+    "default"    : dflt,
+    "field"      : string(field.Name()),
+    "type"       : fieldKind,
+    "number"     : int32(field.Number()),
+    "tKey"       : tKey,
+    "tVal"       : tVal,
+  }
+}
+
+// fieldPresence - Returns how a field tracks whether it was set.
+//
+// Possible Values
+//    - required :: proto2 'required', or editions LEGACY_REQUIRED.
+//    - explicit :: Has a has-er; proto2 optional, proto3 'optional', messages and oneofs.
+//    - implicit :: Unset is indistinguishable from the zero value.
+//    - none     :: Repeated and map fields.
+func fieldPresence(field protoreflect.FieldDescriptor) string {
+  switch {
+  case field.Cardinality() == protoreflect.Required:
+    return "required"
+  case field.Cardinality() == protoreflect.Repeated:
+    return "none"
+  case field.HasPresence():
+    return "explicit"
+  default:
+    return "implicit"
+  }
+}
+
+// compileOneofs -- Compiles a Oneof Node for every oneof group declared in
+// 'msg'. Synthetic oneofs, generated for proto3 'optional' fields, are skipped.
+func(c *ProtoGraphCompiler) compileOneofs(
+  msg protoreflect.MessageDescriptor,
+  ver string,
+  pkg string,
+){
+  msgKey := c.key(msg)
+  oneofs := msg.Oneofs()
+
+  for i := 0; i < oneofs.Len(); i++ {
+    oneof := oneofs.Get(i)
+    if oneof.IsSynthetic() {
+      continue
     }
-  }()
+    oneofKey := c.key(oneof)
+
+    c.graph.AddNode(domain.LabelOneof, oneofKey, withSource(oneof, map[string]any{
+      "package" : pkg,
+      "version" : ver,
+      "message" : string(msg.Name()),
+      "name"    : string(oneof.Name()),
+      "fields"  : oneof.Fields().Len(),
+    }))
+    c.graph.AddEdge(
+      domain.EdgeHasOneof,
+      domain.LabelMessage, msgKey,
+      domain.LabelOneof, oneofKey,
+    )
+  }
 }
 
-func(c *ProtoCypherCompiler) compileServiceDefinitions(
+func(c *ProtoGraphCompiler) compileServiceDefinitions(
   file linker.File,
   ver  string,
   pkg  string,
-  svcs *[]string,
 ){
-  c.wg.Add(1)
-  go func(){
-    defer c.wg.Done()
-    services := file.Services()
-    var (
-      svc      protoreflect.ServiceDescriptor
-      svcName  string
-      svcKey   string
+  pkgKey   := c.packageKey(file)
+  services := file.Services()
+
+  for i := 0; i < services.Len(); i++ {
+    svc    := services.Get(i)
+    svcKey := c.key(svc)
+
+    c.graph.AddNode(domain.LabelService, svcKey, withSource(svc, map[string]any{
+      "name"       : string(svc.Name()),
+      "package"    : pkg,
+      "version"    : ver,
+      "deprecated" : optionBool(svc.Options(), "deprecated"),
+      "options"    : customOptions(svc.Options()),
+    }))
+    c.graph.AddEdge(
+      domain.EdgeDefinedIn,
+      domain.LabelService, svcKey,
+      domain.LabelPackage, pkgKey,
     )
-    for i := 0; i < services.Len(); i++ {
-      svc     = services.Get(i)
-      svcName = string(svc.Name())
-      svcKey  = versionedKey(ver, svcName)
-      *svcs   = append(*svcs, svcKey)
-
-      appendQuery(c.builders[ProtoSvcDefs],
-`(%s:Service {
-  name: "%s",
-  package: "%s",
-  version: "%s"
-}),`,
-      svcKey,
-      svcName,
-      pkg,
-      ver,
-      )
-    }
-  }()
+  }
 }
 
-func(c *ProtoCypherCompiler) compileServiceParams(
+// compileServiceParams -- Compiles a Method Node for every RPC of every
+// Service, along with its streaming mode, idempotency level and options.
+func(c *ProtoGraphCompiler) compileServiceParams(
   file linker.File,
   ver  string,
   pkg  string,
 ){
-  c.wg.Add(1)
-  go func(){
-    defer c.wg.Done()
-    services := file.Services()
-    
-    var (
-      svc        protoreflect.ServiceDescriptor
-      method     protoreflect.MethodDescriptor
-      methodName string
-      methodKey  string
-      svcName    string
-      svcKey     string
-      inputKey   string
-      outputKey  string
-    )
-    for i := 0; i < services.Len(); i++ {
-      svc     = services.Get(i)
-      svcName = string(svc.Name())
-      svcKey  = versionedKey(ver, svcName)
-
-      for j := 0; j < svc.Methods().Len(); j++ {
-        method     = svc.Methods().Get(j)
-        methodName = string(method.Name())
-        methodKey  = versionedKey(ver, svcName, methodName)
-        inputKey   = versionedKey(ver, string(method.Input().Name()))
-        outputKey  = versionedKey(ver, string(method.Output().Name()))
-      }
-      appendQuery(c.builders[ProtoSvcParams],
-`(%s:Method {
-  name: "%s",
-  package: "%s",
-  version: "%s"
-}),`,
-        methodKey,
-        methodName,
-        pkg,
-        ver,
-      )
-      appendQuery(c.builders[ProtoSvcParams],
-        `(%s)-[:INPUT]->(%s),`,
-        inputKey,
-        methodKey,
+  services := file.Services()
+
+  for i := 0; i < services.Len(); i++ {
+    svc    := services.Get(i)
+    svcKey := c.key(svc)
+
+    for j := 0; j < svc.Methods().Len(); j++ {
+      method    := svc.Methods().Get(j)
+      methodKey := c.key(method)
+
+      c.graph.AddNode(domain.LabelMethod, methodKey, withSource(method, map[string]any{
+        "name"            : string(method.Name()),
+        "service"         : string(svc.Name()),
+        "package"         : pkg,
+        "version"         : ver,
+        "clientStreaming" : method.IsStreamingClient(),
+        "serverStreaming" : method.IsStreamingServer(),
+        "idempotency"     : optionEnum(method.Options(), "idempotency_level"),
+        "deprecated"      : optionBool(method.Options(), "deprecated"),
+        "options"         : customOptions(method.Options()),
+      }))
+      c.graph.AddEdge(
+        domain.EdgeInput,
+        domain.LabelMessage, c.key(method.Input()),
+        domain.LabelMethod, methodKey,
       )
-      appendQuery(c.builders[ProtoSvcParams],
-        `(%s)-[:OUTPUT]->(%s),`,
-        outputKey,
-        methodKey,
+      c.graph.AddEdge(
+        domain.EdgeOutput,
+        domain.LabelMessage, c.key(method.Output()),
+        domain.LabelMethod, methodKey,
       )
-      appendQuery(c.builders[ProtoSvcParams],
-        `(%s)-[:RPC_METHOD]->(%s),`,
-        methodKey,
-        svcKey,
+      c.graph.AddEdge(
+        domain.EdgeRPCMethod,
+        domain.LabelMethod, methodKey,
+        domain.LabelService, svcKey,
       )
     }
-  }()
-}
-
-func wrapMergeCypher(cypher string) string {
-  return "BEGIN\n"+
-         "MERGE\n"+
-         "%s\n"+
-         "COMMIT\n"+
-         cypher+
-         "COMMIT\n"+
-         "EXCEPTION\n"+
-         "    WHEN ANY THEN ROLLBACK;\n"
-}
-
-// WriteString -- Pulls all of our compiled Cypher Queries and combines 
-// then into a unified Cypher Query String.
-func(c *ProtoCypherCompiler) WriteString() string {
-  cy := []string{
-    "BEGIN\nMERGE\n",
-    c.builders[ProtoMeta].String(),
-    c.builders[ProtoEnums].String(),
-    c.builders[ProtoMsgDefs].String(),
-    c.builders[ProtoMsgParams].String(),
-    c.builders[ProtoSvcDefs].String(),
-    c.builders[ProtoSvcParams].String(),
-    c.builders[ProtoRelations].String(),
-    "COMMIT\nEXCEPTION\n\tWHEN ANY THEN ROLLBACK;\n",
-  }
-  return strings.Join(cy, "")
-  // cypher := fmt.Sprintf(
-  //   "%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n",
-  //   "BEGIN\nMERGE",
-  //   c.builders[ProtoMeta],
-  //   c.builders[ProtoEnums],
-  //   c.builders[ProtoMsgDefs],
-  //   c.builders[ProtoMsgParams],
-  //   c.builders[ProtoSvcDefs],
-  //   c.builders[ProtoSvcParams],
-  //   c.builders[ProtoRelations],
-  //   "COMMIT\nEXCEPTION\n  WHEN ANY THEN ROLLBACK;",
-  // )
-  // return cypher
+  }
+}
+
+// optionBool - Returns the boolean value of the standard option 'name', or
+// false if 'opts' doesn't declare it.
+func optionBool(opts proto.Message, name protoreflect.Name) bool {
+  if opts == nil {
+    return false
+  }
+  msg := opts.ProtoReflect()
+  if field := msg.Descriptor().Fields().ByName(name); field != nil {
+    return msg.Get(field).Bool()
+  }
+  return false
+}
+
+// optionEnum - Returns the value name of the standard enum option 'name',
+// falling back to the enum's default value when it isn't set.
+func optionEnum(opts proto.Message, name protoreflect.Name) string {
+  if opts == nil {
+    return ""
+  }
+  msg   := opts.ProtoReflect()
+  field := msg.Descriptor().Fields().ByName(name)
+  if field == nil || field.Enum() == nil {
+    return ""
+  }
+  value := field.Enum().Values().ByNumber(msg.Get(field).Enum())
+  if value == nil {
+    return ""
+  }
+  return string(value.Name())
+}
+
+// customOptions - Returns every custom (extension) option set on 'opts' as a
+// sorted list of "fully.qualified.name=value" strings. Neo4j properties can't
+// hold nested maps, so the list is kept flat.
+func customOptions(opts proto.Message) []string {
+  options := []string{}
+  if opts == nil {
+    return options
+  }
+  opts.ProtoReflect().Range(func(
+    field protoreflect.FieldDescriptor,
+    value protoreflect.Value,
+  ) bool {
+    if field.IsExtension() {
+      options = append(options, fmt.Sprintf(
+        "%s=%s",
+        field.FullName(),
+        formatOptionValue(field, value),
+      ))
+    }
+    return true
+  })
+  sort.Strings(options)
+
+  return options
+}
+
+func formatOptionValue(
+  field protoreflect.FieldDescriptor,
+  value protoreflect.Value,
+) string {
+  if field.IsList() {
+    list  := value.List()
+    elems := make([]string, 0, list.Len())
+    for i := 0; i < list.Len(); i++ {
+      elems = append(elems, formatScalar(field, list.Get(i)))
+    }
+    return "[" + strings.Join(elems, ",") + "]"
+  }
+  return formatScalar(field, value)
+}
+
+func formatScalar(
+  field protoreflect.FieldDescriptor,
+  value protoreflect.Value,
+) string {
+  switch field.Kind() {
+  case protoreflect.EnumKind:
+    if v := field.Enum().Values().ByNumber(value.Enum()); v != nil {
+      return string(v.Name())
+    }
+  case protoreflect.MessageKind, protoreflect.GroupKind:
+    return formatMessage(value.Message())
+  }
+  return value.String()
+}
+
+// formatMessage - Formats a message option as {name:value ...}, ordered by
+// field number. prototext isn't used since its output is deliberately unstable.
+func formatMessage(msg protoreflect.Message) string {
+  type entry struct {
+    number protoreflect.FieldNumber
+    text   string
+  }
+  entries := []entry{}
+  msg.Range(func(
+    field protoreflect.FieldDescriptor,
+    value protoreflect.Value,
+  ) bool {
+    text := formatOptionValue(field, value)
+    if field.Kind() == protoreflect.StringKind && !field.IsList() {
+      text = strconv.Quote(value.String())
+    }
+    entries = append(entries, entry{
+      number : field.Number(),
+      text   : string(field.Name()) + ":" + text,
+    })
+    return true
+  })
+  sort.Slice(entries, func(i, j int) bool {
+    return entries[i].number < entries[j].number
+  })
+
+  parts := make([]string, 0, len(entries))
+  for _, e := range entries {
+    parts = append(parts, e.text)
+  }
+  return "{" + strings.Join(parts, " ") + "}"
+}
+
+// extensionRanges - Returns the extension ranges declared by 'msg' as
+// inclusive "start-end" strings.
+func extensionRanges(msg protoreflect.MessageDescriptor) []string {
+  ranges := make([]string, 0, msg.ExtensionRanges().Len())
+  for i := 0; i < msg.ExtensionRanges().Len(); i++ {
+    r := msg.ExtensionRanges().Get(i)
+    ranges = append(ranges, fmt.Sprintf("%d-%d", r[0], r[1]-1))
+  }
+  return ranges
+}
+
+// fileEdition - Returns the edition of an editions file, e.g. "2023", or ""
+// for proto2 and proto3 files.
+func fileEdition(file protoreflect.FileDescriptor) string {
+  if file.Syntax() != protoreflect.Editions {
+    return ""
+  }
+  edition := protodesc.ToFileDescriptorProto(file).GetEdition()
+  return strings.TrimPrefix(edition.String(), "EDITION_")
+}
+
+// withSource - Adds the source span and comments of 'desc' to 'props'.
+func withSource(
+  desc  protoreflect.Descriptor,
+  props map[string]any,
+) map[string]any {
+  file := desc.ParentFile()
+  if file == nil {
+    return props
+  }
+  return withLocation(file, file.SourceLocations().ByDescriptor(desc), props)
+}
+
+// withLocation - Adds a source span, 1-based, along with the leading and
+// trailing comments found at 'loc' to 'props'. Elements without source info,
+// e.g. descriptors built from a FileDescriptorSet, only get their file.
+func withLocation(
+  file  protoreflect.FileDescriptor,
+  loc   protoreflect.SourceLocation,
+  props map[string]any,
+) map[string]any {
+  props["file"] = file.Path()
+  if loc.Path == nil {
+    return props
+  }
+  props["line"]             = loc.StartLine + 1
+  props["column"]           = loc.StartColumn + 1
+  props["endLine"]          = loc.EndLine + 1
+  props["endColumn"]        = loc.EndColumn + 1
+  props["leadingComments"]  = cleanComment(loc.LeadingComments)
+  props["trailingComments"] = cleanComment(loc.TrailingComments)
+
+  return props
+}
+
+// packageLocation - Returns the location of the 'package' statement, which
+// carries the package's documentation.
+func packageLocation(file protoreflect.FileDescriptor) protoreflect.SourceLocation {
+  // ->> 2 == FileDescriptorProto.package
+  return file.SourceLocations().ByPath(protoreflect.SourcePath{ 2 })
+}
+
+// cleanComment - Strips the single space protoc leaves after each comment
+// marker, and the trailing newline, keeping any further indentation intact.
+func cleanComment(comment string) string {
+  lines := strings.Split(strings.TrimRight(comment, "\n"), "\n")
+  for i, line := range lines {
+    lines[i] = strings.TrimPrefix(line, " ")
+  }
+  return strings.Join(lines, "\n")
+}
+
+// walkDescriptors - Returns every message, enum and extension declared in
+// 'file', including those nested within messages, parents before their children.
+// Synthetic map entry messages are skipped; map fields link to their value type.
+func walkDescriptors(
+  file linker.File,
+)(
+  []protoreflect.MessageDescriptor,
+  []protoreflect.EnumDescriptor,
+  []protoreflect.ExtensionDescriptor,
+){
+  var (
+    messages   []protoreflect.MessageDescriptor
+    enums      []protoreflect.EnumDescriptor
+    extensions []protoreflect.ExtensionDescriptor
+    walk       func(msgs protoreflect.MessageDescriptors)
+  )
+  for i := 0; i < file.Enums().Len(); i++ {
+    enums = append(enums, file.Enums().Get(i))
+  }
+  for i := 0; i < file.Extensions().Len(); i++ {
+    extensions = append(extensions, file.Extensions().Get(i))
+  }
+  walk = func(msgs protoreflect.MessageDescriptors) {
+    for i := 0; i < msgs.Len(); i++ {
+      msg := msgs.Get(i)
+      if msg.IsMapEntry() {
+        continue
+      }
+      messages = append(messages, msg)
+      for j := 0; j < msg.Enums().Len(); j++ {
+        enums = append(enums, msg.Enums().Get(j))
+      }
+      for j := 0; j < msg.Extensions().Len(); j++ {
+        extensions = append(extensions, msg.Extensions().Get(j))
+      }
+      walk(msg.Messages())
+    }
+  }
+  walk(file.Messages())
+
+  return messages, enums, extensions
+}
+
+// key - Returns the graph key of 'desc'.
+func(c *ProtoGraphCompiler) key(desc protoreflect.Descriptor) string {
+  return c.namespaced(desc.ParentFile(), string(desc.FullName()))
+}
+
+// packageKey - Returns the graph key of the Package 'file' belongs to.
+func(c *ProtoGraphCompiler) packageKey(file protoreflect.FileDescriptor) string {
+  return c.namespaced(file, string(file.Package()))
+}
+
+// owner - Returns the Owner of everything 'file' defines: its Package's key,
+// scoped to the Subject it's compiled for when compiled WithSubject.
+func(c *ProtoGraphCompiler) owner(file protoreflect.FileDescriptor) string {
+  if c.opts.subject == "" {
+    return c.packageKey(file)
+  }
+  return c.packageKey(file) + "#" + c.opts.subject
+}
+
+// namespaced - Prefixes 'name' with the compiler's namespace, unless 'file'
+// is a shared import whose nodes are referenced by every tenant.
+func(c *ProtoGraphCompiler) namespaced(
+  file protoreflect.FileDescriptor,
+  name string,
+) string {
+  if file == nil || c.opts.isShared(file.Path()) {
+    return name
+  }
+  // ->> Unversioned package names need the upload's version to stay unique.
+  name += c.opts.versioning.KeySuffix()
+  if c.opts.namespace == "" {
+    return name
+  }
+  return c.opts.namespace + "/" + name
+}
+
+// enumValueKey - Enum values are scoped to their enum's parent in proto, so
+// the enum's own name is added to keep aliased values from colliding.
+func(c *ProtoGraphCompiler) enumValueKey(value protoreflect.EnumValueDescriptor) string {
+  return c.namespaced(
+    value.ParentFile(),
+    string(value.Parent().FullName()) + "." + string(value.Name()),
+  )
 }
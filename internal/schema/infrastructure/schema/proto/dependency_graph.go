@@ -1,103 +1,340 @@
 package proto
 
 import (
-	"fmt"
+	"io"
+	"sort"
 
+	"github.com/bufbuild/protocompile/ast"
 	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
 )
 
 type ProtoMetadata struct{
   File    linker.File
-  Imports []string
+  Imports []string // Imported packages.
+  Deps    []string // Imported file paths.
   PkgName string
   Path    string
+  Shared  bool // Well-known, google/api or platform import.
 }
 
 // ProtoDependencyGraph -- Defines a Proto File Dependency graph.
 // Which will determine in what order we comile our Cypher queries.
 // We need to compile our queries from least specificity to greatest
 // specificity in order to make sure all cypher relationship queries
-// are successfully processed by Neo4j
+// are successfully processed by Neo4j.
+//
+// Nodes are keyed by file path, so packages spread across several files
+// are ordered file by file.
 type ProtoDependencyGraph struct {
   Ordered  []*ProtoMetadata          // Final Sorted Order
-  packages map[string][]string       // File ->> Dependencies
-  visited  map[string]bool           // Track visited Files.
-  stack    map[string]*ProtoMetadata // Processsed Proto Metadat objects.
+  files    map[string]*ProtoMetadata // Path ->> Metadata
+  missing  []MissingImport           // Imports that couldn't be resolved.
+  external map[string]bool           // Files compiled outside of this graph.
 }
 
-// BuildDependencyGraph - Builds a Dependency Graph Builder containing ProtoMetadata references,
-// created for ever file passed within 'files'.
-func BuildDependencyGraph(
-  files linker.Files,
-) ProtoDependencyGraph {
-  graph := ProtoDependencyGraph {
-    packages : map[string][]string{},
-    visited  : map[string]bool{},
-    stack    : map[string]*ProtoMetadata{},
+func newDependencyGraph() *ProtoDependencyGraph {
+  return &ProtoDependencyGraph{
     Ordered  : []*ProtoMetadata{},
+    files    : map[string]*ProtoMetadata{},
+    external : map[string]bool{},
   }
-  var (
-    pkgName    string
-    imports    []string
-    path string
-  )
+}
 
+// BuildDependencyGraph - Builds a Dependency Graph containing ProtoMetadata
+// references, created for every file passed within 'files'.
+func BuildDependencyGraph(
+  files linker.Files,
+) *ProtoDependencyGraph {
+  graph := newDependencyGraph()
   for _, file := range files {
-    pkgName  = string(file.Package())
-    path     = string(file.Path())
-    imports  = []string{}
+    graph.files[file.Path()] = &ProtoMetadata{ Path: file.Path() }
+  }
+  graph.Link(files)
 
-    for i := 0; i < file.Imports().Len(); i++ {
-      importPath := string(file.Imports().Get(i).Package())
-      imports = append(imports, importPath)
-    }
-    graph.packages[pkgName] = imports
-    graph.stack[pkgName] = &ProtoMetadata{
-      File    : file,
-      Imports : imports,
-      PkgName : pkgName,
-      Path    : path,
+  // ->> Anything else the files import was already resolved by the compiler.
+  for _, meta := range graph.files {
+    for _, dep := range meta.Deps {
+      if _, ok := graph.files[dep]; !ok {
+        graph.external[dep] = true
+      }
     }
   }
-
   return graph
 }
 
-// TopologicalSort - Sorts all Protofiles level of specificity.
-// ProtoMetadata objects are sorted, in memory, within p.Ordered
-func(p *ProtoDependencyGraph) TopologicalSort() error {
-  var dfs func(node string)error
+// ScanDependencyGraph - Builds a Dependency Graph from raw sources, before
+// anything is compiled, following the imports of 'filepaths' through
+// 'accessor'. Imports matching 'isShared' are marked external rather than
+// read. Imports the accessor can't open are recorded as missing; files
+// that fail to parse are left for the compiler to report.
+func ScanDependencyGraph(
+  accessor  func(string)(io.ReadCloser, error),
+  filepaths []string,
+  isShared  func(string) bool,
+) *ProtoDependencyGraph {
+  graph := newDependencyGraph()
 
-  dfs = func(node string)error {
-    if p.visited[node] {
-      return nil
+  var visit func(path, importedBy string, line int)
+  visit = func(path, importedBy string, line int) {
+    if _, ok := graph.files[path]; ok || graph.external[path] {
+      return
+    }
+    if isShared(path) {
+      graph.external[path] = true
+      return
     }
-    p.visited[node] = true
 
-    // ->> Visit All Dependencies recursively::
-    for _, dep := range p.packages[node] {
-      if err := dfs(dep); err != nil {
-        return err
+    r, err := accessor(path)
+    if err != nil {
+      graph.missing = append(graph.missing, MissingImport{
+        File   : importedBy,
+        Import : path,
+        Line   : line,
+      })
+      return
+    }
+    node, err := parser.Parse(path, r, reporter.NewHandler(nil))
+    _ = r.Close()
+
+    meta := &ProtoMetadata{ Path: path }
+    graph.files[path] = meta
+    if err != nil {
+      return
+    }
+
+    for _, decl := range node.Decls {
+      imp, ok := decl.(*ast.ImportNode)
+      if !ok {
+        continue
       }
+      dep := imp.Name.AsString()
+      meta.Deps = append(meta.Deps, dep)
+      visit(dep, path, node.NodeInfo(imp).Start().Line)
     }
+  }
+
+  for _, path := range filepaths {
+    visit(path, "", 0)
+  }
+  return graph
+}
 
-    meta, exists := p.stack[node]
-    if !exists {
-      return fmt.Errorf("metadata for %s not found", node)
+// Link - Attaches compiled files to their nodes, filling in each node's
+// package and imports. Only linked nodes are added to Ordered.
+func(p *ProtoDependencyGraph) Link(files linker.Files) {
+  for _, file := range files {
+    meta, ok := p.files[file.Path()]
+    if !ok {
+      continue
+    }
+    meta.File    = file
+    meta.PkgName = string(file.Package())
+    meta.Imports = []string{}
+    meta.Deps    = []string{}
+    for i := 0; i < file.Imports().Len(); i++ {
+      imp := file.Imports().Get(i)
+      meta.Imports = append(meta.Imports, string(imp.Package()))
+      meta.Deps    = append(meta.Deps, imp.Path())
     }
-    p.Ordered = append(p.Ordered, meta)
+  }
+}
+
+// MarkExternal - Marks files compiled outside of this graph, e.g. shared
+// imports, so depending on them doesn't fail the sort.
+func(p *ProtoDependencyGraph) MarkExternal(paths ...string) {
+  for _, path := range paths {
+    p.external[path] = true
+  }
+}
+
+// Files - Returns the path of every file in the graph, sorted.
+func(p *ProtoDependencyGraph) Files() []string {
+  paths := make([]string, 0, len(p.files))
+  for path := range p.files {
+    paths = append(paths, path)
+  }
+  sort.Strings(paths)
+  return paths
+}
+
+// Dependencies - Returns the files 'path' directly imports, shared imports
+// included.
+func(p *ProtoDependencyGraph) Dependencies(path string) []string {
+  meta, ok := p.files[path]
+  if !ok {
     return nil
   }
+  return append([]string{}, meta.Deps...)
+}
 
-  // ->> Perform DFS For Each Node:
-  for node := range p.packages {
-    if !p.visited[node] {
-      // Detected Cycle:
-      if err := dfs(node); err != nil {
-        return err
+// Imports - Returns every file 'paths' transitively import, excluding 'paths'
+// themselves, sorted. External files are included but not followed, since
+// their imports aren't part of the graph.
+func(p *ProtoDependencyGraph) Imports(paths ...string) []string {
+  seen  := map[string]bool{}
+  queue := append([]string{}, paths...)
+  for _, path := range paths {
+    seen[path] = true
+  }
+  imports := []string{}
+  for len(queue) != 0 {
+    next := queue[0]
+    queue = queue[1:]
+    meta, ok := p.files[next]
+    if !ok {
+      continue
+    }
+    for _, dep := range meta.Deps {
+      if !seen[dep] {
+        seen[dep] = true
+        imports = append(imports, dep)
+        queue = append(queue, dep)
       }
     }
   }
+  sort.Strings(imports)
+  return imports
+}
+
+// Dependents - Returns every file that transitively imports 'path', sorted.
+// These are the files that need recompiling when 'path' changes.
+func(p *ProtoDependencyGraph) Dependents(path string) []string {
+  reverse := map[string][]string{}
+  for _, from := range p.Files() {
+    for _, dep := range p.files[from].Deps {
+      reverse[dep] = append(reverse[dep], from)
+    }
+  }
+
+  seen  := map[string]bool{}
+  queue := []string{ path }
+  for len(queue) != 0 {
+    next := queue[0]
+    queue = queue[1:]
+    for _, from := range reverse[next] {
+      if !seen[from] && from != path {
+        seen[from] = true
+        queue = append(queue, from)
+      }
+    }
+  }
+
+  dependents := make([]string, 0, len(seen))
+  for from := range seen {
+    dependents = append(dependents, from)
+  }
+  sort.Strings(dependents)
+  return dependents
+}
+
+// MissingImports - Returns every import that couldn't be resolved while scanning.
+func(p *ProtoDependencyGraph) MissingImports() []MissingImport {
+  return append([]MissingImport{}, p.missing...)
+}
+
+// FindCycle - Returns the first import cycle found, starting and ending with
+// the same file, or nil when the graph is acyclic.
+func(p *ProtoDependencyGraph) FindCycle() []string {
+  const (
+    unvisited = iota
+    visiting
+    done
+  )
+  var (
+    state = map[string]int{}
+    stack = []string{}
+    cycle []string
+    dfs   func(path string) bool
+  )
+  dfs = func(path string) bool {
+    state[path] = visiting
+    stack = append(stack, path)
+    for _, dep := range p.files[path].Deps {
+      if _, ok := p.files[dep]; !ok {
+        continue
+      }
+      switch state[dep] {
+      case visiting:
+        for i, s := range stack {
+          if s == dep {
+            cycle = append(append([]string{}, stack[i:]...), dep)
+            break
+          }
+        }
+        return true
+      case unvisited:
+        if dfs(dep) {
+          return true
+        }
+      }
+    }
+    stack = stack[:len(stack)-1]
+    state[path] = done
+    return false
+  }
+
+  for _, path := range p.Files() {
+    if state[path] == unvisited && dfs(path) {
+      return cycle
+    }
+  }
+  return nil
+}
+
+// Validate - Checks the graph for missing imports and import cycles.
+//
+// Potential Errors:
+//    - *MissingImportError
+//    - *ImportCycleError
+func(p *ProtoDependencyGraph) Validate() error {
+  if missing := p.MissingImports(); len(missing) != 0 {
+    return &MissingImportError{ Missing: missing }
+  }
+  if cycle := p.FindCycle(); cycle != nil {
+    return &ImportCycleError{ Path: cycle }
+  }
+  return nil
+}
+
+// TopologicalSort - Sorts all Protofiles level of specificity, dependencies
+// first. ProtoMetadata objects are sorted, in memory, within p.Ordered. Files
+// without a linked linker.File are ordered around but left out of p.Ordered.
+//
+// Potential Errors:
+//    - *MissingImportError
+//    - *ImportCycleError
+func(p *ProtoDependencyGraph) TopologicalSort() error {
+  if err := p.Validate(); err != nil {
+    return err
+  }
+
+  visited := map[string]bool{}
+  ordered := []*ProtoMetadata{}
+
+  var dfs func(path string)
+  dfs = func(path string) {
+    meta, ok := p.files[path]
+    if !ok || visited[path] {
+      return
+    }
+    visited[path] = true
+
+    // ->> Visit All Dependencies recursively::
+    for _, dep := range meta.Deps {
+      dfs(dep)
+    }
+    if meta.File != nil {
+      ordered = append(ordered, meta)
+    }
+  }
+
+  // ->> Perform DFS For Each Node, in path order so the result is stable:
+  for _, path := range p.Files() {
+    dfs(path)
+  }
 
+  p.Ordered = ordered
   return nil
 }
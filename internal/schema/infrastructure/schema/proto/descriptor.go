@@ -0,0 +1,227 @@
+package proto
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+)
+
+// FileDescriptorSet - Encodes 'files' into a binary FileDescriptorSet. Files
+// are listed in path order, each one after the files it imports, so the set
+// can be loaded with protodesc.NewFiles as is. Unless opts.Imports is set,
+// only 'files' themselves are included.
+func FileDescriptorSet(
+  files linker.Files,
+  opts  domain.DescriptorOptions,
+)( []byte, error ){
+  roots := make(map[string]protoreflect.FileDescriptor, len(files))
+  paths := make([]string, 0, len(files))
+  for _, file := range files {
+    roots[file.Path()] = file
+    paths = append(paths, file.Path())
+  }
+  sort.Strings(paths)
+
+  set  := &descriptorpb.FileDescriptorSet{}
+  seen := map[string]bool{}
+  var add func(file protoreflect.FileDescriptor)
+  add = func(file protoreflect.FileDescriptor) {
+    if seen[file.Path()] {
+      return
+    }
+    seen[file.Path()] = true
+
+    imports := file.Imports()
+    for i := 0; i < imports.Len(); i++ {
+      dep := imports.Get(i).FileDescriptor
+      if root, ok := roots[dep.Path()]; ok {
+        add(root)
+      } else if opts.Imports {
+        add(dep)
+      }
+    }
+
+    fd := protodesc.ToFileDescriptorProto(file)
+    if !opts.SourceInfo {
+      fd.SourceCodeInfo = nil
+    }
+    set.File = append(set.File, fd)
+  }
+  for _, path := range paths {
+    add(roots[path])
+  }
+
+  return proto.MarshalOptions{ Deterministic: true }.Marshal(set)
+}
+
+// FileDescriptorSet - Implements domain.DescriptorExportable over every
+// compiled tenant file.
+func(pf *ProtoFiles) FileDescriptorSet(
+  opts domain.DescriptorOptions,
+)( []byte, error ){
+  return FileDescriptorSet(pf.files, opts)
+}
+
+// NewDescriptorFiles - Creates a ProtoFiles instance from a FileDescriptorSet,
+// binary or protojson encoded, e.g. built by `buf build` or Bazel. Every
+// tenant file of the set is linked into a linker.File, so it's compiled,
+// linted and compared like uploaded sources. Shared imports always resolve
+// to Fidicus' own copies, whether or not the set includes them.
+//
+// Potential Errors:
+//    - ErrInvalidDescriptorSet
+//    - *MissingImportError
+//    - *ImportCycleError
+func NewDescriptorFiles(
+  ctx  context.Context,
+  data []byte,
+  opts ...CompileOption,
+)( *ProtoFiles, error ){
+  options := newCompileOptions(opts)
+
+  set := &descriptorpb.FileDescriptorSet{}
+  var err error
+  if domain.DescriptorSetPath(data) == domain.DescriptorSetJSONFile {
+    err = protojson.Unmarshal(data, set)
+  } else {
+    err = proto.Unmarshal(data, set)
+  }
+  if err != nil {
+    return nil, fmt.Errorf("%w: %s", ErrInvalidDescriptorSet, err.Error())
+  }
+
+  // ->> Only the tenant's files are taken from the set. Shared files are
+  //     shadowed by ours, just like shared sources are.
+  tenant   := &descriptorpb.FileDescriptorSet{}
+  included := map[string]bool{}
+  for _, fd := range set.File {
+    if !options.isShared(fd.GetName()) {
+      tenant.File = append(tenant.File, fd)
+      included[fd.GetName()] = true
+    }
+  }
+  if len(tenant.File) == 0 {
+    return nil, fmt.Errorf("%w: no tenant files", ErrInvalidDescriptorSet)
+  }
+
+  shared  := []string{}
+  missing := []MissingImport{}
+  seen    := map[string]bool{}
+  for _, fd := range tenant.File {
+    for _, dep := range fd.GetDependency() {
+      switch {
+      case included[dep] || seen[dep]:
+      case options.isShared(dep):
+        shared = append(shared, dep)
+      default:
+        missing = append(missing, MissingImport{ File: fd.GetName(), Import: dep })
+      }
+      seen[dep] = true
+    }
+  }
+  if len(missing) != 0 {
+    return nil, &MissingImportError{ Missing: missing }
+  }
+
+  full, err := sharedDescriptors(ctx, shared, options)
+  if err != nil {
+    return nil, err
+  }
+  full.File = append(full.File, tenant.File...)
+  registry, err := protodesc.NewFiles(full)
+  if err != nil {
+    return nil, fmt.Errorf("%w: %s", ErrInvalidDescriptorSet, err.Error())
+  }
+
+  filepaths := make([]string, 0, len(tenant.File))
+  for path := range included {
+    filepaths = append(filepaths, path)
+  }
+  sort.Strings(filepaths)
+
+  files := make(linker.Files, 0, len(filepaths))
+  for _, path := range filepaths {
+    fd, err := registry.FindFileByPath(path)
+    if err != nil {
+      return nil, fmt.Errorf("%w: %s", ErrInvalidDescriptorSet, err.Error())
+    }
+    file, err := linker.NewFileRecursive(fd)
+    if err != nil {
+      return nil, fmt.Errorf("%w: %s", ErrInvalidDescriptorSet, err.Error())
+    }
+    files = append(files, file)
+  }
+
+  sharedMeta, err := collectSharedImports(files, options)
+  if err != nil {
+    return nil, err
+  }
+  depGraph := BuildDependencyGraph(files)
+  for _, dep := range sharedMeta {
+    depGraph.MarkExternal(dep.Path)
+  }
+  if err := depGraph.TopologicalSort(); err != nil {
+    return nil, err
+  }
+
+  return &ProtoFiles{
+    filepaths : filepaths,
+    files     : files,
+    shared    : sharedMeta,
+    opts      : options,
+    depGraph  : depGraph,
+    warnings  : []domain.Diagnostic{},
+  }, nil
+}
+
+// sharedDescriptors - Compiles the shared files 'paths' from Fidicus' own
+// sources, returning them and everything they import, dependencies first.
+func sharedDescriptors(
+  ctx     context.Context,
+  paths   []string,
+  options compileOptions,
+)( *descriptorpb.FileDescriptorSet, error ){
+  set := &descriptorpb.FileDescriptorSet{}
+  if len(paths) == 0 {
+    return set, nil
+  }
+
+  compiler := protocompile.Compiler{
+    Resolver: options.resolver(func(string)( io.ReadCloser, error ){
+      return nil, fs.ErrNotExist
+    }),
+  }
+  compiled, err := compiler.Compile(ctx, paths...)
+  if err != nil {
+    return nil, err
+  }
+
+  seen := map[string]bool{}
+  var add func(file protoreflect.FileDescriptor)
+  add = func(file protoreflect.FileDescriptor) {
+    if seen[file.Path()] {
+      return
+    }
+    seen[file.Path()] = true
+    for i := 0; i < file.Imports().Len(); i++ {
+      add(file.Imports().Get(i).FileDescriptor)
+    }
+    set.File = append(set.File, protodesc.ToFileDescriptorProto(file))
+  }
+  for _, file := range compiled {
+    add(file)
+  }
+  return set, nil
+}
@@ -0,0 +1,92 @@
+package proto
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/bufbuild/protocompile/reporter"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+)
+
+// diagnosticsCollector -- A protocompile reporter that records every error and
+// warning instead of stopping at the first error, so a single compile reports
+// every problem in the uploaded sources.
+type diagnosticsCollector struct {
+  mu          sync.Mutex
+  diagnostics []domain.Diagnostic
+  errors      int
+}
+
+func newDiagnosticsCollector() *diagnosticsCollector {
+  return &diagnosticsCollector{
+    diagnostics : []domain.Diagnostic{},
+  }
+}
+
+// Reporter - Returns the reporter.Reporter handed to protocompile.Compiler.
+func(c *diagnosticsCollector) Reporter() reporter.Reporter {
+  return reporter.NewReporter(
+    func(err reporter.ErrorWithPos) error {
+      c.add(err, domain.SeverityError)
+      // ->> Returning nil lets the compiler carry on and report the rest.
+      return nil
+    },
+    func(err reporter.ErrorWithPos) {
+      c.add(err, domain.SeverityWarning)
+    },
+  )
+}
+
+func(c *diagnosticsCollector) add(
+  err      reporter.ErrorWithPos,
+  severity domain.Severity,
+){
+  pos := err.GetPosition()
+  msg := err.Error()
+  if cause := errors.Unwrap(err); cause != nil {
+    msg = cause.Error()
+  }
+
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  c.diagnostics = append(c.diagnostics, domain.Diagnostic{
+    File     : pos.Filename,
+    Line     : pos.Line,
+    Column   : pos.Col,
+    Severity : severity,
+    Message  : msg,
+  })
+  if severity == domain.SeverityError {
+    c.errors++
+  }
+}
+
+// Diagnostics - Returns every collected Diagnostic, ordered by position.
+func(c *diagnosticsCollector) Diagnostics() []domain.Diagnostic {
+  c.mu.Lock()
+  defer c.mu.Unlock()
+  diagnostics := append([]domain.Diagnostic{}, c.diagnostics...)
+  domain.SortDiagnostics(diagnostics)
+  return diagnostics
+}
+
+// Err - Returns a *domain.DiagnosticsError when any error was collected.
+// 'fallback' is reported when the compiler failed without reporting one.
+func(c *diagnosticsCollector) Err(fallback error) error {
+  c.mu.Lock()
+  errs := c.errors
+  c.mu.Unlock()
+
+  if errs == 0 && fallback == nil {
+    return nil
+  }
+  diagnostics := c.Diagnostics()
+  if errs == 0 {
+    diagnostics = append(diagnostics, domain.Diagnostic{
+      Severity : domain.SeverityError,
+      Message  : fallback.Error(),
+    })
+  }
+  return &domain.DiagnosticsError{ Items: diagnostics }
+}
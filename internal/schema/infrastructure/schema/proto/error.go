@@ -0,0 +1,92 @@
+package proto
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+)
+
+var (
+  ErrImportCycle          = errors.New("import cycle")
+  ErrMissingImport        = errors.New("missing import")
+  ErrInvalidDescriptorSet = errors.New("invalid file descriptor set")
+)
+
+// ImportCycleError is returned when proto files import each other. Path lists
+// every file of the cycle, starting and ending with the same file.
+type ImportCycleError struct {
+  Path []string `json:"path"`
+}
+
+func(e *ImportCycleError) Error() string {
+  return fmt.Sprintf("%s: %s", ErrImportCycle, strings.Join(e.Path, " -> "))
+}
+
+func(e *ImportCycleError) Unwrap() error {
+  return ErrImportCycle
+}
+
+// Diagnostics - Reports the cycle against the file it was found from.
+func(e *ImportCycleError) Diagnostics() []domain.Diagnostic {
+  file := ""
+  if len(e.Path) != 0 {
+    file = e.Path[0]
+  }
+  return []domain.Diagnostic{{
+    File     : file,
+    Severity : domain.SeverityError,
+    Message  : e.Error(),
+  }}
+}
+
+// MissingImport defines an import statement that couldn't be resolved.
+type MissingImport struct {
+  File   string `json:"file"`
+  Import string `json:"import"`
+  Line   int    `json:"line,omitempty"`
+}
+
+// MissingImportError is returned when one or more imports, or requested files,
+// can't be found among the uploaded sources or the shared imports.
+type MissingImportError struct {
+  Missing []MissingImport `json:"missing"`
+}
+
+func(e *MissingImportError) Error() string {
+  parts := make([]string, 0, len(e.Missing))
+  for _, m := range e.Missing {
+    switch {
+    case m.File == "":
+      parts = append(parts, fmt.Sprintf("%q", m.Import))
+    case m.Line != 0:
+      parts = append(parts, fmt.Sprintf("%q imported by %s:%d", m.Import, m.File, m.Line))
+    default:
+      parts = append(parts, fmt.Sprintf("%q imported by %s", m.Import, m.File))
+    }
+  }
+  return fmt.Sprintf("%s: %s", ErrMissingImport, strings.Join(parts, ", "))
+}
+
+func(e *MissingImportError) Unwrap() error {
+  return ErrMissingImport
+}
+
+// Diagnostics - Reports every missing import against the file importing it.
+func(e *MissingImportError) Diagnostics() []domain.Diagnostic {
+  diagnostics := make([]domain.Diagnostic, 0, len(e.Missing))
+  for _, m := range e.Missing {
+    message := fmt.Sprintf("import %q not found", m.Import)
+    if m.File == "" {
+      message = fmt.Sprintf("file %q not found", m.Import)
+    }
+    diagnostics = append(diagnostics, domain.Diagnostic{
+      File     : m.File,
+      Line     : m.Line,
+      Severity : domain.SeverityError,
+      Message  : message,
+    })
+  }
+  return diagnostics
+}
@@ -0,0 +1,216 @@
+package proto
+
+import (
+	"embed"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+)
+
+// thirdParty holds the google/api protos bundled with Fidicus, so tenants can
+// import HTTP annotations and field behaviors without uploading them.
+//
+//go:embed thirdparty
+var thirdParty embed.FS
+
+const thirdPartyRoot = "thirdparty"
+
+// PlatformImports -- A set of shared proto sources, keyed by import path,
+// that every tenant may import without uploading them. Like the well-known
+// types, descriptors from these files are compiled into shared graph nodes.
+type PlatformImports map[string]string
+
+// LoadPlatformImports - Loads every .proto file under 'dir', keyed by its
+// path relative to 'dir'.
+func LoadPlatformImports(dir string)( PlatformImports, error ){
+  imports := PlatformImports{}
+  err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+    if err != nil {
+      return err
+    }
+    if d.IsDir() || !strings.HasSuffix(path, ".proto") {
+      return nil
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+      return err
+    }
+    rel, err := filepath.Rel(dir, path)
+    if err != nil {
+      return err
+    }
+    imports[filepath.ToSlash(rel)] = string(data)
+    return nil
+  })
+  if err != nil {
+    return nil, err
+  }
+  return imports, nil
+}
+
+// CompileOption configures how ProtoFiles are compiled.
+type CompileOption func(*compileOptions)
+
+type compileOptions struct {
+  platform   PlatformImports
+  namespace  string
+  subject    string
+  versioning domain.VersionContext
+}
+
+// WithPlatformImports - Makes 'imports' resolvable by every compiled file.
+// Tenant sources with the same path are shadowed by the platform version.
+func WithPlatformImports(imports PlatformImports) CompileOption {
+  return func(o *compileOptions) {
+    o.platform = imports
+  }
+}
+
+// WithNamespace - Prefixes the graph keys of every tenant owned descriptor
+// with 'namespace', keeping tenants that share package names apart. Shared
+// descriptors keep their fully-qualified name so every tenant references
+// the same node.
+func WithNamespace(namespace string) CompileOption {
+  return func(o *compileOptions) {
+    o.namespace = namespace
+  }
+}
+
+// WithSubject - Scopes the Owner of every tenant owned node to 'subject', so
+// Subjects that share a Package never replace each other's nodes. Shared
+// descriptors are never owned.
+func WithSubject(subject string) CompileOption {
+  return func(o *compileOptions) {
+    o.subject = subject
+  }
+}
+
+// WithVersioning - Resolves Package versions with 'vc' rather than requiring
+// a trailing vN package segment. Under the registry and semver schemes
+// tenant owned keys are suffixed with "@<version>", since the package name
+// alone no longer tells versions apart.
+func WithVersioning(vc domain.VersionContext) CompileOption {
+  return func(o *compileOptions) {
+    o.versioning = vc
+  }
+}
+
+func newCompileOptions(opts []CompileOption) compileOptions {
+  o := compileOptions{}
+  for _, opt := range opts {
+    opt(&o)
+  }
+  return o
+}
+
+// isShared - Returns true when 'path' resolves to a well-known type, a
+// bundled google/api proto or a platform proto rather than a tenant source.
+func(o compileOptions) isShared(path string) bool {
+  if strings.HasPrefix(path, "google/protobuf/") {
+    return true
+  }
+  if _, ok := o.platform[path]; ok {
+    return true
+  }
+  _, err := fs.Stat(thirdParty, thirdPartyRoot+"/"+path)
+  return err == nil
+}
+
+// sharedSource - Returns the source of a bundled google/api or platform
+// proto. Well-known types ship with every protobuf toolchain, so they're
+// never returned.
+func(o compileOptions) sharedSource(path string)( []byte, bool ){
+  if strings.HasPrefix(path, "google/protobuf/") {
+    return nil, false
+  }
+  if src, ok := o.platform[path]; ok {
+    return []byte(src), true
+  }
+  data, err := fs.ReadFile(thirdParty, thirdPartyRoot+"/"+path)
+  if err != nil {
+    return nil, false
+  }
+  return data, true
+}
+
+// resolver - Builds the resolver chain used to compile tenant sources. Shared
+// imports are searched first: the standard well-known types, then the bundled
+// google/api protos, then the platform protos. Tenant sources come last.
+func(o compileOptions) resolver(
+  accessor func(string)(io.ReadCloser, error),
+) protocompile.Resolver {
+  shared := &protocompile.SourceResolver{
+    Accessor: func(path string)( io.ReadCloser, error ){
+      if src, ok := o.platform[path]; ok {
+        return io.NopCloser(strings.NewReader(src)), nil
+      }
+      return thirdParty.Open(thirdPartyRoot + "/" + path)
+    },
+  }
+  return protocompile.CompositeResolver{
+    protocompile.WithStandardImports(shared),
+    &protocompile.SourceResolver{
+      Accessor: accessor,
+    },
+  }
+}
+
+// collectSharedImports - Returns every shared file 'files' transitively
+// import, ordered so each file follows its own dependencies.
+func collectSharedImports(
+  files linker.Files,
+  opts  compileOptions,
+)( []*ProtoMetadata, error ){
+  var (
+    shared []*ProtoMetadata
+    seen   = map[string]bool{}
+    visit  func(fd protoreflect.FileDescriptor) error
+  )
+  visit = func(fd protoreflect.FileDescriptor) error {
+    for i := 0; i < fd.Imports().Len(); i++ {
+      imp := fd.Imports().Get(i).FileDescriptor
+      if seen[imp.Path()] || !opts.isShared(imp.Path()) {
+        continue
+      }
+      seen[imp.Path()] = true
+      if err := visit(imp); err != nil {
+        return err
+      }
+
+      file, err := linker.NewFileRecursive(imp)
+      if err != nil {
+        return err
+      }
+      imports := []string{}
+      deps    := []string{}
+      for j := 0; j < imp.Imports().Len(); j++ {
+        imports = append(imports, string(imp.Imports().Get(j).Package()))
+        deps    = append(deps, imp.Imports().Get(j).Path())
+      }
+      shared = append(shared, &ProtoMetadata{
+        File    : file,
+        Imports : imports,
+        Deps    : deps,
+        PkgName : string(imp.Package()),
+        Path    : imp.Path(),
+        Shared  : true,
+      })
+    }
+    return nil
+  }
+
+  for _, file := range files {
+    if err := visit(file); err != nil {
+      return nil, err
+    }
+  }
+  return shared, nil
+}
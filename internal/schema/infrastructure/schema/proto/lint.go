@@ -0,0 +1,553 @@
+package proto
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/protocompile/linker"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+)
+
+// LintRule defines a single style check performed on a compiled proto
+// schema set.
+type LintRule struct {
+  ID       string
+  Severity domain.Severity
+  check    func(r *lintReport, files linker.Files)
+}
+
+var (
+  LintPackageDefined            = LintRule{ "PACKAGE_DEFINED",             domain.SeverityWarning, lintPackageDefined           }
+  LintPackageDirectoryMatch     = LintRule{ "PACKAGE_DIRECTORY_MATCH",     domain.SeverityWarning, lintPackageDirectoryMatch    }
+  LintPackageSameDirectory      = LintRule{ "PACKAGE_SAME_DIRECTORY",      domain.SeverityWarning, lintPackageSameDirectory     }
+  LintPackageLowerSnakeCase     = LintRule{ "PACKAGE_LOWER_SNAKE_CASE",    domain.SeverityWarning, lintPackageLowerSnakeCase    }
+  LintMessagePascalCase         = LintRule{ "MESSAGE_PASCAL_CASE",         domain.SeverityWarning, lintMessagePascalCase        }
+  LintFieldLowerSnakeCase       = LintRule{ "FIELD_LOWER_SNAKE_CASE",      domain.SeverityWarning, lintFieldLowerSnakeCase      }
+  LintOneofLowerSnakeCase       = LintRule{ "ONEOF_LOWER_SNAKE_CASE",      domain.SeverityWarning, lintOneofLowerSnakeCase      }
+  LintEnumPascalCase            = LintRule{ "ENUM_PASCAL_CASE",            domain.SeverityWarning, lintEnumPascalCase           }
+  LintEnumValueUpperSnakeCase   = LintRule{ "ENUM_VALUE_UPPER_SNAKE_CASE", domain.SeverityWarning, lintEnumValueUpperSnakeCase  }
+  LintEnumFirstValueZero        = LintRule{ "ENUM_FIRST_VALUE_ZERO",       domain.SeverityWarning, lintEnumFirstValueZero       }
+  LintServicePascalCase         = LintRule{ "SERVICE_PASCAL_CASE",         domain.SeverityWarning, lintServicePascalCase        }
+  LintRPCPascalCase             = LintRule{ "RPC_PASCAL_CASE",             domain.SeverityWarning, lintRPCPascalCase            }
+  LintEnumValuePrefix           = LintRule{ "ENUM_VALUE_PREFIX",           domain.SeverityWarning, lintEnumValuePrefix          }
+  LintEnumZeroValueSuffix       = LintRule{ "ENUM_ZERO_VALUE_SUFFIX",      domain.SeverityWarning, lintEnumZeroValueSuffix      }
+  LintServiceSuffix             = LintRule{ "SERVICE_SUFFIX",              domain.SeverityWarning, lintServiceSuffix            }
+  LintRPCRequestStandardName    = LintRule{ "RPC_REQUEST_STANDARD_NAME",   domain.SeverityWarning, lintRPCRequestStandardName   }
+  LintRPCResponseStandardName   = LintRule{ "RPC_RESPONSE_STANDARD_NAME",  domain.SeverityWarning, lintRPCResponseStandardName  }
+  LintRPCRequestResponseUnique  = LintRule{ "RPC_REQUEST_RESPONSE_UNIQUE", domain.SeverityWarning, lintRPCRequestResponseUnique }
+  LintCommentMessage            = LintRule{ "COMMENT_MESSAGE",             domain.SeverityWarning, lintCommentMessage           }
+  LintCommentField              = LintRule{ "COMMENT_FIELD",               domain.SeverityWarning, lintCommentField             }
+  LintCommentEnum               = LintRule{ "COMMENT_ENUM",                domain.SeverityWarning, lintCommentEnum              }
+  LintCommentService            = LintRule{ "COMMENT_SERVICE",             domain.SeverityWarning, lintCommentService           }
+  LintCommentRPC                = LintRule{ "COMMENT_RPC",                 domain.SeverityWarning, lintCommentRPC               }
+)
+
+// lintRules -- Every LintRule, keyed by ID. Comment rules aren't part of any
+// built-in set and only run when listed by a domain.LintCustom config.
+var lintRules = map[string]LintRule{}
+
+// lintRuleSets -- The rules run by each built-in domain.LintRuleSet.
+var lintRuleSets = map[domain.LintRuleSet][]LintRule{}
+
+func init() {
+  minimal := []LintRule{
+    LintPackageDefined,
+    LintPackageDirectoryMatch,
+    LintPackageSameDirectory,
+  }
+  basic := append(append([]LintRule{}, minimal...),
+    LintPackageLowerSnakeCase,
+    LintMessagePascalCase,
+    LintFieldLowerSnakeCase,
+    LintOneofLowerSnakeCase,
+    LintEnumPascalCase,
+    LintEnumValueUpperSnakeCase,
+    LintEnumFirstValueZero,
+    LintServicePascalCase,
+    LintRPCPascalCase,
+  )
+  standard := append(append([]LintRule{}, basic...),
+    LintEnumValuePrefix,
+    LintEnumZeroValueSuffix,
+    LintServiceSuffix,
+    LintRPCRequestStandardName,
+    LintRPCResponseStandardName,
+    LintRPCRequestResponseUnique,
+  )
+  lintRuleSets[domain.LintMinimal] = minimal
+  lintRuleSets[domain.LintBasic]   = basic
+  lintRuleSets[domain.LintDefault] = standard
+
+  for _, rule := range append(standard,
+    LintCommentMessage,
+    LintCommentField,
+    LintCommentEnum,
+    LintCommentService,
+    LintCommentRPC,
+  ){
+    lintRules[rule.ID] = rule
+  }
+}
+
+// LintRuleIDs - Returns the ID of every available LintRule, sorted.
+func LintRuleIDs() []string {
+  ids := make([]string, 0, len(lintRules))
+  for id := range lintRules {
+    ids = append(ids, id)
+  }
+  sort.Strings(ids)
+  return ids
+}
+
+// lintReport -- Collects Diagnostics while running a single LintRule.
+type lintReport struct {
+  rule        LintRule
+  config      domain.LintConfig
+  diagnostics []domain.Diagnostic
+}
+
+func(r *lintReport) add(
+  desc protoreflect.Descriptor,
+  loc  protoreflect.SourceLocation,
+  f    string,
+  args ...any,
+) {
+  file := desc.ParentFile()
+  if file != nil && r.config.Ignored(file.Path()) {
+    return
+  }
+  d := domain.Diagnostic{
+    Severity : r.rule.Severity,
+    Rule     : r.rule.ID,
+    Path     : string(desc.FullName()),
+    Message  : fmt.Sprintf(f, args...),
+  }
+  if file != nil {
+    d.File = file.Path()
+    if loc.Path != nil {
+      d.Line   = loc.StartLine + 1
+      d.Column = loc.StartColumn + 1
+    }
+  }
+  r.diagnostics = append(r.diagnostics, d)
+}
+
+// addDesc - Reports a finding at the declaration of 'desc'.
+func(r *lintReport) addDesc(
+  desc protoreflect.Descriptor,
+  f    string,
+  args ...any,
+) {
+  var loc protoreflect.SourceLocation
+  if file := desc.ParentFile(); file != nil {
+    loc = file.SourceLocations().ByDescriptor(desc)
+  }
+  r.add(desc, loc, f, args...)
+}
+
+// Lint - Runs the rules selected by 'config' over 'files'. Unknown rule IDs
+// are reported as warnings rather than failing, so a stale config never
+// blocks an upload. Results are sorted by file, line and rule.
+func Lint(
+  files  linker.Files,
+  config domain.LintConfig,
+) []domain.Diagnostic {
+  var (
+    rules       []LintRule
+    diagnostics = []domain.Diagnostic{}
+  )
+  unknown := func(id string) {
+    diagnostics = append(diagnostics, domain.Diagnostic{
+      Severity : domain.SeverityWarning,
+      Rule     : id,
+      Message  : fmt.Sprintf("unknown lint rule %q", id),
+    })
+  }
+
+  if config.RuleSet == domain.LintCustom {
+    for _, id := range config.Rules {
+      rule, ok := lintRules[id]
+      if !ok {
+        unknown(id)
+        continue
+      }
+      rules = append(rules, rule)
+    }
+  } else {
+    set, ok := lintRuleSets[config.RuleSet]
+    if !ok {
+      set = lintRuleSets[domain.DefaultLintConfig.RuleSet]
+    }
+    rules = set
+  }
+
+  except := map[string]bool{}
+  for _, id := range config.Except {
+    if _, ok := lintRules[id]; !ok {
+      unknown(id)
+    }
+    except[id] = true
+  }
+
+  for _, rule := range rules {
+    if except[rule.ID] {
+      continue
+    }
+    report := &lintReport{ rule: rule, config: config }
+    rule.check(report, files)
+    diagnostics = append(diagnostics, report.diagnostics...)
+  }
+
+  sort.SliceStable(diagnostics, func(i, j int) bool {
+    a, b := diagnostics[i], diagnostics[j]
+    if a.File != b.File {
+      return a.File < b.File
+    }
+    if a.Line != b.Line {
+      return a.Line < b.Line
+    }
+    if a.Rule != b.Rule {
+      return a.Rule < b.Rule
+    }
+    return a.Path < b.Path
+  })
+  return diagnostics
+}
+
+var (
+  pascalCasePattern     = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+  lowerSnakeCasePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+  upperSnakeCasePattern = regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`)
+)
+
+// toUpperSnakeCase - Converts a PascalCase name into UPPER_SNAKE_CASE, e.g.
+// OrderStatus into ORDER_STATUS.
+func toUpperSnakeCase(name string) string {
+  var b strings.Builder
+  for i, r := range name {
+    if i > 0 && r >= 'A' && r <= 'Z' {
+      prev := rune(name[i-1])
+      if prev >= 'a' && prev <= 'z' || prev >= '0' && prev <= '9' {
+        b.WriteByte('_')
+      }
+    }
+    b.WriteRune(r)
+  }
+  return strings.ToUpper(b.String())
+}
+
+// lintEachMessage - Calls 'fn' for every message of 'files', nested ones included.
+func lintEachMessage(files linker.Files, fn func(msg protoreflect.MessageDescriptor)) {
+  for _, file := range files {
+    messages, _, _ := walkDescriptors(file)
+    for _, msg := range messages {
+      fn(msg)
+    }
+  }
+}
+
+// lintEachEnum - Calls 'fn' for every enum of 'files', nested ones included.
+func lintEachEnum(files linker.Files, fn func(enum protoreflect.EnumDescriptor)) {
+  for _, file := range files {
+    _, enums, _ := walkDescriptors(file)
+    for _, enum := range enums {
+      fn(enum)
+    }
+  }
+}
+
+// lintEachMethod - Calls 'fn' for every RPC of every service in 'files'.
+func lintEachMethod(files linker.Files, fn func(method protoreflect.MethodDescriptor)) {
+  for _, file := range files {
+    for i := 0; i < file.Services().Len(); i++ {
+      methods := file.Services().Get(i).Methods()
+      for j := 0; j < methods.Len(); j++ {
+        fn(methods.Get(j))
+      }
+    }
+  }
+}
+
+// lintEachService - Calls 'fn' for every service in 'files'.
+func lintEachService(files linker.Files, fn func(service protoreflect.ServiceDescriptor)) {
+  for _, file := range files {
+    for i := 0; i < file.Services().Len(); i++ {
+      fn(file.Services().Get(i))
+    }
+  }
+}
+
+func lintPackageDefined(r *lintReport, files linker.Files) {
+  for _, file := range files {
+    if file.Package() == "" {
+      r.add(file, protoreflect.SourceLocation{}, "file %q does not declare a package", file.Path())
+    }
+  }
+}
+
+func lintPackageDirectoryMatch(r *lintReport, files linker.Files) {
+  for _, file := range files {
+    if file.Package() == "" {
+      continue
+    }
+    want := strings.ReplaceAll(string(file.Package()), ".", "/")
+    if dir := path.Dir(file.Path()); dir != want {
+      r.add(
+        file,
+        packageLocation(file),
+        "files of package %q should be in directory %q, not %q",
+        file.Package(), want, dir,
+      )
+    }
+  }
+}
+
+func lintPackageSameDirectory(r *lintReport, files linker.Files) {
+  dirs := map[protoreflect.FullName]string{}
+  for _, file := range files {
+    dir := path.Dir(file.Path())
+    first, ok := dirs[file.Package()]
+    if !ok {
+      dirs[file.Package()] = dir
+      continue
+    }
+    if first != dir {
+      r.add(
+        file,
+        packageLocation(file),
+        "package %q is spread across directories %q and %q",
+        file.Package(), first, dir,
+      )
+    }
+  }
+}
+
+func lintPackageLowerSnakeCase(r *lintReport, files linker.Files) {
+  for _, file := range files {
+    for _, part := range strings.Split(string(file.Package()), ".") {
+      if part != "" && !lowerSnakeCasePattern.MatchString(part) {
+        r.add(file, packageLocation(file), "package %q should be lower_snake_case", file.Package())
+        break
+      }
+    }
+  }
+}
+
+func lintMessagePascalCase(r *lintReport, files linker.Files) {
+  lintEachMessage(files, func(msg protoreflect.MessageDescriptor){
+    if !pascalCasePattern.MatchString(string(msg.Name())) {
+      r.addDesc(msg, "message %q should be PascalCase", msg.Name())
+    }
+  })
+}
+
+func lintFieldLowerSnakeCase(r *lintReport, files linker.Files) {
+  lintEachMessage(files, func(msg protoreflect.MessageDescriptor){
+    for i := 0; i < msg.Fields().Len(); i++ {
+      field := msg.Fields().Get(i)
+      // ->> proto2 group fields are named after their PascalCase message.
+      if field.Kind() == protoreflect.GroupKind {
+        continue
+      }
+      if !lowerSnakeCasePattern.MatchString(string(field.Name())) {
+        r.addDesc(field, "field %q should be lower_snake_case", field.Name())
+      }
+    }
+  })
+}
+
+func lintOneofLowerSnakeCase(r *lintReport, files linker.Files) {
+  lintEachMessage(files, func(msg protoreflect.MessageDescriptor){
+    for i := 0; i < msg.Oneofs().Len(); i++ {
+      oneof := msg.Oneofs().Get(i)
+      if oneof.IsSynthetic() {
+        continue
+      }
+      if !lowerSnakeCasePattern.MatchString(string(oneof.Name())) {
+        r.addDesc(oneof, "oneof %q should be lower_snake_case", oneof.Name())
+      }
+    }
+  })
+}
+
+func lintEnumPascalCase(r *lintReport, files linker.Files) {
+  lintEachEnum(files, func(enum protoreflect.EnumDescriptor){
+    if !pascalCasePattern.MatchString(string(enum.Name())) {
+      r.addDesc(enum, "enum %q should be PascalCase", enum.Name())
+    }
+  })
+}
+
+func lintEnumValueUpperSnakeCase(r *lintReport, files linker.Files) {
+  lintEachEnum(files, func(enum protoreflect.EnumDescriptor){
+    for i := 0; i < enum.Values().Len(); i++ {
+      value := enum.Values().Get(i)
+      if !upperSnakeCasePattern.MatchString(string(value.Name())) {
+        r.addDesc(value, "enum value %q should be UPPER_SNAKE_CASE", value.Name())
+      }
+    }
+  })
+}
+
+func lintEnumFirstValueZero(r *lintReport, files linker.Files) {
+  lintEachEnum(files, func(enum protoreflect.EnumDescriptor){
+    if enum.Values().Len() == 0 {
+      return
+    }
+    if first := enum.Values().Get(0); first.Number() != 0 {
+      r.addDesc(first, "first value of enum %q should be 0, not %d", enum.Name(), first.Number())
+    }
+  })
+}
+
+func lintServicePascalCase(r *lintReport, files linker.Files) {
+  lintEachService(files, func(service protoreflect.ServiceDescriptor){
+    if !pascalCasePattern.MatchString(string(service.Name())) {
+      r.addDesc(service, "service %q should be PascalCase", service.Name())
+    }
+  })
+}
+
+func lintRPCPascalCase(r *lintReport, files linker.Files) {
+  lintEachMethod(files, func(method protoreflect.MethodDescriptor){
+    if !pascalCasePattern.MatchString(string(method.Name())) {
+      r.addDesc(method, "rpc %q should be PascalCase", method.Name())
+    }
+  })
+}
+
+func lintEnumValuePrefix(r *lintReport, files linker.Files) {
+  lintEachEnum(files, func(enum protoreflect.EnumDescriptor){
+    prefix := toUpperSnakeCase(string(enum.Name())) + "_"
+    for i := 0; i < enum.Values().Len(); i++ {
+      value := enum.Values().Get(i)
+      if !strings.HasPrefix(string(value.Name()), prefix) {
+        r.addDesc(value, "enum value %q should be prefixed with %q", value.Name(), prefix)
+      }
+    }
+  })
+}
+
+func lintEnumZeroValueSuffix(r *lintReport, files linker.Files) {
+  lintEachEnum(files, func(enum protoreflect.EnumDescriptor){
+    for i := 0; i < enum.Values().Len(); i++ {
+      value := enum.Values().Get(i)
+      if value.Number() == 0 && !strings.HasSuffix(string(value.Name()), "_UNSPECIFIED") {
+        r.addDesc(value, "zero value %q of enum %q should end with \"_UNSPECIFIED\"", value.Name(), enum.Name())
+      }
+    }
+  })
+}
+
+func lintServiceSuffix(r *lintReport, files linker.Files) {
+  lintEachService(files, func(service protoreflect.ServiceDescriptor){
+    if !strings.HasSuffix(string(service.Name()), "Service") {
+      r.addDesc(service, "service %q should end with \"Service\"", service.Name())
+    }
+  })
+}
+
+// standardMessageName - Returns true when 'msg' is named <Method><suffix> or
+// <Service><Method><suffix>.
+func standardMessageName(
+  method protoreflect.MethodDescriptor,
+  msg    protoreflect.MessageDescriptor,
+  suffix string,
+) bool {
+  name := string(msg.Name())
+  return name == string(method.Name())+suffix ||
+         name == string(method.Parent().Name())+string(method.Name())+suffix
+}
+
+func lintRPCRequestStandardName(r *lintReport, files linker.Files) {
+  lintEachMethod(files, func(method protoreflect.MethodDescriptor){
+    if !standardMessageName(method, method.Input(), "Request") {
+      r.addDesc(
+        method,
+        "rpc %q request should be named %q, not %q",
+        method.Name(), string(method.Name())+"Request", method.Input().FullName(),
+      )
+    }
+  })
+}
+
+func lintRPCResponseStandardName(r *lintReport, files linker.Files) {
+  lintEachMethod(files, func(method protoreflect.MethodDescriptor){
+    if !standardMessageName(method, method.Output(), "Response") {
+      r.addDesc(
+        method,
+        "rpc %q response should be named %q, not %q",
+        method.Name(), string(method.Name())+"Response", method.Output().FullName(),
+      )
+    }
+  })
+}
+
+func lintRPCRequestResponseUnique(r *lintReport, files linker.Files) {
+  used := map[protoreflect.FullName]protoreflect.FullName{}
+  lintEachMethod(files, func(method protoreflect.MethodDescriptor){
+    for _, msg := range []protoreflect.MessageDescriptor{ method.Input(), method.Output() } {
+      first, ok := used[msg.FullName()]
+      if !ok {
+        used[msg.FullName()] = method.FullName()
+        continue
+      }
+      r.addDesc(
+        method,
+        "rpc %q reuses %q, already used by %q",
+        method.Name(), msg.FullName(), first,
+      )
+    }
+  })
+}
+
+// lintComment - Reports 'desc' when it has no leading comment.
+func lintComment(r *lintReport, desc protoreflect.Descriptor, kind string) {
+  // ->> Files without source info, e.g. descriptor sets built without
+  //     --include_source_info, carry no comments to check.
+  file := desc.ParentFile()
+  if file == nil || file.SourceLocations().Len() == 0 {
+    return
+  }
+  loc := file.SourceLocations().ByDescriptor(desc)
+  if strings.TrimSpace(loc.LeadingComments) == "" {
+    r.add(desc, loc, "%s %q should have a comment", kind, desc.Name())
+  }
+}
+
+func lintCommentMessage(r *lintReport, files linker.Files) {
+  lintEachMessage(files, func(msg protoreflect.MessageDescriptor){
+    lintComment(r, msg, "message")
+  })
+}
+
+func lintCommentField(r *lintReport, files linker.Files) {
+  lintEachMessage(files, func(msg protoreflect.MessageDescriptor){
+    for i := 0; i < msg.Fields().Len(); i++ {
+      lintComment(r, msg.Fields().Get(i), "field")
+    }
+  })
+}
+
+func lintCommentEnum(r *lintReport, files linker.Files) {
+  lintEachEnum(files, func(enum protoreflect.EnumDescriptor){
+    lintComment(r, enum, "enum")
+  })
+}
+
+func lintCommentService(r *lintReport, files linker.Files) {
+  lintEachService(files, func(service protoreflect.ServiceDescriptor){
+    lintComment(r, service, "service")
+  })
+}
+
+func lintCommentRPC(r *lintReport, files linker.Files) {
+  lintEachMethod(files, func(method protoreflect.MethodDescriptor){
+    lintComment(r, method, "rpc")
+  })
+}
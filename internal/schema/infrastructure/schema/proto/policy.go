@@ -0,0 +1,366 @@
+package proto
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/google/cel-go/cel"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+)
+
+// policyVariables -- The CEL variable each domain.PolicyTarget's element is
+// bound to. Every expression can also read the declaring `file`.
+var policyVariables = map[domain.PolicyTarget]string{
+  domain.PolicyFile    : "file",
+  domain.PolicyMessage : "message",
+  domain.PolicyField   : "field",
+  domain.PolicyEnum    : "enum",
+  domain.PolicyService : "service",
+  domain.PolicyMethod  : "method",
+}
+
+var (
+  policyEnvs   = map[domain.PolicyTarget]*cel.Env{}
+  policyEnvsMu sync.Mutex
+)
+
+// policyEnv - Returns the CEL environment Policies of 'target' compile in.
+// Elements are exposed as maps, see the descriptor model functions below.
+func policyEnv(target domain.PolicyTarget)( *cel.Env, error ){
+  policyEnvsMu.Lock()
+  defer policyEnvsMu.Unlock()
+
+  if env, ok := policyEnvs[target]; ok {
+    return env, nil
+  }
+  name, ok := policyVariables[target]
+  if !ok {
+    return nil, fmt.Errorf("%w: %q", domain.ErrUnknownPolicyTarget, target)
+  }
+
+  model := cel.MapType(cel.StringType, cel.DynType)
+  vars  := []cel.EnvOption{ cel.Variable("file", model) }
+  if name != "file" {
+    vars = append(vars, cel.Variable(name, model))
+  }
+  env, err := cel.NewEnv(vars...)
+  if err != nil {
+    return nil, err
+  }
+  policyEnvs[target] = env
+  return env, nil
+}
+
+// compiledPolicy -- A domain.Policy with its expression compiled.
+type compiledPolicy struct {
+  domain.Policy
+  program cel.Program
+}
+
+// compilePolicy - Compiles a Policy's expression, checking that it evaluates
+// to a bool.
+//
+// Potential Errors:
+//    - domain.ErrUnknownPolicyTarget
+//    - domain.ErrInvalidPolicy :: The expression fails to compile or doesn't return a bool.
+func compilePolicy(policy domain.Policy)( *compiledPolicy, error ){
+  env, err := policyEnv(policy.Target)
+  if err != nil {
+    return nil, err
+  }
+  ast, issues := env.Compile(policy.Expression)
+  if issues != nil && issues.Err() != nil {
+    return nil, fmt.Errorf("%w: %s", domain.ErrInvalidPolicy, issues.Err().Error())
+  }
+  if ast.OutputType() != cel.BoolType {
+    return nil, fmt.Errorf(
+      "%w: expression must return a bool, not %s",
+      domain.ErrInvalidPolicy,
+      ast.OutputType(),
+    )
+  }
+  program, err := env.Program(ast)
+  if err != nil {
+    return nil, fmt.Errorf("%w: %s", domain.ErrInvalidPolicy, err.Error())
+  }
+  return &compiledPolicy{ Policy: policy, program: program }, nil
+}
+
+// policyElement -- A single descriptor a Policy is evaluated against.
+type policyElement struct {
+  desc  protoreflect.Descriptor
+  file  linker.File
+  model map[string]any
+}
+
+// EvaluatePolicies - Evaluates every Policy against the elements of 'files'
+// it targets. Elements the expression returns false for are reported with the
+// Policy's Severity. Policies that fail to compile, or to evaluate, are
+// reported once as a warning rather than failing, so a broken Policy never
+// blocks an upload. Results are sorted by file, line and rule.
+func EvaluatePolicies(
+  files    linker.Files,
+  policies []domain.Policy,
+) []domain.Diagnostic {
+  diagnostics := []domain.Diagnostic{}
+  warn := func(policy domain.Policy, f string, args ...any) {
+    diagnostics = append(diagnostics, domain.Diagnostic{
+      Severity : domain.SeverityWarning,
+      Rule     : policy.Name,
+      Message  : fmt.Sprintf(f, args...),
+    })
+  }
+
+  for _, policy := range policies {
+    compiled, err := compilePolicy(policy)
+    if err != nil {
+      warn(policy, "policy %q failed to compile: %s", policy.Name, err.Error())
+      continue
+    }
+    severity := policy.Severity
+    if severity == "" {
+      severity = domain.SeverityError
+    }
+
+    for _, el := range policyElements(files, policy.Target) {
+      if !policy.Applies(string(el.file.Package())) {
+        continue
+      }
+      vars := map[string]any{ "file": fileModel(el.file) }
+      vars[policyVariables[policy.Target]] = el.model
+
+      out, _, err := compiled.program.Eval(vars)
+      if err != nil {
+        warn(policy, "policy %q failed to evaluate %s: %s", policy.Name, el.desc.FullName(), err.Error())
+        break
+      }
+      if ok, _ := out.Value().(bool); ok {
+        continue
+      }
+      diagnostics = append(diagnostics, policyViolation(policy, severity, el))
+    }
+  }
+
+  sort.SliceStable(diagnostics, func(i, j int) bool {
+    a, b := diagnostics[i], diagnostics[j]
+    if a.File != b.File {
+      return a.File < b.File
+    }
+    if a.Line != b.Line {
+      return a.Line < b.Line
+    }
+    return a.Rule < b.Rule
+  })
+  return diagnostics
+}
+
+// policyViolation - Reports 'el' as violating 'policy', at its declaration.
+func policyViolation(
+  policy   domain.Policy,
+  severity domain.Severity,
+  el       policyElement,
+) domain.Diagnostic {
+  name := string(el.desc.FullName())
+  if el.desc == el.file {
+    name = el.file.Path()
+  }
+  message := fmt.Sprintf("%s violates policy %q", name, policy.Name)
+  if policy.Description != "" {
+    message = fmt.Sprintf("%s: %s", name, policy.Description)
+  }
+
+  d := domain.Diagnostic{
+    File     : el.file.Path(),
+    Severity : severity,
+    Rule     : policy.Name,
+    Path     : name,
+    Message  : message,
+  }
+  if el.desc != el.file {
+    if loc := el.file.SourceLocations().ByDescriptor(el.desc); loc.Path != nil {
+      d.Line   = loc.StartLine + 1
+      d.Column = loc.StartColumn + 1
+    }
+  }
+  return d
+}
+
+// policyElements - Returns every element of 'files' a Policy of 'target' is
+// evaluated against, in declaration order.
+func policyElements(
+  files  linker.Files,
+  target domain.PolicyTarget,
+) []policyElement {
+  elements := []policyElement{}
+  for _, file := range files {
+    messages, enums, _ := walkDescriptors(file)
+    switch target {
+    case domain.PolicyFile:
+      elements = append(elements, policyElement{ file, file, fileModel(file) })
+    case domain.PolicyMessage:
+      for _, msg := range messages {
+        elements = append(elements, policyElement{ msg, file, messageModel(msg) })
+      }
+    case domain.PolicyField:
+      for _, msg := range messages {
+        for i := 0; i < msg.Fields().Len(); i++ {
+          field := msg.Fields().Get(i)
+          elements = append(elements, policyElement{ field, file, fieldModel(field) })
+        }
+      }
+    case domain.PolicyEnum:
+      for _, enum := range enums {
+        elements = append(elements, policyElement{ enum, file, enumModel(enum) })
+      }
+    case domain.PolicyService, domain.PolicyMethod:
+      for i := 0; i < file.Services().Len(); i++ {
+        service := file.Services().Get(i)
+        if target == domain.PolicyService {
+          elements = append(elements, policyElement{ service, file, serviceModel(service) })
+          continue
+        }
+        for j := 0; j < service.Methods().Len(); j++ {
+          method := service.Methods().Get(j)
+          elements = append(elements, policyElement{ method, file, methodModel(method) })
+        }
+      }
+    }
+  }
+  return elements
+}
+
+// ->> Descriptor model. Every element is exposed to CEL as a map, so
+//     expressions read e.g. `message.fields`, `method.output` or `file.package`.
+
+func fileModel(file protoreflect.FileDescriptor) map[string]any {
+  imports := []any{}
+  for i := 0; i < file.Imports().Len(); i++ {
+    imports = append(imports, file.Imports().Get(i).Path())
+  }
+  options := []any{}
+  if file.Options() != nil {
+    file.Options().ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+      options = append(options, string(fd.Name()))
+      return true
+    })
+  }
+  return map[string]any{
+    "path"    : file.Path(),
+    "package" : string(file.Package()),
+    "syntax"  : file.Syntax().String(),
+    "imports" : imports,
+    "options" : options,
+  }
+}
+
+func messageModel(msg protoreflect.MessageDescriptor) map[string]any {
+  fields := []any{}
+  for i := 0; i < msg.Fields().Len(); i++ {
+    fields = append(fields, fieldModel(msg.Fields().Get(i)))
+  }
+  oneofs := []any{}
+  for i := 0; i < msg.Oneofs().Len(); i++ {
+    if oneof := msg.Oneofs().Get(i); !oneof.IsSynthetic() {
+      oneofs = append(oneofs, string(oneof.Name()))
+    }
+  }
+  parent := ""
+  if p, ok := msg.Parent().(protoreflect.MessageDescriptor); ok {
+    parent = string(p.FullName())
+  }
+  return map[string]any{
+    "name"      : string(msg.Name()),
+    "full_name" : string(msg.FullName()),
+    "package"   : string(msg.ParentFile().Package()),
+    "parent"    : parent,
+    "fields"    : fields,
+    "oneofs"    : oneofs,
+    "comment"   : descriptorComment(msg),
+  }
+}
+
+func fieldModel(field protoreflect.FieldDescriptor) map[string]any {
+  typeName := ""
+  switch {
+  case field.Message() != nil:
+    typeName = string(field.Message().FullName())
+  case field.Enum() != nil:
+    typeName = string(field.Enum().FullName())
+  }
+  oneof := ""
+  if o := field.ContainingOneof(); o != nil && !o.IsSynthetic() {
+    oneof = string(o.Name())
+  }
+  return map[string]any{
+    "name"      : string(field.Name()),
+    "full_name" : string(field.FullName()),
+    "number"    : int64(field.Number()),
+    "json_name" : field.JSONName(),
+    "type"      : field.Kind().String(),
+    "type_name" : typeName,
+    "label"     : field.Cardinality().String(),
+    "map"       : field.IsMap(),
+    "oneof"     : oneof,
+    "message"   : string(field.ContainingMessage().FullName()),
+    "comment"   : descriptorComment(field),
+  }
+}
+
+func enumModel(enum protoreflect.EnumDescriptor) map[string]any {
+  values := []any{}
+  for i := 0; i < enum.Values().Len(); i++ {
+    value := enum.Values().Get(i)
+    values = append(values, map[string]any{
+      "name"   : string(value.Name()),
+      "number" : int64(value.Number()),
+    })
+  }
+  return map[string]any{
+    "name"      : string(enum.Name()),
+    "full_name" : string(enum.FullName()),
+    "package"   : string(enum.ParentFile().Package()),
+    "values"    : values,
+    "comment"   : descriptorComment(enum),
+  }
+}
+
+func serviceModel(service protoreflect.ServiceDescriptor) map[string]any {
+  methods := []any{}
+  for i := 0; i < service.Methods().Len(); i++ {
+    methods = append(methods, methodModel(service.Methods().Get(i)))
+  }
+  return map[string]any{
+    "name"      : string(service.Name()),
+    "full_name" : string(service.FullName()),
+    "package"   : string(service.ParentFile().Package()),
+    "methods"   : methods,
+    "comment"   : descriptorComment(service),
+  }
+}
+
+func methodModel(method protoreflect.MethodDescriptor) map[string]any {
+  return map[string]any{
+    "name"             : string(method.Name()),
+    "full_name"        : string(method.FullName()),
+    "package"          : string(method.ParentFile().Package()),
+    "service"          : string(method.Parent().FullName()),
+    "input"            : string(method.Input().FullName()),
+    "output"           : string(method.Output().FullName()),
+    "client_streaming" : method.IsStreamingClient(),
+    "server_streaming" : method.IsStreamingServer(),
+    "comment"          : descriptorComment(method),
+  }
+}
+
+// descriptorComment - Returns the leading comment of 'desc'.
+func descriptorComment(desc protoreflect.Descriptor) string {
+  file := desc.ParentFile()
+  if file == nil {
+    return ""
+  }
+  return cleanComment(file.SourceLocations().ByDescriptor(desc).LeadingComments)
+}
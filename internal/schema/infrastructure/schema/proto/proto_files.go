@@ -3,24 +3,31 @@ package proto
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/bufbuild/protocompile"
 	"github.com/bufbuild/protocompile/linker"
 
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/cypher"
+
 	log "github.com/sirupsen/logrus"
 )
 
 type ProtoFiles struct {
   filepaths []string
   files     []linker.File
-  parsed    map[string]*ProtoCypherCompiler
-  compiled  map[string]*ProtoCypherCompiler
-  depGraph  ProtoDependencyGraph
+  shared    []*ProtoMetadata
+  opts      compileOptions
+  compiled  map[string]*ProtoGraphCompiler
+  sharedCmp []*ProtoGraphCompiler
+  depGraph  *ProtoDependencyGraph
+  warnings  []domain.Diagnostic
 }
 
 // NewLocal --- For Testing on local proto files.
@@ -28,63 +35,227 @@ func NewLocalFiles(
   ctx          context.Context,
   src          string,
   filepaths    []string,
+  opts         ...CompileOption,
 )( *ProtoFiles, error ){
   // <NOTE> For Local Files
   sources := loadProtoSources(src)
-  compiler := protocompile.Compiler{
-    Resolver: &protocompile.SourceResolver{
-      Accessor: protocompile.SourceAccessorFromMap(sources), 
-    },
-  }
+  return compileProtoFiles(
+    ctx,
+    protocompile.SourceAccessorFromMap(sources),
+    filepaths,
+    opts...,
+  )
+}
 
-  files, err := compiler.Compile(ctx, filepaths...)
-  if err != nil {
-    log.Error("Failed to compile proto files: %s", err.Error())
-    return nil, err
+// NewSourceFiles - Creates a ProtoFiles instance from in-memory proto sources,
+// keyed by their import path. Every provided source is compiled.
+func NewSourceFiles(
+  ctx     context.Context,
+  sources map[string][]byte,
+  opts    ...CompileOption,
+)( *ProtoFiles, error ){
+  contents  := make(map[string]string, len(sources))
+  filepaths := make([]string, 0, len(sources))
+  for path, data := range sources {
+    contents[path] = string(data)
+    filepaths = append(filepaths, path)
   }
+  sort.Strings(filepaths)
 
-  depGraph := BuildDependencyGraph(files)
-  depGraph.TopologicalSort()
+  return compileProtoFiles(
+    ctx,
+    protocompile.SourceAccessorFromMap(contents),
+    filepaths,
+    opts...,
+  )
+}
 
-  return &ProtoFiles{
-    filepaths : filepaths,
-    files     : files,
-    parsed    : make(map[string]*ProtoCypherCompiler),
-    depGraph  : depGraph,
-  }, nil
+// LoadSchema - Implements domain.SchemaLoader for protobuf sources.
+func LoadSchema(
+  ctx   context.Context,
+  lc    domain.LoadContext,
+  files map[string][]byte,
+)( domain.ComparableSchema, error ){
+  return NewSchemaLoader()(ctx, lc, files)
 }
 
-// NewProtoFiles - creates a ProtoFiles instance. Settings up both
-// protocompile and our Proto Dependency Graph.
-func NewBlobFiles(
-  ctx          context.Context,
-  filepaths    []string,
-  protoHandler *ProtoHandler,
+// NewSchemaLoader - Returns a domain.SchemaLoader that compiles protobuf
+// sources with 'opts', e.g. WithPlatformImports, along with the
+// CompileOptions each domain.LoadContext implies, see loadOptions. Uploaded
+// FileDescriptorSets, see domain.IsDescriptorSetUpload, are linked with
+// NewDescriptorFiles.
+func NewSchemaLoader(opts ...CompileOption) domain.SchemaLoader {
+  return func(
+    ctx   context.Context,
+    lc    domain.LoadContext,
+    files map[string][]byte,
+  )( domain.ComparableSchema, error ){
+    opts := append(append([]CompileOption{}, opts...), loadOptions(lc)...)
+    if domain.IsDescriptorSetUpload(files) {
+      for _, data := range files {
+        return NewDescriptorFiles(ctx, data, opts...)
+      }
+    }
+    return NewSourceFiles(ctx, files, opts...)
+  }
+}
+
+// loadOptions - Returns the CompileOptions a domain.LoadContext implies.
+func loadOptions(lc domain.LoadContext) []CompileOption {
+  opts := []CompileOption{}
+  if lc.Version.Scheme != "" {
+    opts = append(opts, WithVersioning(lc.Version))
+  }
+  if ns := domain.GraphNamespace(lc.EntityID); ns != "" {
+    opts = append(opts, WithNamespace(ns))
+  }
+  if lc.Subject != "" {
+    opts = append(opts, WithSubject(lc.Subject))
+  }
+  return opts
+}
+
+func compileProtoFiles(
+  ctx       context.Context,
+  accessor  func(string)(io.ReadCloser, error),
+  filepaths []string,
+  opts      ...CompileOption,
 )( *ProtoFiles, error ){
-  // For BlobDB Stored Files
-  compiler := protocompile.Compiler{
-    Resolver: &protocompile.SourceResolver{
-      Accessor: protoHandler.GenerateResolver(ctx),
-    },
+  options  := newCompileOptions(opts)
+
+  // ->> Catch missing imports and import cycles before compiling, so they're
+  //     reported with the offending file and the full cycle path.
+  depGraph := ScanDependencyGraph(accessor, filepaths, options.isShared)
+  if err := depGraph.Validate(); err != nil {
+    log.Errorf("Invalid proto imports: %s", err.Error())
+    return nil, err
+  }
+
+  collector := newDiagnosticsCollector()
+  compiler  := protocompile.Compiler{
+    Resolver       : options.resolver(accessor),
+    SourceInfoMode : protocompile.SourceInfoStandard,
+    Reporter       : collector.Reporter(),
   }
 
   files, err := compiler.Compile(ctx, filepaths...)
+  if err := collector.Err(err); err != nil {
+    log.Errorf("Failed to compile proto files: %s", err.Error())
+    return nil, err
+  }
+
+  // ->> Only warnings about the tenant's own files are worth reporting.
+  warnings := []domain.Diagnostic{}
+  for _, d := range collector.Diagnostics() {
+    if !options.isShared(d.File) {
+      warnings = append(warnings, d)
+    }
+  }
+
+  shared, err := collectSharedImports(files, options)
   if err != nil {
-    log.Error("Failed to compile proto files: %s", err.Error())
+    log.Errorf("Failed to load shared proto imports: %s", err.Error())
     return nil, err
   }
 
-  depGraph := BuildDependencyGraph(files)
-  depGraph.TopologicalSort()
+  depGraph.Link(files)
+  for _, dep := range shared {
+    depGraph.MarkExternal(dep.Path)
+  }
+  if err := depGraph.TopologicalSort(); err != nil {
+    log.Errorf("Failed to order proto files: %s", err.Error())
+    return nil, err
+  }
 
   return &ProtoFiles{
     filepaths : filepaths,
     files     : files,
-    parsed    : make(map[string]*ProtoCypherCompiler),
+    shared    : shared,
+    opts      : options,
     depGraph  : depGraph,
+    warnings  : warnings,
   }, nil
 }
 
+// Files - Returns the compiled proto files backing this ProtoFiles instance.
+func(pf *ProtoFiles) Files() linker.Files {
+  return pf.files
+}
+
+// Diagnostics - Returns the warnings reported while compiling, e.g. unused imports.
+func(pf *ProtoFiles) Diagnostics() []domain.Diagnostic {
+  return pf.warnings
+}
+
+// Lint - Implements domain.Lintable, running the rules selected by 'config'
+// over every compiled tenant file.
+func(pf *ProtoFiles) Lint(config domain.LintConfig) []domain.Diagnostic {
+  return Lint(pf.files, config)
+}
+
+// EvaluatePolicies - Implements domain.PolicyEvaluable, evaluating every
+// Policy over the compiled tenant files.
+func(pf *ProtoFiles) EvaluatePolicies(policies []domain.Policy) []domain.Diagnostic {
+  return EvaluatePolicies(pf.files, policies)
+}
+
+// TransitiveImports - Implements domain.Bundleable, returning every tenant
+// and shared file 'paths' transitively import, well-known types included.
+func(pf *ProtoFiles) TransitiveImports(paths []string) []string {
+  shared := make(map[string]*ProtoMetadata, len(pf.shared))
+  for _, dep := range pf.shared {
+    shared[dep.Path] = dep
+  }
+
+  // ->> The graph stops at shared files, so follow their imports from here.
+  seen    := map[string]bool{}
+  imports := []string{}
+  queue   := pf.depGraph.Imports(paths...)
+  for _, path := range paths {
+    seen[path] = true
+  }
+  for len(queue) != 0 {
+    next := queue[0]
+    queue = queue[1:]
+    if seen[next] {
+      continue
+    }
+    seen[next] = true
+    imports = append(imports, next)
+    if dep, ok := shared[next]; ok {
+      queue = append(queue, dep.Deps...)
+    }
+  }
+  sort.Strings(imports)
+  return imports
+}
+
+// SharedSource - Implements domain.Bundleable, returning the source of a
+// bundled google/api or platform proto.
+func(pf *ProtoFiles) SharedSource(path string)( []byte, bool ){
+  return pf.opts.sharedSource(path)
+}
+
+// DependencyGraph - Returns the file-level import graph of this ProtoFiles instance.
+func(pf *ProtoFiles) DependencyGraph() *ProtoDependencyGraph {
+  return pf.depGraph
+}
+
+// BreakingChanges - Compares 'previous' against this ProtoFiles instance and
+// returns every breaking change introduced by moving from previous to pf.
+//
+// Potential Errors:
+//    - domain.ErrIncomparableSchemas :: 'previous' isn't a protobuf Schema.
+func(pf *ProtoFiles) BreakingChanges(
+  previous domain.Schema,
+)( []domain.BreakingChange, error ){
+  prev, ok := previous.(*ProtoFiles)
+  if !ok {
+    return nil, domain.ErrIncomparableSchemas
+  }
+  return DetectBreakingChanges(prev.files, pf.files), nil
+}
+
 func loadProtoSources(root string) map[string]string {
   sources := make(map[string]string)
   _ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
@@ -106,66 +277,75 @@ func(pf *ProtoFiles) ParseSchemaFiles(
 ) error {
   // Create Dependency Graph to determine which order to parse schemas. 
 
-  compilers := make(map[string]*ProtoCypherCompiler)
-  errors := make(map[string]error)
-  var wg sync.WaitGroup
+  // ->> Shared imports are few and already ordered, dependencies first.
+  sharedCmp := make([]*ProtoGraphCompiler, 0, len(pf.shared))
+  for _, dep := range pf.shared {
+    compiler := newProtoGraphCompiler(pf.opts)
+    if err := compiler.Run(dep); err != nil {
+      return fmt.Errorf("Failed to compile shared import \"%s\": %s", dep.Path, err.Error())
+    }
+    sharedCmp = append(sharedCmp, compiler)
+  }
+
+  compilers   := make(map[string]*ProtoGraphCompiler)
+  diagnostics := []domain.Diagnostic{}
+  var (
+    wg sync.WaitGroup
+    mu sync.Mutex
+  )
 
   for _, dep := range pf.depGraph.Ordered {
-    fmt.Printf("Processing Package: \"%s\"\n",dep.PkgName)
-    fmt.Printf("Dependencies: \"%s\"\n", strings.Join(dep.Imports, ", "))
-
-    file := dep.File
-    if file.Syntax().String() != "proto3" {
-      return fmt.Errorf("currently only support proto3: not %s", file.Syntax().String())
-    }
-    compilers[dep.PkgName] = NewProtoCypherCompiler()
+    compilers[dep.Path] = newProtoGraphCompiler(pf.opts)
 
     wg.Add(1)
-    go func(key string, protoMetadata *ProtoMetadata, errors map[string]error){
+    go func(compiler *ProtoGraphCompiler, protoMetadata *ProtoMetadata){
       defer wg.Done()
-      if err := compilers[key].Run(protoMetadata); err != nil {
-        errors[protoMetadata.PkgName] = fmt.Errorf("")
+      if err := compiler.Run(protoMetadata); err != nil {
+        mu.Lock()
+        diagnostics = append(diagnostics, domain.Diagnostic{
+          File     : protoMetadata.Path,
+          Severity : domain.SeverityError,
+          Message  : err.Error(),
+        })
+        mu.Unlock()
       }
-    }(dep.PkgName, dep, errors)
+    }(compilers[dep.Path], dep)
   }
 
-
   wg.Wait()
-  time.Sleep(time.Second)
-
-  // <TODO> :: This is temporary. Will need to create an error handling message broker
-  err := new(strings.Builder)
-  for p, e := range errors {
-    if e != nil {
-      err.WriteString(fmt.Sprintf(
-        "Failed to compile \"%s\": %s\n",
-        p,
-        e.Error(),
-      ))
-    }
-  }
-  errMsg := err.String()
-  if errMsg != "" {
-    return fmt.Errorf(errMsg)
+
+  if len(diagnostics) != 0 {
+    domain.SortDiagnostics(diagnostics)
+    return &domain.DiagnosticsError{ Items: diagnostics }
   }
 
-  pf.compiled = compilers
+  pf.compiled  = compilers
+  pf.sharedCmp = sharedCmp
   return nil
 }
 
-// Cypher -- Combines all of the parsed Cypher Queries from the provided files
-// in order from least specificity to greatest specificity.
-func(pf *ProtoFiles) Cyphers()( []string, error ){
+// Graph -- Combines all of the compiled file graphs, in order from least
+// specificity to greatest specificity.
+func(pf *ProtoFiles) Graph()( *domain.SchemaGraph, error ){
   if pf.compiled == nil || len(pf.compiled) == 0 {
     return nil, fmt.Errorf("Files have not been compiled yet")
   }
-  cyphers := []string{}
+  graph := &domain.SchemaGraph{}
 
+  for _, shared := range pf.sharedCmp {
+    graph.Merge(shared.Graph())
+  }
   for _, dep := range pf.depGraph.Ordered {
-    cyphers = append(
-      cyphers, 
-      strings.TrimSpace(pf.compiled[dep.PkgName].WriteString()),
-    )
+    graph.Merge(pf.compiled[dep.Path].Graph())
+  }
+  return graph, nil
+}
+
+// Cypher -- Compiles the combined graph into parameterized, batched Cypher statements.
+func(pf *ProtoFiles) Cyphers()( []domain.CypherStatement, error ){
+  graph, err := pf.Graph()
+  if err != nil {
+    return nil, err
   }
-  return cyphers, nil
+  return cypher.NewWriter(cypher.DefaultBatchSize).Statements(graph)
 }
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/proto"
+)
+
+func TestDetectBreakingChanges(t *testing.T) {
+  ctx := context.Background()
+
+  prev, err := proto.NewLocalFiles(ctx, "./breaking/v1", []string{"orders.proto"})
+  if err != nil {
+    t.Fatal(err)
+  }
+  next, err := proto.NewLocalFiles(ctx, "./breaking/v2", []string{"orders.proto"})
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  changes, err := next.BreakingChanges(prev)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  found := map[string]domain.BreakingChange{}
+  for _, c := range changes {
+    found[c.RuleID+" "+c.Path] = c
+  }
+
+  tests := []struct{
+    name     string
+    key      string
+    severity domain.Severity
+  }{
+    { "field type changed",          "FIELD_SAME_TYPE orders.v1.Order.total",                 domain.SeverityError   },
+    { "field cardinality changed",   "FIELD_SAME_CARDINALITY orders.v1.Order.items",          domain.SeverityError   },
+    { "field renumbered",            "FIELD_SAME_NUMBER orders.v1.Order.region",              domain.SeverityError   },
+    { "field renamed",               "FIELD_SAME_NAME orders.v1.Order.customer_id",           domain.SeverityWarning },
+    { "reserved field deleted",      "FIELD_NO_DELETE_RESERVED orders.v1.Order",              domain.SeverityWarning },
+    { "reserved number reused",      "FIELD_NUMBER_REUSED orders.v1.Order.shard",             domain.SeverityError   },
+    { "message deleted",             "MESSAGE_NO_DELETE orders.v1.Legacy",                    domain.SeverityError   },
+    { "enum value renamed",          "ENUM_VALUE_SAME_NAME orders.v1.STATUS_DONE",            domain.SeverityWarning },
+    { "reserved enum value deleted", "ENUM_VALUE_NO_DELETE_RESERVED orders.v1.Status",        domain.SeverityWarning },
+    { "rpc deleted",                 "RPC_NO_DELETE orders.v1.OrderService",                  domain.SeverityError   },
+    { "rpc output changed",          "RPC_SAME_OUTPUT_TYPE orders.v1.OrderService.GetOrder",  domain.SeverityError   },
+    { "rpc streaming changed",       "RPC_SAME_SERVER_STREAMING orders.v1.OrderService.WatchOrder", domain.SeverityError },
+  }
+
+  for _, tt := range tests {
+    t.Run(tt.name, func(t *testing.T){
+      change, ok := found[tt.key]
+      if !assert.True(t, ok, "missing %s", tt.key) {
+        return
+      }
+      assert.Equal(t, tt.severity, change.Severity)
+      assert.Equal(t, "orders.proto", change.File)
+      assert.NotZero(t, change.Line)
+    })
+  }
+  assert.Len(t, changes, len(tests))
+
+  // ->> Comparing a version against itself must never report anything.
+  same, err := prev.BreakingChanges(prev)
+  assert.NoError(t, err)
+  assert.Empty(t, same)
+}
+
+func TestDetectBreakingChangesAcrossSyntaxes(t *testing.T) {
+  ctx := context.Background()
+
+  prev, err := proto.NewLocalFiles(ctx, "./breaking/legacy/v1", []string{"accounts.proto"})
+  if err != nil {
+    t.Fatal(err)
+  }
+  next, err := proto.NewLocalFiles(ctx, "./breaking/legacy/v2", []string{"accounts.proto"})
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  changes, err := next.BreakingChanges(prev)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  found := map[string]domain.BreakingChange{}
+  for _, c := range changes {
+    found[c.RuleID+" "+c.Path] = c
+  }
+
+  tests := []struct{
+    name     string
+    key      string
+    severity domain.Severity
+  }{
+    { "default changed",   "FIELD_SAME_DEFAULT accounts.v1.Account.retries",   domain.SeverityError   },
+    { "presence changed",  "FIELD_SAME_PRESENCE accounts.v1.Account.email",    domain.SeverityWarning },
+    { "group to message",  "FIELD_SAME_ENCODING accounts.v1.Account.settings", domain.SeverityError   },
+    { "enum opened",       "ENUM_SAME_TYPE accounts.v1.Status",                domain.SeverityError   },
+    { "extension deleted", "EXTENSION_NO_DELETE accounts.v1.referrer",         domain.SeverityError   },
+  }
+
+  for _, tt := range tests {
+    t.Run(tt.name, func(t *testing.T){
+      change, ok := found[tt.key]
+      if !assert.True(t, ok, "missing %s", tt.key) {
+        return
+      }
+      assert.Equal(t, tt.severity, change.Severity)
+    })
+  }
+  assert.Len(t, changes, len(tests))
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/proto"
+)
+
+func TestTransitiveImports(t *testing.T) {
+  ctx := context.Background()
+
+  files, err := proto.NewLocalFiles(
+    ctx,
+    "./impact",
+    []string{
+      "acme/common/v1/money.proto",
+      "acme/orders/v1/orders.proto",
+      "acme/billing/v1/billing.proto",
+    },
+  )
+  if err != nil {
+    t.Fatal(err)
+  }
+  assert.Equal(t, []string{
+    "acme/common/v1/money.proto",
+    "acme/orders/v1/orders.proto",
+  }, files.TransitiveImports([]string{ "acme/billing/v1/billing.proto" }))
+  assert.Equal(t, []string{
+    "acme/common/v1/money.proto",
+  }, files.TransitiveImports([]string{ "acme/orders/v1/orders.proto", "acme/billing/v1/billing.proto" }))
+  assert.Empty(t, files.TransitiveImports([]string{ "acme/common/v1/money.proto" }))
+
+  platform, err := proto.LoadPlatformImports("./shared/platform")
+  if err != nil {
+    t.Fatal(err)
+  }
+  shared, err := proto.NewLocalFiles(
+    ctx,
+    "./shared/tenant",
+    []string{ "invoices.proto" },
+    proto.WithPlatformImports(platform),
+  )
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  // ->> Shared imports are followed too, e.g. annotations.proto imports http.proto.
+  assert.Equal(t, []string{
+    "google/api/annotations.proto",
+    "google/api/field_behavior.proto",
+    "google/api/http.proto",
+    "google/protobuf/descriptor.proto",
+    "google/protobuf/timestamp.proto",
+    "platform/money/v1/money.proto",
+  }, shared.TransitiveImports([]string{ "invoices.proto" }))
+
+  for path, bundled := range map[string]bool{
+    "google/api/http.proto"           : true,
+    "platform/money/v1/money.proto"   : true,
+    "google/protobuf/timestamp.proto" : false,
+    "invoices.proto"                  : false,
+  }{
+    src, ok := shared.SharedSource(path)
+    assert.Equal(t, bundled, ok, path)
+    assert.Equal(t, bundled, len(src) != 0, path)
+  }
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/proto"
+)
+
+func TestDependencyGraphMultiFilePackage(t *testing.T) {
+  ctx := context.Background()
+
+  files, err := proto.NewLocalFiles(
+    ctx,
+    "./deps/multi",
+    []string{ "shop/cart.proto", "shop/items.proto", "common.proto" },
+  )
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  deps := files.DependencyGraph()
+  ordered := []string{}
+  for _, meta := range deps.Ordered {
+    ordered = append(ordered, meta.Path)
+  }
+  // ->> Both shop.v1 files are kept, each after the files it imports.
+  assert.Equal(t, []string{ "common.proto", "shop/items.proto", "shop/cart.proto" }, ordered)
+  assert.Equal(t, []string{ "shop/items.proto" }, deps.Dependencies("shop/cart.proto"))
+  assert.Equal(t, []string{ "shop/cart.proto", "shop/items.proto" }, deps.Dependents("common.proto"))
+
+  if err := files.ParseSchemaFiles(ctx); err != nil {
+    t.Fatal(err)
+  }
+  graph, err := files.Graph()
+  if err != nil {
+    t.Fatal(err)
+  }
+  nodes := map[string]domain.GraphNode{}
+  for _, n := range graph.Nodes {
+    nodes[string(n.Label)+" "+n.Key] = n
+  }
+  assert.Contains(t, nodes, "Message shop.v1.Cart")
+  assert.Contains(t, nodes, "Message shop.v1.Item")
+}
+
+func TestDependencyGraphImportCycle(t *testing.T) {
+  _, err := proto.NewLocalFiles(
+    context.Background(),
+    "./deps/cycle",
+    []string{ "a.proto", "b.proto", "c.proto" },
+  )
+  assert.ErrorIs(t, err, proto.ErrImportCycle)
+
+  var cycleErr *proto.ImportCycleError
+  if assert.True(t, errors.As(err, &cycleErr)) {
+    assert.Equal(t, []string{ "a.proto", "b.proto", "c.proto", "a.proto" }, cycleErr.Path)
+  }
+  assert.EqualError(t, err, "import cycle: a.proto -> b.proto -> c.proto -> a.proto")
+}
+
+func TestDependencyGraphMissingImports(t *testing.T) {
+  _, err := proto.NewLocalFiles(
+    context.Background(),
+    "./deps/missing",
+    []string{ "orders.proto", "refunds.proto" },
+  )
+  assert.ErrorIs(t, err, proto.ErrMissingImport)
+
+  var missingErr *proto.MissingImportError
+  if assert.True(t, errors.As(err, &missingErr)) {
+    // ->> Well-known imports resolve; the unknown import and file don't.
+    assert.Equal(t, []proto.MissingImport{
+      { File: "orders.proto", Import: "customers/customer.proto", Line: 6 },
+      { Import: "refunds.proto" },
+    }, missingErr.Missing)
+  }
+}
@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/proto"
+)
+
+func TestFileDescriptorSet(t *testing.T) {
+  ctx := context.Background()
+
+  files, err := proto.NewLocalFiles(
+    ctx,
+    "./impact",
+    []string{
+      "acme/billing/v1/billing.proto",
+      "acme/orders/v1/orders.proto",
+      "acme/common/v1/money.proto",
+    },
+  )
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  decode := func(opts domain.DescriptorOptions) *descriptorpb.FileDescriptorSet {
+    data, err := files.FileDescriptorSet(opts)
+    if err != nil {
+      t.Fatal(err)
+    }
+    set := &descriptorpb.FileDescriptorSet{}
+    if err := protov2.Unmarshal(data, set); err != nil {
+      t.Fatal(err)
+    }
+    return set
+  }
+
+  // ->> Every file is listed after the files it imports.
+  set   := decode(domain.DescriptorOptions{})
+  paths := []string{}
+  for _, fd := range set.File {
+    paths = append(paths, fd.GetName())
+    assert.Nil(t, fd.SourceCodeInfo, fd.GetName())
+  }
+  assert.Equal(t, []string{
+    "acme/common/v1/money.proto",
+    "acme/orders/v1/orders.proto",
+    "acme/billing/v1/billing.proto",
+  }, paths)
+  _, err = protodesc.NewFiles(set)
+  assert.NoError(t, err)
+
+  for _, fd := range decode(domain.DescriptorOptions{ SourceInfo: true }).File {
+    assert.NotNil(t, fd.SourceCodeInfo, fd.GetName())
+  }
+
+  platform, err := proto.LoadPlatformImports("./shared/platform")
+  if err != nil {
+    t.Fatal(err)
+  }
+  shared, err := proto.NewLocalFiles(
+    ctx,
+    "./shared/tenant",
+    []string{ "invoices.proto" },
+    proto.WithPlatformImports(platform),
+  )
+  if err != nil {
+    t.Fatal(err)
+  }
+  data, err := shared.FileDescriptorSet(domain.DescriptorOptions{})
+  assert.NoError(t, err)
+  set = &descriptorpb.FileDescriptorSet{}
+  assert.NoError(t, protov2.Unmarshal(data, set))
+  assert.Len(t, set.File, 1)
+
+  data, err = shared.FileDescriptorSet(domain.DescriptorOptions{ Imports: true })
+  assert.NoError(t, err)
+  set = &descriptorpb.FileDescriptorSet{}
+  assert.NoError(t, protov2.Unmarshal(data, set))
+  paths = []string{}
+  for _, fd := range set.File {
+    paths = append(paths, fd.GetName())
+  }
+  assert.Equal(t, "invoices.proto", paths[len(paths)-1])
+  assert.Contains(t, paths, "google/api/annotations.proto")
+  assert.Contains(t, paths, "google/protobuf/descriptor.proto")
+  assert.Contains(t, paths, "platform/money/v1/money.proto")
+  _, err = protodesc.NewFiles(set)
+  assert.NoError(t, err)
+}
+
+func TestDescriptorFiles(t *testing.T) {
+  ctx := context.Background()
+
+  platform, err := proto.LoadPlatformImports("./shared/platform")
+  if err != nil {
+    t.Fatal(err)
+  }
+  sources, err := proto.NewLocalFiles(
+    ctx,
+    "./shared/tenant",
+    []string{ "invoices.proto" },
+    proto.WithPlatformImports(platform),
+  )
+  if err != nil {
+    t.Fatal(err)
+  }
+  loader := proto.NewSchemaLoader(proto.WithPlatformImports(platform))
+
+  // ->> Sets built with or without their imports link the same way.
+  for _, opts := range []domain.DescriptorOptions{
+    { SourceInfo: true },
+    { Imports: true },
+  }{
+    data, err := sources.FileDescriptorSet(opts)
+    if err != nil {
+      t.Fatal(err)
+    }
+    set := &descriptorpb.FileDescriptorSet{}
+    assert.NoError(t, protov2.Unmarshal(data, set))
+    encoded, err := protojson.Marshal(set)
+    if err != nil {
+      t.Fatal(err)
+    }
+    assert.Equal(t, domain.DescriptorSetFile, domain.DescriptorSetPath(data))
+    assert.Equal(t, domain.DescriptorSetJSONFile, domain.DescriptorSetPath(encoded))
+
+    for path, upload := range map[string][]byte{
+      domain.DescriptorSetFile     : data,
+      domain.DescriptorSetJSONFile : encoded,
+    }{
+      schema, err := loader(ctx, domain.LoadContext{}, map[string][]byte{ path: upload })
+      if !assert.NoError(t, err, path) {
+        continue
+      }
+      files, ok := schema.(*proto.ProtoFiles)
+      assert.True(t, ok, path)
+      assert.Len(t, files.Files(), 1, path)
+      assert.Equal(t, "invoices.proto", files.Files()[0].Path(), path)
+      assert.Equal(t,
+        sources.TransitiveImports([]string{ "invoices.proto" }),
+        files.TransitiveImports([]string{ "invoices.proto" }),
+        path,
+      )
+
+      changes, err := files.BreakingChanges(sources)
+      assert.NoError(t, err, path)
+      assert.Empty(t, changes, path)
+      assert.NoError(t, files.ParseSchemaFiles(ctx), path)
+    }
+  }
+
+  // ->> Sources still compile as sources.
+  schema, err := loader(ctx, domain.LoadContext{}, map[string][]byte{
+    "a.proto": []byte(`syntax = "proto3"; package a.v1; message A {}`),
+  })
+  assert.NoError(t, err)
+  assert.NotNil(t, schema)
+
+  _, err = loader(ctx, domain.LoadContext{}, map[string][]byte{ domain.DescriptorSetFile: []byte("not a descriptor") })
+  assert.ErrorIs(t, err, proto.ErrInvalidDescriptorSet)
+
+  // ->> Imports that are neither in the set nor shared can't be resolved.
+  data, err := protov2.Marshal(&descriptorpb.FileDescriptorSet{
+    File: []*descriptorpb.FileDescriptorProto{{
+      Name       : protov2.String("b.proto"),
+      Package    : protov2.String("b.v1"),
+      Dependency : []string{ "missing.proto" },
+      Syntax     : protov2.String("proto3"),
+    }},
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+  _, err = loader(ctx, domain.LoadContext{}, map[string][]byte{ domain.DescriptorSetFile: data })
+  assert.ErrorIs(t, err, proto.ErrMissingImport)
+}
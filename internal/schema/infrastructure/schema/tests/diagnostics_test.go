@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/proto"
+)
+
+func TestCompileDiagnostics(t *testing.T) {
+  ctx := context.Background()
+
+  _, err := proto.NewLocalFiles(
+    ctx,
+    "./diagnostics",
+    []string{ "broken.proto", "invalid.proto" },
+  )
+  var diagErr domain.DiagnosticError
+  if !assert.True(t, errors.As(err, &diagErr), "expected diagnostics, got %v", err) {
+    return
+  }
+
+  // ->> Every error is reported, across every file, ordered by position.
+  positions := []string{}
+  for _, d := range diagErr.Diagnostics() {
+    assert.Equal(t, domain.SeverityError, d.Severity)
+    assert.NotZero(t, d.Column)
+    assert.NotEmpty(t, d.Message)
+    positions = append(positions, fmt.Sprintf("%s:%d", d.File, d.Line))
+  }
+  assert.Equal(t, []string{
+    "broken.proto:7",  // unknown type Customer
+    "broken.proto:11", // unknown type Reason
+    "invalid.proto:6", // unknown request type Entry
+    "invalid.proto:6", // unknown response type Entry
+  }, positions)
+
+  // ->> Warnings don't fail the compile, but are kept on the ProtoFiles.
+  files, err := proto.NewLocalFiles(ctx, "./diagnostics", []string{ "unused.proto" })
+  if !assert.NoError(t, err) {
+    return
+  }
+  warnings := files.Diagnostics()
+  if assert.Len(t, warnings, 1) {
+    assert.Equal(t, "unused.proto", warnings[0].File)
+    assert.Equal(t, 5, warnings[0].Line)
+    assert.Equal(t, domain.SeverityWarning, warnings[0].Severity)
+    assert.Contains(t, warnings[0].Message, "common.proto")
+  }
+}
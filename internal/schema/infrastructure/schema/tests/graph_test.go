@@ -0,0 +1,586 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/cypher"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/proto"
+)
+
+func compileTestGraph(t *testing.T) *domain.SchemaGraph {
+  ctx := context.Background()
+  files, err := proto.NewLocalFiles(
+    ctx,
+    "./main/protos",
+    []string{ "common.proto", "user.proto", "other.proto", "third.proto" },
+  )
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err := files.ParseSchemaFiles(ctx); err != nil {
+    t.Fatal(err)
+  }
+  graph, err := files.Graph()
+  if err != nil {
+    t.Fatal(err)
+  }
+  return graph
+}
+
+func TestProtoGraphCompiler(t *testing.T) {
+  graph := compileTestGraph(t)
+
+  nodes := map[string]domain.GraphNode{}
+  for _, n := range graph.Nodes {
+    nodes[string(n.Label)+" "+n.Key] = n
+  }
+  edges := map[string]bool{}
+  for _, e := range graph.Edges {
+    edges[e.FromKey+" "+string(e.Type)+" "+e.ToKey] = true
+  }
+
+  for _, key := range []string{
+    "Package user.v1alpha",
+    "Message user.v1alpha.User",
+    "Parameter user.v1alpha.User.address",
+    "Enum user.v1alpha.Rank",
+    "EnumValue user.v1alpha.Rank.SECOND",
+    "Service user.v1alpha.UserService",
+    "Method user.v1alpha.UserService.GetUser",
+  }{
+    assert.Contains(t, nodes, key)
+  }
+
+  for _, edge := range []string{
+    // ->> Every field gets its own HAS_PARAMETER, not just the last one.
+    "user.v1alpha.User HAS_PARAMETER user.v1alpha.User.id",
+    "user.v1alpha.User HAS_PARAMETER user.v1alpha.User.address",
+    // ->> Cross-package references point at the referenced package's keys.
+    "user.v1alpha.User.address USES_MSG_TYPE common.v1alpha.Address",
+    "user.v1alpha.User.address FROM_PACKAGE common.v1alpha",
+    "user.v1alpha.Player.rank USES_ENUM_TYPE user.v1alpha.Rank",
+    "user.v1alpha.Rank.One ALIAS user.v1alpha.Rank.FIRST",
+    "user.v1alpha IMPORTS common.v1alpha",
+    "user.v1alpha.GetUserRequest INPUT user.v1alpha.UserService.GetUser",
+    "user.v1alpha.GetUserResponse OUTPUT user.v1alpha.UserService.GetUser",
+    "user.v1alpha.UserService.GetUser RPC_METHOD user.v1alpha.UserService",
+  }{
+    assert.True(t, edges[edge], "missing edge %s", edge)
+  }
+}
+
+func TestProtoGraphNestedTypes(t *testing.T) {
+  ctx := context.Background()
+
+  files, err := proto.NewLocalFiles(ctx, "./nested", []string{ "shop.proto" })
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err := files.ParseSchemaFiles(ctx); err != nil {
+    t.Fatal(err)
+  }
+  graph, err := files.Graph()
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  nodes := map[string]domain.GraphNode{}
+  for _, n := range graph.Nodes {
+    nodes[string(n.Label)+" "+n.Key] = n
+  }
+  edges := map[string]bool{}
+  for _, e := range graph.Edges {
+    edges[e.FromKey+" "+string(e.Type)+" "+e.ToKey] = true
+  }
+
+  for _, key := range []string{
+    "Message shop.v1.Cart.Item",
+    "Message shop.v1.Cart.Item.Price",
+    "Enum shop.v1.Cart.Item.Kind",
+    "EnumValue shop.v1.Cart.Item.Kind.KIND_DIGITAL",
+    "Parameter shop.v1.Cart.Item.Price.units",
+    "Oneof shop.v1.Cart.payment",
+  }{
+    assert.Contains(t, nodes, key)
+  }
+  // ->> Map entries and proto3 'optional' oneofs are synthetic.
+  assert.NotContains(t, nodes, "Message shop.v1.Cart.BySkuEntry")
+  assert.NotContains(t, nodes, "Oneof shop.v1.Cart._note")
+  assert.Equal(t, "payment", nodes["Parameter shop.v1.Cart.voucher"].Props["oneof"])
+
+  for _, edge := range []string{
+    "shop.v1.Cart.Item NESTED_IN shop.v1.Cart",
+    "shop.v1.Cart.Item.Price NESTED_IN shop.v1.Cart.Item",
+    "shop.v1.Cart.Item.Kind NESTED_IN shop.v1.Cart.Item",
+    "shop.v1.Cart.Item.Price DEFINED_IN shop.v1",
+    "shop.v1.Cart.items USES_MSG_TYPE shop.v1.Cart.Item",
+    "shop.v1.Cart.by_sku USES_MSG_TYPE shop.v1.Cart.Item",
+    "shop.v1.Cart.Item.kind USES_ENUM_TYPE shop.v1.Cart.Item.Kind",
+    "shop.v1.Cart HAS_ONEOF shop.v1.Cart.payment",
+    "shop.v1.Cart.payment ONEOF_FIELD shop.v1.Cart.card_token",
+    "shop.v1.Cart.payment ONEOF_FIELD shop.v1.Cart.voucher",
+  }{
+    assert.True(t, edges[edge], "missing edge %s", edge)
+  }
+  assert.False(t, edges["shop.v1.Cart.payment ONEOF_FIELD shop.v1.Cart.id"])
+
+  // ->> Comments and 1-based source spans are kept on every node.
+  cart := nodes["Message shop.v1.Cart"]
+  assert.Equal(t, "shop.proto", cart.Props["file"])
+  assert.Equal(t, 8,            cart.Props["line"])
+  assert.Equal(t, 1,            cart.Props["column"])
+  assert.Equal(t, "Cart holds the items a customer intends to buy.\n  Items are keyed by SKU.", cart.Props["leadingComments"])
+
+  id := nodes["Parameter shop.v1.Cart.id"]
+  assert.Equal(t, "Opaque cart identifier.", id.Props["trailingComments"])
+  assert.Equal(t, 26, id.Props["line"])
+
+  pkg := nodes["Package shop.v1"]
+  assert.Equal(t, "Shopping cart API.", pkg.Props["leadingComments"])
+  assert.Equal(t, 4,                    pkg.Props["line"])
+
+  for _, key := range []string{
+    "Enum shop.v1.Cart.Item.Kind",
+    "EnumValue shop.v1.Cart.Item.Kind.KIND_DIGITAL",
+    "Oneof shop.v1.Cart.payment",
+  }{
+    assert.NotZero(t, nodes[key].Props["line"], key)
+  }
+}
+
+func TestProtoGraphMethodOptions(t *testing.T) {
+  ctx := context.Background()
+
+  files, err := proto.NewLocalFiles(ctx, "./rpc", []string{ "billing.proto" })
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err := files.ParseSchemaFiles(ctx); err != nil {
+    t.Fatal(err)
+  }
+  graph, err := files.Graph()
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  nodes := map[string]domain.GraphNode{}
+  for _, n := range graph.Nodes {
+    nodes[string(n.Label)+" "+n.Key] = n
+  }
+
+  svc := nodes["Service billing.v1.BillingService"]
+  assert.Equal(t, true, svc.Props["deprecated"])
+  assert.Equal(t, []string{ "billing.v1.owner_team=payments" }, svc.Props["options"])
+
+  tests := []struct{
+    method          string
+    clientStreaming bool
+    serverStreaming bool
+    idempotency     string
+    deprecated      bool
+    options         []string
+  }{
+    { "GetInvoice",    false, false, "NO_SIDE_EFFECTS",     false, []string{ "billing.v1.required_scope=billing.read" } },
+    { "Charge",        false, false, "IDEMPOTENCY_UNKNOWN", false, []string{ "billing.v1.audited=true", "billing.v1.required_scope=billing.write" } },
+    { "WatchInvoices", false, true,  "IDEMPOTENT",          false, []string{} },
+    { "UploadLines",   true,  false, "IDEMPOTENCY_UNKNOWN", true,  []string{} },
+  }
+  for _, tt := range tests {
+    t.Run(tt.method, func(t *testing.T){
+      node, ok := nodes["Method billing.v1.BillingService."+tt.method]
+      if !assert.True(t, ok) {
+        return
+      }
+      assert.Equal(t, tt.clientStreaming, node.Props["clientStreaming"])
+      assert.Equal(t, tt.serverStreaming, node.Props["serverStreaming"])
+      assert.Equal(t, tt.idempotency,     node.Props["idempotency"])
+      assert.Equal(t, tt.deprecated,      node.Props["deprecated"])
+      assert.Equal(t, tt.options,         node.Props["options"])
+    })
+  }
+}
+
+func TestProtoGraphSharedImports(t *testing.T) {
+  ctx := context.Background()
+
+  platform, err := proto.LoadPlatformImports("./shared/platform")
+  if err != nil {
+    t.Fatal(err)
+  }
+  files, err := proto.NewLocalFiles(
+    ctx,
+    "./shared/tenant",
+    []string{ "invoices.proto" },
+    proto.WithPlatformImports(platform),
+    proto.WithNamespace("acme"),
+    proto.WithSubject("invoices"),
+  )
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err := files.ParseSchemaFiles(ctx); err != nil {
+    t.Fatal(err)
+  }
+  graph, err := files.Graph()
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  nodes := map[string]domain.GraphNode{}
+  for _, n := range graph.Nodes {
+    nodes[string(n.Label)+" "+n.Key] = n
+  }
+  edges := map[string]bool{}
+  for _, e := range graph.Edges {
+    edges[e.FromKey+" "+string(e.Type)+" "+e.ToKey] = true
+  }
+
+  // ->> Tenant nodes are namespaced, shared nodes are not.
+  for _, key := range []string{
+    "Package acme/invoices.v1",
+    "Message acme/invoices.v1.Invoice",
+    "Method acme/invoices.v1.InvoiceService.GetInvoice",
+    "Package google.protobuf",
+    "Message google.protobuf.Timestamp",
+    "Message google.api.HttpRule",
+    "Enum google.api.FieldBehavior",
+    "Message platform.money.v1.Money",
+  }{
+    assert.Contains(t, nodes, key)
+  }
+  assert.NotContains(t, nodes, "Message acme/google.protobuf.Timestamp")
+  assert.Equal(t, true,  nodes["Package google.api"].Props["shared"])
+  assert.Equal(t, false, nodes["Package acme/invoices.v1"].Props["shared"])
+
+  // ->> Tenant nodes are owned by their Subject's Package, shared nodes are
+  //     only ever merged.
+  for key, node := range nodes {
+    if strings.HasPrefix(node.Key, "acme/") {
+      assert.Equal(t, "acme/invoices.v1#invoices", node.Owner, key)
+    } else {
+      assert.Empty(t, node.Owner, key)
+    }
+  }
+
+  for _, edge := range []string{
+    "acme/invoices.v1 IMPORTS google.protobuf",
+    "acme/invoices.v1 IMPORTS platform.money.v1",
+    "acme/invoices.v1.Invoice.issued_at USES_MSG_TYPE google.protobuf.Timestamp",
+    "acme/invoices.v1.Invoice.issued_at FROM_PACKAGE google.protobuf",
+    "acme/invoices.v1.Invoice.total USES_MSG_TYPE platform.money.v1.Money",
+  }{
+    assert.True(t, edges[edge], "missing edge %s", edge)
+  }
+
+  method := nodes["Method acme/invoices.v1.InvoiceService.GetInvoice"]
+  assert.Equal(t, []string{ `google.api.http={get:"/v1/invoices/{id}"}` }, method.Props["options"])
+}
+
+func TestProtoGraphSyntaxes(t *testing.T) {
+  ctx := context.Background()
+
+  files, err := proto.NewLocalFiles(ctx, "./syntax", []string{
+    "legacy.proto",
+    "editions.proto",
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err := files.ParseSchemaFiles(ctx); err != nil {
+    t.Fatal(err)
+  }
+  graph, err := files.Graph()
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  nodes := map[string]domain.GraphNode{}
+  for _, n := range graph.Nodes {
+    nodes[string(n.Label)+" "+n.Key] = n
+  }
+  edges := map[string]bool{}
+  for _, e := range graph.Edges {
+    edges[e.FromKey+" "+string(e.Type)+" "+e.ToKey] = true
+  }
+
+  assert.Equal(t, "proto2",   nodes["Package legacy.v1"].Props["syntax"])
+  assert.Equal(t, "editions", nodes["Package modern.v1"].Props["syntax"])
+  assert.Equal(t, "2023",     nodes["Package modern.v1"].Props["edition"])
+
+  tests := []struct{
+    key      string
+    presence string
+    dflt     string
+    encoding string
+  }{
+    { "Parameter legacy.v1.Account.id",      "required", "",          ""                },
+    { "Parameter legacy.v1.Account.region",  "explicit", "REGION_EU", ""                },
+    { "Parameter legacy.v1.Account.retries", "explicit", "3",         ""                },
+    { "Parameter legacy.v1.Account.scores",  "none",     "",          ""                },
+    { "Parameter legacy.v1.Account.profile", "explicit", "",          "delimited"       },
+    { "Parameter modern.v1.Plan.name",       "implicit", "",          ""                },
+    { "Parameter modern.v1.Plan.seats",      "explicit", "5",         ""                },
+    { "Parameter modern.v1.Plan.limits",     "explicit", "",          "delimited"       },
+    { "Parameter modern.v1.Plan.owner",      "required", "",          ""                },
+    { "Extension legacy.v1.nickname",        "explicit", "",          ""                },
+    { "Extension legacy.v1.Audit.last_audit","explicit", "",          "length_prefixed" },
+  }
+  for _, tt := range tests {
+    t.Run(tt.key, func(t *testing.T){
+      node, ok := nodes[tt.key]
+      if !assert.True(t, ok) {
+        return
+      }
+      assert.Equal(t, tt.presence, node.Props["presence"])
+      assert.Equal(t, tt.dflt,     node.Props["default"])
+      assert.Equal(t, tt.encoding, node.Props["encoding"])
+    })
+  }
+  assert.Equal(t, true, nodes["Parameter legacy.v1.Account.scores"].Props["packed"])
+  assert.Equal(t, true, nodes["Enum legacy.v1.Region"].Props["closed"])
+  assert.Equal(t, true, nodes["Enum modern.v1.Tier"].Props["closed"])
+  assert.Equal(t, []string{ "100-199" }, nodes["Message legacy.v1.Account"].Props["extensionRanges"])
+
+  for _, edge := range []string{
+    "legacy.v1.nickname EXTENDS legacy.v1.Account",
+    "legacy.v1.nickname DEFINED_IN legacy.v1",
+    "legacy.v1.Audit.last_audit EXTENDS legacy.v1.Account",
+    "legacy.v1.Audit.last_audit NESTED_IN legacy.v1.Audit",
+    "legacy.v1.Audit.last_audit USES_MSG_TYPE legacy.v1.Audit",
+    "legacy.v1.Account.profile USES_MSG_TYPE legacy.v1.Account.Profile",
+  }{
+    assert.True(t, edges[edge], "missing edge %s", edge)
+  }
+}
+
+func TestProtoGraphVersioning(t *testing.T) {
+  ctx := context.Background()
+
+  compile := func(vc domain.VersionContext)( *domain.SchemaGraph, error ){
+    files, err := proto.NewLocalFiles(
+      ctx,
+      "./versioning",
+      []string{ "catalog.proto" },
+      proto.WithVersioning(vc),
+      proto.WithNamespace("acme"),
+    )
+    if err != nil {
+      return nil, err
+    }
+    if err := files.ParseSchemaFiles(ctx); err != nil {
+      return nil, err
+    }
+    return files.Graph()
+  }
+
+  // ->> Unversioned packages are rejected by the default scheme, without panicking.
+  _, err := compile(domain.VersionContext{})
+  assert.ErrorContains(t, err, "invalid version")
+
+  tests := []struct{
+    name      string
+    vc        domain.VersionContext
+    suffix    string
+    version   string
+    stability string
+    major     int
+    minor     int
+  }{
+    {
+      name      : "registry",
+      vc        : domain.VersionContext{ Scheme: domain.VersioningRegistry, Registry: 4 },
+      suffix    : "@4",
+      version   : "4",
+      stability : "stable",
+      major     : 4,
+    },
+    {
+      name      : "semver",
+      vc        : domain.VersionContext{ Scheme: domain.VersioningSemver, Tag: "v2.1.0-beta.3" },
+      suffix    : "@2.1.0-beta.3",
+      version   : "2.1.0-beta.3",
+      stability : "beta",
+      major     : 2,
+      minor     : 1,
+    },
+  }
+
+  for _, tt := range tests {
+    t.Run(tt.name, func(t *testing.T){
+      graph, err := compile(tt.vc)
+      if !assert.NoError(t, err) {
+        return
+      }
+      nodes := map[string]domain.GraphNode{}
+      for _, n := range graph.Nodes {
+        nodes[string(n.Label)+" "+n.Key] = n
+      }
+
+      pkg, ok := nodes["Package acme/catalog"+tt.suffix]
+      if !assert.True(t, ok, "missing package acme/catalog%s", tt.suffix) {
+        return
+      }
+      assert.Equal(t, "catalog",    pkg.Props["name"])
+      assert.Equal(t, tt.version,   pkg.Props["version"])
+      assert.Equal(t, tt.stability, pkg.Props["stability"])
+      assert.Equal(t, tt.major,     pkg.Props["versionMajor"])
+      assert.Equal(t, tt.minor,     pkg.Props["versionMinor"])
+
+      // ->> The version suffix ends every tenant key, including enum values.
+      assert.Contains(t, nodes, "Message acme/catalog.Product"+tt.suffix)
+      assert.Contains(t, nodes, "EnumValue acme/catalog.Kind.KIND_PHYSICAL"+tt.suffix)
+    })
+  }
+}
+
+func TestCypherWriter(t *testing.T) {
+  graph := compileTestGraph(t)
+
+  statements, err := cypher.NewWriter(2).Statements(graph)
+  assert.NoError(t, err)
+
+  // ->> Every Package first drops what it no longer holds.
+  owners, _ := graph.Owners()
+  assert.NotEmpty(t, owners)
+  for i, owner := range owners {
+    assert.Equal(t, owner, statements[2*i].Params["owner"])
+    assert.Contains(t, statements[2*i].Query, "DETACH DELETE")
+    assert.Equal(t, owner, statements[2*i+1].Params["owner"])
+  }
+  statements = statements[2*len(owners):]
+
+  seenEdge := false
+  rows     := 0
+  for _, s := range statements {
+    batch, ok := s.Params["rows"].([]any)
+    if !assert.True(t, ok) {
+      return
+    }
+    assert.LessOrEqual(t, len(batch), 2)
+
+    // ->> Every node statement must come before any relationship statement.
+    isEdge := len(batch) != 0 && batch[0].(map[string]any)["from"] != nil
+    assert.False(t, seenEdge && !isEdge, "node statement after relationship statement")
+    seenEdge = seenEdge || isEdge
+    rows += len(batch)
+  }
+  assert.Equal(t, len(graph.Nodes)+len(graph.Edges), rows)
+
+  // ->> User controlled names only ever travel as parameters.
+  for _, s := range statements {
+    assert.NotContains(t, s.Query, "v1alpha")
+  }
+
+  // ->> Labels are interpolated, so anything but a plain identifier is rejected.
+  _, err = cypher.NewWriter(0).Statements(&domain.SchemaGraph{
+    Nodes: []domain.GraphNode{{ Label: "Package` {x:1}) DETACH DELETE n //", Key: "k" }},
+  })
+  assert.ErrorIs(t, err, cypher.ErrInvalidIdentifier)
+}
+
+func TestCypherWriterOwners(t *testing.T) {
+  ctx := context.Background()
+  compile := func(subject, orders string) *domain.SchemaGraph {
+    money, err := os.ReadFile("./impact/acme/common/v1/money.proto")
+    if err != nil {
+      t.Fatal(err)
+    }
+    files, err := proto.NewSourceFiles(ctx, map[string][]byte{
+      "acme/common/v1/money.proto"  : money,
+      "acme/orders/v1/orders.proto" : []byte(orders),
+    }, proto.WithNamespace("acme"), proto.WithSubject(subject))
+    if err != nil {
+      t.Fatal(err)
+    }
+    if err := files.ParseSchemaFiles(ctx); err != nil {
+      t.Fatal(err)
+    }
+    graph, err := files.Graph()
+    if err != nil {
+      t.Fatal(err)
+    }
+    return graph
+  }
+
+  // ->> The field removed since the last write isn't held anymore, so the
+  //     cleanup statement drops it along with its relationships.
+  graph := compile("orders", `syntax = "proto3";
+package acme.orders.v1;
+import "acme/common/v1/money.proto";
+import "google/protobuf/timestamp.proto";
+message Order {
+  string                    id         = 1;
+  google.protobuf.Timestamp created_at = 2;
+}`)
+  statements, err := cypher.NewWriter(0).Statements(graph)
+  if !assert.NoError(t, err) {
+    return
+  }
+
+  cleanup := map[string][]domain.CypherStatement{}
+  for _, s := range statements {
+    if owner, ok := s.Params["owner"].(string); ok {
+      cleanup[owner] = append(cleanup[owner], s)
+    }
+  }
+  assert.Len(t, cleanup, 2)
+  orders := cleanup["acme/acme.orders.v1#orders"]
+  if !assert.Len(t, orders, 2) {
+    return
+  }
+  assert.Equal(t,
+    "MATCH (n:Owned {owner: $owner})\n"+
+    "WHERE NONE(l IN labels(n) WHERE l + ':' + n.key IN $refs)\n"+
+    "DETACH DELETE n",
+    orders[0].Query,
+  )
+  refs := orders[0].Params["refs"].([]string)
+  assert.Contains(t, refs, "Parameter:acme/acme.orders.v1.Order.id")
+  assert.Contains(t, refs, "Parameter:acme/acme.orders.v1.Order.created_at")
+  for _, ref := range refs {
+    assert.NotContains(t, ref, "google.protobuf")
+  }
+  assert.Equal(t,
+    "MATCH (n:Owned {owner: $owner})-[r]->()\n"+
+    "DELETE r",
+    orders[1].Query,
+  )
+  assert.Contains(t, cleanup, "acme/acme.common.v1#orders")
+
+  // ->> Only owned nodes are labeled, and only they are matched by Owner.
+  for _, s := range statements {
+    rows, ok := s.Params["rows"].([]any)
+    if !ok || rows[0].(map[string]any)["key"] == nil {
+      continue
+    }
+    for _, row := range rows {
+      props := row.(map[string]any)["props"].(map[string]any)
+      owned := strings.HasSuffix(s.Query, ", n:Owned")
+      assert.Equal(t, owned, props["owner"] != nil, row.(map[string]any)["key"])
+    }
+    if strings.Contains(s.Query, "`Package`") && !strings.HasSuffix(s.Query, ", n:Owned") {
+      assert.Equal(t, "google.protobuf", rows[0].(map[string]any)["key"])
+    }
+  }
+
+  // ->> Subjects sharing a Package clean up under Owners of their own.
+  other, err := cypher.NewWriter(0).Statements(compile("archive", `syntax = "proto3";
+package acme.orders.v1;
+message Order {
+  string id = 1;
+}`))
+  if !assert.NoError(t, err) {
+    return
+  }
+  for _, s := range other {
+    if owner, ok := s.Params["owner"].(string); ok {
+      assert.NotContains(t, cleanup, owner)
+    }
+  }
+}
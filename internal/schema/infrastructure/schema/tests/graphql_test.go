@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/graphql"
+)
+
+func TestGraphQLGraph(t *testing.T) {
+  ctx := context.Background()
+
+  files, err := graphql.NewLocalFiles(ctx, "./graphql", []string{
+    "schema.graphql",
+    "extensions.graphql",
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err := files.ParseSchemaFiles(ctx); err != nil {
+    t.Fatal(err)
+  }
+  graph, err := files.Graph()
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  nodes := map[string]domain.GraphNode{}
+  for _, n := range graph.Nodes {
+    nodes[string(n.Label)+" "+n.Key] = n
+  }
+  edges := map[string]bool{}
+  for _, e := range graph.Edges {
+    edges[e.FromKey+" "+string(e.Type)+" "+e.ToKey] = true
+  }
+
+  for _, key := range []string{
+    "GraphQLType User",
+    "GraphQLType SearchResult",
+    "GraphQLType UpdateUserInput",
+    "GraphQLField User.name",
+    // ->> Fields added through 'extend type' in another file.
+    "GraphQLField User.team",
+    "GraphQLOperation Query.team",
+    "GraphQLOperation Mutation.updateUser",
+    "GraphQLArgument User.friends.first",
+    "GraphQLEnumValue Role.GUEST",
+    "GraphQLDirective @auth",
+    "GraphQLArgument @auth.role",
+  }{
+    assert.Contains(t, nodes, key)
+  }
+  assert.NotContains(t, nodes, "GraphQLType __Schema")
+  assert.NotContains(t, nodes, "GraphQLDirective @deprecated")
+
+  assert.Equal(t, "mutation",  nodes["GraphQLOperation Mutation.updateUser"].Props["operation"])
+  assert.Equal(t, "[User!]!",  nodes["GraphQLField User.friends"].Props["type"])
+  assert.Equal(t, true,        nodes["GraphQLEnumValue Role.GUEST"].Props["deprecated"])
+  assert.Equal(t, "extensions.graphql", nodes["GraphQLType Team"].Props["file"])
+
+  for _, edge := range []string{
+    "User IMPLEMENTS Node",
+    "Team IMPLEMENTS Node",
+    "SearchResult UNION_MEMBER User",
+    "SearchResult UNION_MEMBER Team",
+    "User HAS_FIELD User.role",
+    "User.role RETURNS Role",
+    "User.friends RETURNS User",
+    "User.friends HAS_ARGUMENT User.friends.first",
+    "User.friends.first ARG_TYPE Int",
+    "Query.user ROOT_FIELD Query",
+    "Query.search RETURNS SearchResult",
+    "Mutation.updateUser.input ARG_TYPE UpdateUserInput",
+    "UpdateUserInput.role ARG_TYPE Role",
+    "Role HAS_VALUE Role.ADMIN",
+    "User USES_DIRECTIVE @auth",
+    "Mutation.updateUser USES_DIRECTIVE @auth",
+  }{
+    assert.True(t, edges[edge], "missing edge %s", edge)
+  }
+
+  _, err = files.Cyphers()
+  assert.NoError(t, err)
+}
+
+func TestGraphQLInvalidSchema(t *testing.T) {
+  ctx := context.Background()
+
+  files, err := graphql.NewSourceFiles(ctx, map[string][]byte{
+    "broken.graphql": []byte(`type Query { user: Missing }`),
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+  assert.ErrorIs(t, files.ParseSchemaFiles(ctx), graphql.ErrSchemaInvalid)
+}
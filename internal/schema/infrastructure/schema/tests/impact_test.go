@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/proto"
+)
+
+func TestImpactAnalysis(t *testing.T) {
+  ctx := context.Background()
+  files, err := proto.NewLocalFiles(
+    ctx,
+    "./impact",
+    []string{
+      "acme/common/v1/money.proto",
+      "acme/orders/v1/orders.proto",
+      "acme/billing/v1/billing.proto",
+    },
+  )
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err := files.ParseSchemaFiles(ctx); err != nil {
+    t.Fatal(err)
+  }
+  graph, err := files.Graph()
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  keys := func(nodes []domain.ImpactedNode) []string {
+    found := []string{}
+    for _, n := range nodes {
+      found = append(found, string(n.Label)+" "+n.Key)
+    }
+    return found
+  }
+  pathOf := func(nodes []domain.ImpactedNode, key string) string {
+    for _, n := range nodes {
+      if n.Key == key {
+        steps := []string{}
+        for _, step := range n.Path {
+          steps = append(steps, string(step.Edge)+" "+step.Node.Key)
+        }
+        return strings.Join(steps, " -> ")
+      }
+    }
+    return ""
+  }
+
+  t.Run("message", func(t *testing.T){
+    report, err := graph.Impact(ctx, "", domain.NodeRef{
+      Label : domain.LabelMessage,
+      Key   : "acme.common.v1.Money",
+    }, 0)
+    assert.NoError(t, err)
+
+    assert.Equal(t, []string{
+      "Parameter acme.orders.v1.LineItem.price",
+      "Parameter acme.orders.v1.Order.total",
+      "Message acme.orders.v1.LineItem",
+      "Message acme.orders.v1.Order",
+      "Parameter acme.billing.v1.Invoice.order",
+      "Parameter acme.orders.v1.Order.items",
+      "Method acme.orders.v1.OrderService.GetOrder",
+      "Message acme.billing.v1.Invoice",
+    }, keys(report.Elements))
+    assert.Equal(t, []string{
+      "Service acme.orders.v1.OrderService",
+    }, keys(report.Services))
+    assert.Equal(t, []string{
+      "Package acme.common.v1",
+      "Package acme.billing.v1",
+      "Package acme.orders.v1",
+    }, keys(report.Packages))
+
+    assert.Equal(t,
+      "USES_MSG_TYPE acme.orders.v1.Order.total -> HAS_PARAMETER acme.orders.v1.Order -> "+
+      "OUTPUT acme.orders.v1.OrderService.GetOrder -> RPC_METHOD acme.orders.v1.OrderService",
+      pathOf(report.Services, "acme.orders.v1.OrderService"),
+    )
+  })
+
+  t.Run("enum, limited depth", func(t *testing.T){
+    report, err := graph.Impact(ctx, "", domain.NodeRef{
+      Label : domain.LabelEnum,
+      Key   : "acme.common.v1.Region",
+    }, 2)
+    assert.NoError(t, err)
+    assert.Equal(t, []string{
+      "Parameter acme.billing.v1.Invoice.region",
+      "Message acme.billing.v1.Invoice",
+    }, keys(report.Elements))
+    assert.Empty(t, report.Services)
+  })
+
+  t.Run("field", func(t *testing.T){
+    report, err := graph.Impact(ctx, "", domain.NodeRef{
+      Label : domain.LabelParameter,
+      Key   : "acme.orders.v1.GetOrderRequest.id",
+    }, 3)
+    assert.NoError(t, err)
+    assert.Equal(t, []string{
+      "Message acme.orders.v1.GetOrderRequest",
+      "Method acme.orders.v1.OrderService.GetOrder",
+    }, keys(report.Elements))
+    assert.Equal(t, []string{ "Service acme.orders.v1.OrderService" }, keys(report.Services))
+  })
+
+  t.Run("unknown target", func(t *testing.T){
+    _, err := graph.Impact(ctx, "", domain.NodeRef{
+      Label : domain.LabelMessage,
+      Key   : "acme.orders.v1.Missing",
+    }, 0)
+    assert.ErrorIs(t, err, domain.ErrImpactTargetNotFound)
+  })
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/proto"
+)
+
+func TestLint(t *testing.T) {
+  files, err := proto.NewLocalFiles(
+    context.Background(),
+    "./lint",
+    []string{ "acme/orders/v1/orders.proto", "misplaced.proto" },
+  )
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  findings := func(config domain.LintConfig) []string {
+    found := []string{}
+    for _, d := range files.Lint(config) {
+      assert.Equal(t, domain.SeverityWarning, d.Severity)
+      found = append(found, d.Rule+" "+d.Path)
+    }
+    return found
+  }
+
+  tests := []struct{
+    name   string
+    config domain.LintConfig
+    want   []string
+  }{
+    {
+      name   : "minimal",
+      config : domain.LintConfig{ RuleSet: domain.LintMinimal },
+      want   : []string{
+        "PACKAGE_DIRECTORY_MATCH acme.billing.v1",
+      },
+    },
+    {
+      name   : "default",
+      config : domain.DefaultLintConfig,
+      want   : []string{
+        "FIELD_LOWER_SNAKE_CASE acme.orders.v1.Order.orderId",
+        "ENUM_VALUE_PREFIX acme.orders.v1.UNKNOWN",
+        "ENUM_ZERO_VALUE_SUFFIX acme.orders.v1.UNKNOWN",
+        "MESSAGE_PASCAL_CASE acme.orders.v1.order_line",
+        "SERVICE_SUFFIX acme.orders.v1.Orders",
+        "RPC_RESPONSE_STANDARD_NAME acme.orders.v1.Orders.GetOrder",
+        "RPC_REQUEST_RESPONSE_UNIQUE acme.orders.v1.Orders.ListOrders",
+        "RPC_REQUEST_STANDARD_NAME acme.orders.v1.Orders.ListOrders",
+        "PACKAGE_DIRECTORY_MATCH acme.billing.v1",
+      },
+    },
+    {
+      name   : "default with excepted rules",
+      config : domain.LintConfig{
+        RuleSet : domain.LintDefault,
+        Except  : []string{ "ENUM_VALUE_PREFIX", "ENUM_ZERO_VALUE_SUFFIX", "SERVICE_SUFFIX" },
+        Ignore  : []string{ "acme/orders" },
+      },
+      want   : []string{
+        "PACKAGE_DIRECTORY_MATCH acme.billing.v1",
+      },
+    },
+    {
+      name   : "custom comment rules",
+      config : domain.LintConfig{
+        RuleSet : domain.LintCustom,
+        Rules   : []string{ "COMMENT_MESSAGE", "COMMENT_EVERYTHING" },
+        Ignore  : []string{ "misplaced.proto" },
+      },
+      want   : []string{
+        "COMMENT_EVERYTHING ",
+        "COMMENT_MESSAGE acme.orders.v1.order_line",
+        "COMMENT_MESSAGE acme.orders.v1.GetOrderRequest",
+        "COMMENT_MESSAGE acme.orders.v1.ListOrdersResponse",
+      },
+    },
+  }
+
+  for _, tt := range tests {
+    t.Run(tt.name, func(t *testing.T){
+      assert.Equal(t, tt.want, findings(tt.config))
+    })
+  }
+
+  // ->> Findings point at the offending declaration.
+  for _, d := range files.Lint(domain.DefaultLintConfig) {
+    if d.Rule == "FIELD_LOWER_SNAKE_CASE" {
+      assert.Equal(t, "acme/orders/v1/orders.proto", d.File)
+      assert.Equal(t, 7, d.Line)
+      assert.Equal(t, 3, d.Column)
+    }
+  }
+}
+
+func TestLintDescriptorSet(t *testing.T) {
+  ctx   := context.Background()
+  paths := []string{ "acme/orders/v1/orders.proto", "misplaced.proto" }
+  sources, err := proto.NewLocalFiles(ctx, "./lint", paths)
+  if err != nil {
+    t.Fatal(err)
+  }
+  config := domain.LintConfig{
+    RuleSet : domain.LintCustom,
+    Rules   : proto.LintRuleIDs(),
+  }
+
+  rules := func(diagnostics []domain.Diagnostic) []string {
+    found := []string{}
+    for _, d := range diagnostics {
+      found = append(found, d.Rule+" "+d.Path)
+    }
+    return found
+  }
+  lint := func(opts domain.DescriptorOptions) []domain.Diagnostic {
+    data, err := sources.FileDescriptorSet(opts)
+    if err != nil {
+      t.Fatal(err)
+    }
+    schema, err := proto.NewSchemaLoader()(ctx, domain.LoadContext{}, map[string][]byte{ domain.DescriptorSetFile: data })
+    if err != nil {
+      t.Fatal(err)
+    }
+    return schema.(domain.Lintable).Lint(config)
+  }
+
+  // ->> With source info, a descriptor set lints exactly like its sources.
+  assert.Equal(t, sources.Lint(config), lint(domain.DescriptorOptions{ SourceInfo: true }))
+
+  // ->> Without it there are no comments to check, so only the comment
+  //     rules go quiet. Findings without a line sort differently.
+  want := []string{}
+  for _, rule := range rules(sources.Lint(config)) {
+    if !strings.HasPrefix(rule, "COMMENT_") {
+      want = append(want, rule)
+    }
+  }
+  assert.NotEqual(t, len(want), len(rules(sources.Lint(config))))
+  assert.ElementsMatch(t, want, rules(lint(domain.DescriptorOptions{})))
+}
@@ -66,7 +66,7 @@ func main(){
   }
 
   for _, c := range cyphers {
-    fmt.Println(c)
+    fmt.Println(c.Query)
   }
 
   fmt.Printf(" ------- Total Time: %dms ------- \n", time.Since(start).Milliseconds())
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/openapi"
+)
+
+func TestOpenAPIGraph(t *testing.T) {
+  ctx := context.Background()
+
+  files, err := openapi.NewLocalFiles(ctx, "./openapi", []string{ "users.yaml" })
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err := files.ParseSchemaFiles(ctx); err != nil {
+    t.Fatal(err)
+  }
+  graph, err := files.Graph()
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  nodes := map[string]domain.GraphNode{}
+  for _, n := range graph.Nodes {
+    nodes[string(n.Label)+" "+n.Key] = n
+  }
+  edges := map[string]bool{}
+  for _, e := range graph.Edges {
+    edges[e.FromKey+" "+string(e.Type)+" "+e.ToKey] = true
+  }
+
+  const (
+    getUser    = "users.yaml#/paths/~1users~1{id}/get"
+    updateUser = "users.yaml#/paths/~1users~1{id}/put"
+    user       = "users.yaml#/components/schemas/User"
+    address    = "common.yaml#/components/schemas/Address"
+    apiError   = "common.yaml#/components/schemas/Error"
+  )
+
+  for _, key := range []string{
+    "API users.yaml#",
+    "APIPath users.yaml#/paths/~1users~1{id}",
+    "Operation " + getUser,
+    "APIParameter " + getUser + "/parameters/path/id",
+    "APIParameter " + updateUser + "/parameters/query/dryRun",
+    "RequestBody " + updateUser + "/requestBody",
+    "Response " + getUser + "/responses/404",
+    "ComponentSchema " + user,
+    "ComponentSchema " + address,
+    "ComponentSchema users.yaml#/components/schemas/Unused",
+  }{
+    assert.Contains(t, nodes, key)
+  }
+  assert.Equal(t, "PUT", nodes["Operation "+updateUser].Props["method"])
+  assert.Equal(t, true,  nodes["Operation "+updateUser].Props["deprecated"])
+
+  for _, edge := range []string{
+    "users.yaml# HAS_PATH users.yaml#/paths/~1users~1{id}",
+    "users.yaml#/paths/~1users~1{id} HAS_OPERATION " + getUser,
+    // ->> Path level parameters are inherited by every operation.
+    updateUser + " HAS_PARAMETER " + updateUser + "/parameters/path/id",
+    getUser + "/responses/200 USES_SCHEMA " + user,
+    // ->> $refs across files resolve relative to the referencing document.
+    getUser + "/responses/404 USES_SCHEMA " + apiError,
+    user + " USES_SCHEMA " + address,
+    // ->> Recursive schemas terminate.
+    user + " USES_SCHEMA " + user,
+    // ->> Inline schemas link straight to the components they reference.
+    updateUser + "/requestBody USES_SCHEMA " + user,
+  }{
+    assert.True(t, edges[edge], "missing edge %s", edge)
+  }
+
+  _, err = files.Cyphers()
+  assert.NoError(t, err)
+}
+
+func TestOpenAPIUnresolvedRef(t *testing.T) {
+  ctx := context.Background()
+
+  files, err := openapi.NewSourceFiles(ctx, map[string][]byte{
+    "api.json": []byte(`{
+      "openapi": "3.1.0",
+      "info": { "title": "Broken", "version": "1" },
+      "paths": { "/": { "get": { "responses": { "200": {
+        "description": "ok",
+        "content": { "application/json": { "schema": { "$ref": "missing.json#/Thing" } } }
+      }}}}}
+    }`),
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+  assert.ErrorIs(t, files.ParseSchemaFiles(ctx), openapi.ErrDocumentLoadFailed)
+}
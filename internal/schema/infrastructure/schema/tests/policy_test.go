@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TylerAldrich814/Fidicus/internal/schema/domain"
+	"github.com/TylerAldrich814/Fidicus/internal/schema/infrastructure/schema/proto"
+)
+
+func TestEvaluatePolicies(t *testing.T) {
+  files, err := proto.NewLocalFiles(
+    context.Background(),
+    "./policy",
+    []string{ "billing/v1/invoice.proto", "catalog/v1/product.proto" },
+  )
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  tenantID := domain.Policy{
+    Name        : "billing-tenant-id",
+    Description : "billing messages must have a tenant_id field",
+    Target      : domain.PolicyMessage,
+    Packages    : []string{ "billing.*" },
+    Expression  : `message.fields.exists(f, f.name == "tenant_id")`,
+  }
+  noEmpty := domain.Policy{
+    Name       : "no-empty-response",
+    Target     : domain.PolicyMethod,
+    Expression : `method.output != "google.protobuf.Empty"`,
+    Severity   : domain.SeverityWarning,
+  }
+
+  findings := func(policies ...domain.Policy) []string {
+    found := []string{}
+    for _, d := range files.EvaluatePolicies(policies) {
+      found = append(found, string(d.Severity)+" "+d.Rule+" "+d.Path)
+    }
+    return found
+  }
+
+  tests := []struct{
+    name     string
+    policies []domain.Policy
+    want     []string
+  }{
+    {
+      name     : "package scoped message policy",
+      policies : []domain.Policy{ tenantID },
+      want     : []string{
+        "error billing-tenant-id billing.v1.Invoice.Line",
+        "error billing-tenant-id billing.v1.Payment",
+      },
+    },
+    {
+      name     : "method policy",
+      policies : []domain.Policy{ noEmpty },
+      want     : []string{
+        "warning no-empty-response billing.v1.InvoiceService.VoidInvoice",
+        "warning no-empty-response catalog.v1.ProductService.Ping",
+      },
+    },
+    {
+      name     : "file policy",
+      policies : []domain.Policy{{
+        Name       : "no-empty-import",
+        Target     : domain.PolicyFile,
+        Expression : `file.package == "billing.v1" || !("google/protobuf/empty.proto" in file.imports)`,
+      }},
+      want     : []string{
+        "error no-empty-import catalog/v1/product.proto",
+      },
+    },
+    {
+      name     : "broken policies are reported once",
+      policies : []domain.Policy{
+        { Name: "unbalanced", Target: domain.PolicyMessage, Expression: `message.fields.size(` },
+        { Name: "not-a-bool", Target: domain.PolicyMessage, Expression: `message.name` },
+        { Name: "missing-key", Target: domain.PolicyField, Expression: `field.nope == 1` },
+      },
+      want     : []string{
+        "warning missing-key ",
+        "warning not-a-bool ",
+        "warning unbalanced ",
+      },
+    },
+  }
+
+  for _, tt := range tests {
+    t.Run(tt.name, func(t *testing.T){
+      assert.Equal(t, tt.want, findings(tt.policies...))
+    })
+  }
+
+  // ->> Violations point at the offending declaration, with the Policy's description.
+  for _, d := range files.EvaluatePolicies([]domain.Policy{ tenantID }) {
+    if d.Path == "billing.v1.Payment" {
+      assert.Equal(t, "billing/v1/invoice.proto", d.File)
+      assert.Equal(t, 17, d.Line)
+      assert.Equal(t, "billing.v1.Payment: billing messages must have a tenant_id field", d.Message)
+    }
+  }
+}
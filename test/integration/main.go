@@ -48,7 +48,7 @@ func main(){
 
   dbConfig, err := config.GetPgsqlConfig("-auth")
   if err != nil {
-    log.Fatal("Failed to get Database Config: %s", err.Error())
+    log.Fatalf("Failed to get Database Config: %s", err.Error())
   }
   authURI := dbConfig.GetPostgresURI()
 